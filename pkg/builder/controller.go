@@ -24,6 +24,7 @@ import (
 
 	"github.com/go-logr/logr"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 
@@ -34,6 +35,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/sharding"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
@@ -64,6 +66,7 @@ type TypedBuilder[request comparable] struct {
 	ctrl             controller.TypedController[request]
 	ctrlOptions      controller.TypedOptions[request]
 	name             string
+	ring             *sharding.Ring
 	newController    func(name string, mgr manager.Manager, options controller.TypedOptions[request]) (controller.TypedController[request], error)
 }
 
@@ -256,6 +259,19 @@ func (blder *TypedBuilder[request]) Named(name string) *TypedBuilder[request] {
 	return blder
 }
 
+// WithSharding makes the controller only reconcile the objects in the shards of
+// the given ring that are owned by this replica. It requires For() and can only
+// be used with reconcile.Request.
+//
+// Events for the For() object are filtered by the ring's predicate, all requests
+// regardless of their source are filtered before they reach the reconciler, and
+// the objects of a shard are enqueued once this replica acquires it.
+// The ring must be added to the manager separately.
+func (blder *TypedBuilder[request]) WithSharding(ring *sharding.Ring) *TypedBuilder[request] {
+	blder.ring = ring
+	return blder
+}
+
 // Complete builds the Application Controller.
 func (blder *TypedBuilder[request]) Complete(r reconcile.TypedReconciler[request]) error {
 	_, err := blder.Build(r)
@@ -320,10 +336,25 @@ func (blder *TypedBuilder[request]) doWatch() error {
 		reflect.ValueOf(&hdler).Elem().Set(reflect.ValueOf(&handler.EnqueueRequestForObject{}))
 		allPredicates := append([]predicate.Predicate(nil), blder.globalPredicates...)
 		allPredicates = append(allPredicates, blder.forInput.predicates...)
+		if blder.ring != nil {
+			allPredicates = append(allPredicates, blder.ring.Predicate())
+		}
 		src := source.TypedKind(blder.mgr.GetCache(), obj, hdler, allPredicates...)
 		if err := blder.ctrl.Watch(src); err != nil {
 			return err
 		}
+
+		if blder.ring != nil {
+			list, err := blder.listFor(obj)
+			if err != nil {
+				return err
+			}
+			var shardSrc source.TypedSource[request]
+			reflect.ValueOf(&shardSrc).Elem().Set(reflect.ValueOf(blder.ring.Source(blder.mgr.GetCache(), list)))
+			if err := blder.ctrl.Watch(shardSrc); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Watches the managed types
@@ -377,6 +408,34 @@ func (blder *TypedBuilder[request]) doWatch() error {
 	return nil
 }
 
+// listFor returns an empty list for the given, possibly projected, object.
+func (blder *TypedBuilder[request]) listFor(obj client.Object) (client.ObjectList, error) {
+	gvk, err := apiutil.GVKForObject(obj, blder.mgr.GetScheme())
+	if err != nil {
+		return nil, err
+	}
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+	switch obj.(type) {
+	case *metav1.PartialObjectMetadata:
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(listGVK)
+		return list, nil
+	case *unstructured.Unstructured:
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(listGVK)
+		return list, nil
+	}
+	listObj, err := blder.mgr.GetScheme().New(listGVK)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create list for %T: %w", obj, err)
+	}
+	list, ok := listObj.(client.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement client.ObjectList", listObj)
+	}
+	return list, nil
+}
+
 func (blder *TypedBuilder[request]) getControllerName(gvk schema.GroupVersionKind, hasGVK bool) (string, error) {
 	if blder.name != "" {
 		return blder.name, nil
@@ -398,6 +457,21 @@ func (blder *TypedBuilder[request]) doController(r reconcile.TypedReconciler[req
 		ctrlOptions.Reconciler = r
 	}
 
+	if blder.ring != nil {
+		if blder.forInput.object == nil {
+			return errors.New("WithSharding() can only be used together with For()")
+		}
+		reconciler, ok := any(ctrlOptions.Reconciler).(reconcile.Reconciler)
+		if !ok {
+			return fmt.Errorf("WithSharding() can only be used with reconcile.Request, got %T", *new(request))
+		}
+		obj, err := blder.project(blder.forInput.object, blder.forInput.objectProjection)
+		if err != nil {
+			return err
+		}
+		ctrlOptions.Reconciler = any(blder.ring.Reconciler(reconciler, blder.mgr.GetCache(), obj)).(reconcile.TypedReconciler[request])
+	}
+
 	// Retrieve the GVK from the object we're reconciling
 	// to pre-populate logger information, and to optionally generate a default name.
 	var gvk schema.GroupVersionKind
@@ -45,6 +45,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/sharding"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
@@ -179,6 +180,69 @@ var _ = Describe("application", func() {
 			// manifest when we try to default the controller name, which is good to double check.
 		})
 
+		It("should return an error when using WithSharding without For", func() {
+			By("creating a controller manager")
+			m, err := manager.New(cfg, manager.Options{})
+			Expect(err).NotTo(HaveOccurred())
+			ring, err := sharding.New(m, sharding.Options{Name: "sharded-without-for", Namespace: "default", Identity: "a"})
+			Expect(err).NotTo(HaveOccurred())
+
+			instance, err := ControllerManagedBy(m).
+				Named("sharded_without_for").
+				Watches(&appsv1.ReplicaSet{}, &handler.EnqueueRequestForObject{}).
+				WithSharding(ring).
+				Build(noop)
+			Expect(err).To(MatchError(ContainSubstring("WithSharding() can only be used together with For()")))
+			Expect(instance).To(BeNil())
+		})
+
+		It("should only reconcile the objects of owned shards when using WithSharding", func(ctx SpecContext) {
+			By("creating a controller manager")
+			m, err := manager.New(cfg, manager.Options{})
+			Expect(err).NotTo(HaveOccurred())
+			ring, err := sharding.New(m, sharding.Options{Name: "sharded-builder", Namespace: "default", Identity: "a", Shards: 4})
+			Expect(err).NotTo(HaveOccurred())
+
+			var reconciled atomic.Int32
+			var options controller.Options
+			builder := ControllerManagedBy(m).
+				For(&appsv1.ReplicaSet{}).
+				Named("sharded_builder").
+				WithSharding(ring)
+			builder.newController = func(name string, mgr manager.Manager, opts controller.Options) (controller.Controller, error) {
+				options = opts
+				return controller.New(name, mgr, opts)
+			}
+			_, err = builder.Build(reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+				reconciled.Add(1)
+				return reconcile.Result{}, nil
+			}))
+			Expect(err).NotTo(HaveOccurred())
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "rs"}}
+			By("skipping requests while the shard is not owned")
+			_, err = options.Reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciled.Load()).To(BeZero())
+
+			By("reconciling requests once the ring acquired all shards")
+			ringCtx, cancel := context.WithCancel(ctx)
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				Expect(ring.Start(ringCtx)).To(Succeed())
+			}()
+			defer func() {
+				cancel()
+				<-done
+			}()
+			Eventually(ring.OwnedShards).Should(HaveLen(4))
+			_, err = options.Reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciled.Load()).To(BeEquivalentTo(1))
+		})
+
 		It("should return error if in For is used with a custom request type", func() {
 			By("creating a controller manager")
 			m, err := manager.New(cfg, manager.Options{})
@@ -42,7 +42,8 @@ import (
 )
 
 var (
-	defaultSyncPeriod = 10 * time.Hour
+	defaultSyncPeriod       = 10 * time.Hour
+	defaultSnapshotInterval = 5 * time.Minute
 )
 
 // InformerGetOptions defines the behavior of how informers are retrieved.
@@ -142,6 +143,14 @@ type Options struct {
 	// Mapper is the RESTMapper to use for mapping GroupVersionKinds to Resources
 	Mapper meta.RESTMapper
 
+	// ContentType is the content type the informers use to list and watch
+	// types that support it, i.e. types registered with
+	// apiutil.AddToProtobufScheme, which includes all built-in types.
+	// Custom resources and unstructured objects always use JSON.
+	// Defaults to the ContentType of the rest.Config. If that is unset too,
+	// protobuf is used for all types that support it.
+	ContentType string
+
 	// SyncPeriod determines the minimum frequency at which watched resources are
 	// reconciled. A lower period will correct entropy more quickly, but reduce
 	// responsiveness to change if there are many watched resources. Change this
@@ -222,12 +231,51 @@ type Options struct {
 	// DefaultNamespaces.
 	DefaultUnsafeDisableDeepCopy *bool
 
+	// Snapshot enables persisting the contents of the cache to disk, so that
+	// informers don't need to list all objects again after a restart.
+	// Defaults to disabled.
+	Snapshot *SnapshotOptions
+
 	// ByObject restricts the cache's ListWatch to the desired fields per GVK at the specified object.
 	// If unset, this will fall through to the Default* settings.
 	ByObject map[client.Object]ByObject
 
 	// newInformer allows overriding of NewSharedIndexInformer for testing.
 	newInformer *func(toolscache.ListerWatcher, runtime.Object, time.Duration, toolscache.Indexers) toolscache.SharedIndexInformer
+
+	// client is used by the informers to list and watch objects instead of
+	// REST clients, if set by NewFromClient.
+	client client.WithWatch
+}
+
+// SnapshotOptions configures persisting the contents of the cache to disk.
+//
+// A snapshot of every informer is written, together with the resourceVersion
+// it was taken at, periodically and when the cache stops. When an informer is
+// started and a snapshot for it exists, it is seeded from the snapshot and
+// resumes watching from its resourceVersion instead of listing all objects.
+// Should the resourceVersion be too old for the API server to resume the
+// watch, the informer falls back to a regular list.
+//
+// An informer seeded from a snapshot is marked as synced right away, before
+// its watch caught up with the changes made since the snapshot was taken.
+// Until then, the cache serves the possibly stale contents of the snapshot,
+// including objects that were deleted meanwhile, and WaitForCacheSync doesn't
+// wait for the watch to catch up. Controllers must tolerate this, as they
+// need to tolerate a lagging cache in general.
+//
+// Snapshots are not used for the initial list of informers that use
+// streaming lists, see SetWatchListEnabled.
+type SnapshotOptions struct {
+	// Dir is the directory the snapshots are stored in. It will be created
+	// if it doesn't exist. Snapshots contain the cached objects unencrypted,
+	// so the directory must not be readable by others if the cache contains
+	// sensitive data like Secrets.
+	Dir string
+
+	// Interval is the interval at which snapshots are written while the
+	// cache is running. Defaults to 5 minutes.
+	Interval *time.Duration
 }
 
 // ByObject offers more fine-grained control over the cache's ListWatch by object.
@@ -303,6 +351,21 @@ type Config struct {
 // NewCacheFunc - Function for creating a new cache from the options and a rest config.
 type NewCacheFunc func(config *rest.Config, opts Options) (Cache, error)
 
+// SetWatchListEnabled makes informers stream their initial list through a
+// watch with sendInitialEvents=true instead of issuing a list request, which
+// considerably reduces the memory the API server needs to serve it. Informers
+// transparently fall back to a list request if the API server doesn't support
+// streaming lists.
+//
+// client-go only allows to configure this through its WatchListClient feature
+// gate, which this overrides. The setting is thus process-wide and applies to
+// all informers started afterwards, of all caches, so it should be called
+// before creating any cache, e.g. in main. Without calling it, the feature
+// gate is left untouched.
+func SetWatchListEnabled(enabled bool) {
+	internal.SetWatchList(enabled)
+}
+
 // New initializes and returns a new Cache.
 func New(cfg *rest.Config, opts Options) (Cache, error) {
 	opts, err := defaultOpts(cfg, opts)
@@ -347,6 +410,24 @@ func New(cfg *rest.Config, opts Options) (Cache, error) {
 	return delegating, nil
 }
 
+// NewFromClient initializes and returns a new Cache whose informers list and
+// watch objects through the given client instead of talking to an API server.
+// Scheme and Mapper default to the ones of the client.
+//
+// This is mostly useful to back a cache by a fake client in tests, see
+// fake.ClientBuilder.WithWatchHistory for making its watches start at the
+// resourceVersion of the informers' lists, so that no events are missed.
+func NewFromClient(c client.WithWatch, opts Options) (Cache, error) {
+	if opts.Scheme == nil {
+		opts.Scheme = c.Scheme()
+	}
+	if opts.Mapper == nil {
+		opts.Mapper = c.RESTMapper()
+	}
+	opts.client = c
+	return New(&rest.Config{}, opts)
+}
+
 // TransformStripManagedFields strips the managed fields of an object before it is committed to the cache.
 // If you are not explicitly accessing managedFields from your code, setting this as `DefaultTransform`
 // on the cache can lead to a significant reduction in memory usage.
@@ -382,6 +463,18 @@ func byObjectToConfig(byObject ByObject) Config {
 type newCacheFunc func(config Config, namespace string) Cache
 
 func newCache(restConfig *rest.Config, opts Options) newCacheFunc {
+	var (
+		snapshotDir      string
+		snapshotInterval time.Duration
+	)
+	if opts.Snapshot != nil {
+		snapshotDir = opts.Snapshot.Dir
+		snapshotInterval = ptr.Deref(opts.Snapshot.Interval, defaultSnapshotInterval)
+	}
+	if opts.ContentType != "" {
+		restConfig = rest.CopyConfig(restConfig)
+		restConfig.ContentType = opts.ContentType
+	}
 	return func(config Config, namespace string) Cache {
 		return &informerCache{
 			scheme: opts.Scheme,
@@ -399,6 +492,9 @@ func newCache(restConfig *rest.Config, opts Options) newCacheFunc {
 				WatchErrorHandler:     opts.DefaultWatchErrorHandler,
 				UnsafeDisableDeepCopy: ptr.Deref(config.UnsafeDisableDeepCopy, false),
 				NewInformer:           opts.newInformer,
+				SnapshotDir:           snapshotDir,
+				SnapshotInterval:      snapshotInterval,
+				Client:                opts.client,
 			}),
 			readerFailOnMissingInformer: opts.ReaderFailOnMissingInformer,
 		}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNewFromClient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	existing := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "existing", Labels: map[string]string{"app": "foo"}}}
+	c := fake.NewClientBuilder().WithRESTMapper(mapper).WithWatchHistory(0).WithObjects(existing).Build()
+
+	cache, err := NewFromClient(c, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = cache.Start(ctx)
+	}()
+	if !cache.WaitForCacheSync(ctx) {
+		t.Fatal("cache did not sync")
+	}
+
+	structured := &corev1.ConfigMapList{}
+	unstructuredList := &unstructured.UnstructuredList{}
+	unstructuredList.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMapList"))
+	metadata := &metav1.PartialObjectMetadataList{}
+	metadata.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMapList"))
+	lists := []client.ObjectList{structured, unstructuredList, metadata}
+
+	expectItems := func(names ...string) {
+		t.Helper()
+		for _, list := range lists {
+			err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(ctx context.Context) (bool, error) {
+				if err := cache.List(ctx, list, client.MatchingLabels{"app": "foo"}); err != nil {
+					return false, err
+				}
+				return meta.LenList(list) == len(names), nil
+			})
+			if err != nil {
+				t.Fatalf("expected %d items in %T, got %d: %v", len(names), list, meta.LenList(list), err)
+			}
+			items, _ := meta.ExtractList(list)
+			found := sets.New[string]()
+			for _, item := range items {
+				accessor, _ := meta.Accessor(item)
+				found.Insert(accessor.GetName())
+			}
+			if !found.Equal(sets.New(names...)) {
+				t.Errorf("expected %T to contain %v, got %v", list, names, sets.List(found))
+			}
+		}
+	}
+
+	expectItems("existing")
+
+	if err := c.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "created", Labels: map[string]string{"app": "foo"}}}); err != nil {
+		t.Fatal(err)
+	}
+	expectItems("created", "existing")
+
+	if err := c.Delete(ctx, existing); err != nil {
+		t.Fatal(err)
+	}
+	expectItems("created")
+
+	metadataObj := &metav1.PartialObjectMetadata{}
+	metadataObj.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+	if err := cache.Get(ctx, client.ObjectKey{Namespace: "default", Name: "created"}, metadataObj); err != nil {
+		t.Fatal(err)
+	}
+	if metadataObj.Labels["app"] != "foo" {
+		t.Errorf("expected metadata to contain the labels, got %v", metadataObj.Labels)
+	}
+}
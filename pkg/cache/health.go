@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/exp/maps"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache/internal"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+const (
+	defaultInformerErrorThreshold = 2 * time.Minute
+	defaultInformerStallThreshold = 15 * time.Minute
+)
+
+// InformerHealth describes the health of a single informer of a cache.
+type InformerHealth internal.Health
+
+// InformerHealthReporter is implemented by caches that can report the health
+// of their informers. All caches returned by New implement it.
+type InformerHealthReporter interface {
+	// InformerHealth returns the health of all informers of the cache.
+	InformerHealth() []InformerHealth
+}
+
+var (
+	_ InformerHealthReporter = &informerCache{}
+	_ InformerHealthReporter = &multiNamespaceCache{}
+	_ InformerHealthReporter = &delegatingByGVKCache{}
+)
+
+// InformerHealthCheckOptions configures the checker returned by InformerHealthChecker.
+type InformerHealthCheckOptions struct {
+	// ErrorThreshold is the duration after which an informer that keeps
+	// failing to list or watch is considered unhealthy.
+	// Defaults to 2 minutes.
+	ErrorThreshold time.Duration
+
+	// StallThreshold is the duration after which a synced informer that
+	// neither received a watch event, nor listed or opened a watch, is
+	// considered stalled. As the API server periodically closes watches and
+	// sends bookmarks, this also detects watches that silently stopped
+	// delivering events. A negative value disables stall detection.
+	// Defaults to 15 minutes.
+	StallThreshold time.Duration
+}
+
+// InformerHealthChecker returns a healthz.Checker that fails if any informer
+// of the given cache has been failing or stalled for longer than the
+// configured thresholds. It can be registered with Manager.AddHealthzCheck:
+//
+//	mgr.AddHealthzCheck("informers", cache.InformerHealthChecker(mgr.GetCache(), cache.InformerHealthCheckOptions{}))
+//
+// The checker fails if the cache doesn't implement InformerHealthReporter.
+func InformerHealthChecker(c Cache, opts InformerHealthCheckOptions) healthz.Checker {
+	if opts.ErrorThreshold == 0 {
+		opts.ErrorThreshold = defaultInformerErrorThreshold
+	}
+	if opts.StallThreshold == 0 {
+		opts.StallThreshold = defaultInformerStallThreshold
+	}
+	return func(_ *http.Request) error {
+		reporter, ok := c.(InformerHealthReporter)
+		if !ok {
+			return fmt.Errorf("cache %T does not report informer health", c)
+		}
+		return checkInformerHealth(reporter.InformerHealth(), opts, time.Now())
+	}
+}
+
+func checkInformerHealth(health []InformerHealth, opts InformerHealthCheckOptions, now time.Time) error {
+	var errs []error
+	for _, h := range health {
+		if h.Stopped {
+			continue
+		}
+		if h.ConsecutiveWatchErrors > 0 && now.Sub(h.ErroringSince) > opts.ErrorThreshold {
+			errs = append(errs, fmt.Errorf("informer for %s%s failed %d times in a row since %s: %w",
+				h.GroupVersionKind, namespaceSuffix(h.Namespace), h.ConsecutiveWatchErrors, h.ErroringSince.Format(time.RFC3339), h.LastWatchError))
+			continue
+		}
+		if opts.StallThreshold > 0 && h.Synced && now.Sub(h.LastActivity) > opts.StallThreshold {
+			errs = append(errs, fmt.Errorf("informer for %s%s stalled, last activity at %s",
+				h.GroupVersionKind, namespaceSuffix(h.Namespace), h.LastActivity.Format(time.RFC3339)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func namespaceSuffix(namespace string) string {
+	if namespace == "" {
+		return ""
+	}
+	return fmt.Sprintf(" in namespace %q", namespace)
+}
+
+// InformerHealth implements InformerHealthReporter.
+func (ic *informerCache) InformerHealth() []InformerHealth {
+	health := ic.Informers.Health()
+	res := make([]InformerHealth, 0, len(health))
+	for _, h := range health {
+		res = append(res, InformerHealth(h))
+	}
+	return res
+}
+
+// InformerHealth implements InformerHealthReporter.
+func (c *multiNamespaceCache) InformerHealth() []InformerHealth {
+	caches := maps.Values(c.namespaceToCache)
+	if c.clusterCache != nil {
+		caches = append(caches, c.clusterCache)
+	}
+	return collectInformerHealth(caches)
+}
+
+// InformerHealth implements InformerHealthReporter.
+func (dbt *delegatingByGVKCache) InformerHealth() []InformerHealth {
+	return collectInformerHealth(append(maps.Values(dbt.caches), dbt.defaultCache))
+}
+
+func collectInformerHealth(caches []Cache) []InformerHealth {
+	var res []InformerHealth
+	for _, c := range caches {
+		if reporter, ok := c.(InformerHealthReporter); ok {
+			res = append(res, reporter.InformerHealth()...)
+		}
+	}
+	return res
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCheckInformerHealth(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	opts := InformerHealthCheckOptions{ErrorThreshold: time.Minute, StallThreshold: 10 * time.Minute}
+	pods := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+
+	testCases := []struct {
+		name    string
+		opts    InformerHealthCheckOptions
+		health  InformerHealth
+		healthy bool
+	}{
+		{
+			name:    "recently active",
+			health:  InformerHealth{GroupVersionKind: pods, Synced: true, LastActivity: now.Add(-time.Minute)},
+			healthy: true,
+		},
+		{
+			name: "erroring within threshold",
+			health: InformerHealth{
+				GroupVersionKind: pods, Synced: true, LastActivity: now,
+				ConsecutiveWatchErrors: 3, LastWatchError: errors.New("forbidden"), ErroringSince: now.Add(-30 * time.Second),
+			},
+			healthy: true,
+		},
+		{
+			name: "erroring beyond threshold",
+			health: InformerHealth{
+				GroupVersionKind: pods, Namespace: "default",
+				ConsecutiveWatchErrors: 3, LastWatchError: errors.New("forbidden"), ErroringSince: now.Add(-2 * time.Minute),
+			},
+		},
+		{
+			name:   "stalled",
+			health: InformerHealth{GroupVersionKind: pods, Synced: true, LastActivity: now.Add(-time.Hour)},
+		},
+		{
+			name:    "stall detection disabled",
+			opts:    InformerHealthCheckOptions{ErrorThreshold: time.Minute, StallThreshold: -1},
+			health:  InformerHealth{GroupVersionKind: pods, Synced: true, LastActivity: now.Add(-time.Hour)},
+			healthy: true,
+		},
+		{
+			name:    "not synced yet",
+			health:  InformerHealth{GroupVersionKind: pods},
+			healthy: true,
+		},
+		{
+			name: "stopped",
+			health: InformerHealth{
+				GroupVersionKind: pods, Synced: true, Stopped: true, LastActivity: now.Add(-time.Hour),
+				ConsecutiveWatchErrors: 1, LastWatchError: errors.New("forbidden"), ErroringSince: now.Add(-time.Hour),
+			},
+			healthy: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			o := opts
+			if tc.opts != (InformerHealthCheckOptions{}) {
+				o = tc.opts
+			}
+			err := checkInformerHealth([]InformerHealth{tc.health}, o, now)
+			if tc.healthy && err != nil {
+				t.Errorf("expected informer to be healthy, got %v", err)
+			}
+			if !tc.healthy && err == nil {
+				t.Error("expected informer to be unhealthy")
+			}
+		})
+	}
+}
+
+type fakeHealthReporter struct {
+	Cache
+	health []InformerHealth
+}
+
+func (f *fakeHealthReporter) InformerHealth() []InformerHealth {
+	return f.health
+}
+
+func TestInformerHealthChecker(t *testing.T) {
+	t.Parallel()
+
+	if err := InformerHealthChecker(&fakeHealthReporter{}, InformerHealthCheckOptions{})(nil); err != nil {
+		t.Errorf("expected cache without informers to be healthy, got %v", err)
+	}
+
+	stalled := &fakeHealthReporter{health: []InformerHealth{{Synced: true, LastActivity: time.Now().Add(-time.Hour)}}}
+	if err := InformerHealthChecker(stalled, InformerHealthCheckOptions{})(nil); err == nil {
+		t.Error("expected stalled informer to fail the check with default thresholds")
+	}
+
+	var notReporting struct{ Cache }
+	if err := InformerHealthChecker(notReporting, InformerHealthCheckOptions{})(nil); err == nil {
+		t.Error("expected cache that doesn't report informer health to fail the check")
+	}
+}
@@ -28,6 +28,9 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -36,6 +39,7 @@ import (
 	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/internal/syncs"
 )
@@ -52,6 +56,9 @@ type InformersOpts struct {
 	Transform             cache.TransformFunc
 	UnsafeDisableDeepCopy bool
 	WatchErrorHandler     cache.WatchErrorHandler
+	SnapshotDir           string
+	SnapshotInterval      time.Duration
+	Client                client.WithWatch
 }
 
 // NewInformers creates a new InformersMap that can create informers under the hood.
@@ -80,6 +87,9 @@ func NewInformers(config *rest.Config, options *InformersOpts) *Informers {
 		unsafeDisableDeepCopy: options.UnsafeDisableDeepCopy,
 		newInformer:           newInformer,
 		watchErrorHandler:     options.WatchErrorHandler,
+		snapshotDir:           options.SnapshotDir,
+		snapshotInterval:      options.SnapshotInterval,
+		client:                options.Client,
 	}
 }
 
@@ -93,6 +103,12 @@ type Cache struct {
 
 	// Stop can be used to stop this individual informer.
 	stop chan struct{}
+
+	// snapshot persists the contents of the informer. It is nil if snapshots are disabled.
+	snapshot *snapshot
+
+	// stats records the lists and watches of the informer.
+	stats *listWatchStats
 }
 
 // Start starts the informer managed by a MapEntry.
@@ -182,6 +198,17 @@ type Informers struct {
 	// watchErrorHandler to be set by overriding the options
 	// or to use the default watchErrorHandler
 	watchErrorHandler cache.WatchErrorHandler
+
+	// snapshotDir is the directory the contents of the informers are persisted in.
+	// Snapshots are disabled if it is empty.
+	snapshotDir string
+
+	// snapshotInterval is the interval at which snapshots are written while running.
+	snapshotInterval time.Duration
+
+	// client is used to list and watch objects instead of the REST clients
+	// built from config, if set.
+	client client.WithWatch
 }
 
 // Start calls Run on each of the informers and sets started to true. Blocks on the context.
@@ -217,14 +244,66 @@ func (ip *Informers) Start(ctx context.Context) error {
 	}(); err != nil {
 		return err
 	}
+	snapshotsDone := make(chan struct{})
+	go func() {
+		defer close(snapshotsDone)
+		ip.runSnapshots(ctx)
+	}()
+
 	<-ctx.Done() // Block until the context is done
 	ip.mu.Lock()
 	ip.stopped = true // Set stopped to true so we don't start any new informers
 	ip.mu.Unlock()
 	ip.waitGroup.Wait() // Block until all informers have stopped
+	<-snapshotsDone
+	ip.writeSnapshots() // Persist the final state of all informers
 	return nil
 }
 
+// runSnapshots periodically writes snapshots of all informers until the context is done.
+func (ip *Informers) runSnapshots(ctx context.Context) {
+	if ip.snapshotDir == "" || ip.snapshotInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(ip.snapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ip.writeSnapshots()
+		}
+	}
+}
+
+// writeSnapshots writes a snapshot of every informer that has synced.
+func (ip *Informers) writeSnapshots() {
+	if ip.snapshotDir == "" {
+		return
+	}
+	// Only collect the informers under the lock, so that getting or adding
+	// informers isn't blocked by the file I/O.
+	type entry struct {
+		gvk   schema.GroupVersionKind
+		cache *Cache
+	}
+	var entries []entry
+	ip.mu.RLock()
+	for _, informers := range []map[schema.GroupVersionKind]*Cache{ip.tracker.Structured, ip.tracker.Unstructured, ip.tracker.Metadata} {
+		for gvk, i := range informers {
+			entries = append(entries, entry{gvk: gvk, cache: i})
+		}
+	}
+	ip.mu.RUnlock()
+
+	for _, e := range entries {
+		if err := e.cache.snapshot.write(e.cache.Informer); err != nil {
+			snapshotLog.Error(err, "Failed to write cache snapshot", "gvk", e.gvk, "path", e.cache.snapshot.path)
+		}
+	}
+}
+
 func (ip *Informers) startInformerLocked(cacheEntry *Cache) {
 	// Don't start the informer in case we are already waiting for the items in
 	// the waitGroup to finish, since waitGroups don't support waiting and adding
@@ -269,6 +348,26 @@ func (ip *Informers) getHasSyncedFuncs() []cache.InformerSynced {
 	return res
 }
 
+// Health returns the health of all informers in this map.
+func (ip *Informers) Health() []Health {
+	ip.mu.RLock()
+	defer ip.mu.RUnlock()
+
+	res := make([]Health, 0,
+		len(ip.tracker.Structured)+len(ip.tracker.Unstructured)+len(ip.tracker.Metadata),
+	)
+	for _, informers := range []map[schema.GroupVersionKind]*Cache{ip.tracker.Structured, ip.tracker.Unstructured, ip.tracker.Metadata} {
+		for _, i := range informers {
+			health := i.stats.health()
+			health.Namespace = ip.namespace
+			health.Synced = i.Informer.HasSynced()
+			health.Stopped = i.Informer.IsStopped()
+			res = append(res, health)
+		}
+	}
+	return res
+}
+
 // WaitForCacheSync waits until all the caches have been started and synced.
 func (ip *Informers) WaitForCacheSync(ctx context.Context) bool {
 	if !ip.waitForStarted(ctx) {
@@ -355,7 +454,7 @@ func (ip *Informers) addInformerToMap(gvk schema.GroupVersionKind, obj runtime.O
 	if err != nil {
 		return nil, false, err
 	}
-	sharedIndexInformer := ip.newInformer(&cache.ListWatch{
+	lw := &cache.ListWatch{
 		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
 			ip.selector.ApplyToList(&opts)
 			return listWatcher.ListFunc(opts)
@@ -365,15 +464,34 @@ func (ip *Informers) addInformerToMap(gvk schema.GroupVersionKind, obj runtime.O
 			opts.Watch = true // Watch needs to be set to true separately
 			return listWatcher.WatchFunc(opts)
 		},
-	}, obj, calculateResyncPeriod(ip.resync), cache.Indexers{
-		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
-	})
+	}
+
+	stats := newListWatchStats(gvk)
+	lw = stats.wrap(lw)
 
-	// Set WatchErrorHandler on SharedIndexInformer if set
-	if ip.watchErrorHandler != nil {
-		if err := sharedIndexInformer.SetWatchErrorHandler(ip.watchErrorHandler); err != nil {
+	var snap *snapshot
+	if ip.snapshotDir != "" {
+		snap, err = ip.newSnapshot(gvk, obj)
+		if err != nil {
 			return nil, false, err
 		}
+		lw = snap.wrap(lw, stats.seeded)
+	}
+
+	sharedIndexInformer := ip.newInformer(lw, obj, calculateResyncPeriod(ip.resync), cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+	})
+
+	// Set WatchErrorHandler on SharedIndexInformer, recording the errors for health reporting
+	watchErrorHandler := ip.watchErrorHandler
+	if watchErrorHandler == nil {
+		watchErrorHandler = cache.DefaultWatchErrorHandler
+	}
+	if err := sharedIndexInformer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		stats.watchFailed(err)
+		watchErrorHandler(r, err)
+	}); err != nil {
+		return nil, false, err
 	}
 
 	// Check to see if there is a transformer for this gvk
@@ -395,7 +513,9 @@ func (ip *Informers) addInformerToMap(gvk schema.GroupVersionKind, obj runtime.O
 			scopeName:        mapping.Scope.Name(),
 			disableDeepCopy:  ip.unsafeDisableDeepCopy,
 		},
-		stop: make(chan struct{}),
+		stop:     make(chan struct{}),
+		snapshot: snap,
+		stats:    stats,
 	}
 	ip.informersByType(obj)[gvk] = i
 
@@ -407,6 +527,40 @@ func (ip *Informers) addInformerToMap(gvk schema.GroupVersionKind, obj runtime.O
 	return i, ip.started, nil
 }
 
+// newSnapshot returns the snapshot for the informer of the given GVK and object type.
+func (ip *Informers) newSnapshot(gvk schema.GroupVersionKind, obj runtime.Object) (*snapshot, error) {
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+	switch obj.(type) {
+	case runtime.Unstructured:
+		return &snapshot{
+			path: snapshotPath(ip.snapshotDir, "unstructured", gvk, ip.namespace, ip.selector),
+			newList: func() runtime.Object {
+				list := &unstructured.UnstructuredList{}
+				list.SetGroupVersionKind(listGVK)
+				return list
+			},
+		}, nil
+	case *metav1.PartialObjectMetadata, *metav1.PartialObjectMetadataList:
+		return &snapshot{
+			path: snapshotPath(ip.snapshotDir, "metadata", gvk, ip.namespace, ip.selector),
+			newList: func() runtime.Object {
+				list := &metav1.PartialObjectMetadataList{}
+				list.SetGroupVersionKind(listGVK)
+				return list
+			},
+		}, nil
+	default:
+		listObj, err := ip.scheme.New(listGVK)
+		if err != nil {
+			return nil, err
+		}
+		return &snapshot{
+			path:    snapshotPath(ip.snapshotDir, "structured", gvk, ip.namespace, ip.selector),
+			newList: listObj.DeepCopyObject,
+		}, nil
+	}
+}
+
 func (ip *Informers) makeListWatcher(gvk schema.GroupVersionKind, obj runtime.Object) (*cache.ListWatch, error) {
 	// Kubernetes APIs work against Resources, not GroupVersionKinds.  Map the
 	// groupVersionKind to the Resource API we will use.
@@ -421,6 +575,10 @@ func (ip *Informers) makeListWatcher(gvk schema.GroupVersionKind, obj runtime.Ob
 		namespace = restrictNamespaceBySelector(ip.namespace, ip.selector)
 	}
 
+	if ip.client != nil {
+		return ip.makeClientListWatcher(gvk, obj, namespace)
+	}
+
 	switch obj.(type) {
 	//
 	// Unstructured
@@ -539,6 +697,83 @@ func (ip *Informers) makeListWatcher(gvk schema.GroupVersionKind, obj runtime.Ob
 	}
 }
 
+// makeClientListWatcher returns a ListWatch that lists and watches objects through ip.client.
+func (ip *Informers) makeClientListWatcher(gvk schema.GroupVersionKind, obj runtime.Object, namespace string) (*cache.ListWatch, error) {
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+	var newList func() client.ObjectList
+	switch obj.(type) {
+	case runtime.Unstructured:
+		newList = func() client.ObjectList {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(listGVK)
+			return list
+		}
+	case *metav1.PartialObjectMetadata:
+		newList = func() client.ObjectList {
+			list := &metav1.PartialObjectMetadataList{}
+			list.SetGroupVersionKind(listGVK)
+			return list
+		}
+	default:
+		listObj, err := ip.scheme.New(listGVK)
+		if err != nil {
+			return nil, err
+		}
+		list, ok := listObj.(client.ObjectList)
+		if !ok {
+			return nil, fmt.Errorf("%T is not a client.ObjectList", listObj)
+		}
+		newList = func() client.ObjectList {
+			return list.DeepCopyObject().(client.ObjectList)
+		}
+	}
+
+	listOptions := func(opts metav1.ListOptions) (*client.ListOptions, error) {
+		listOpts := &client.ListOptions{Namespace: namespace, Raw: &opts}
+		if opts.LabelSelector != "" {
+			selector, err := labels.Parse(opts.LabelSelector)
+			if err != nil {
+				return nil, err
+			}
+			listOpts.LabelSelector = selector
+		}
+		if opts.FieldSelector != "" {
+			selector, err := fields.ParseSelector(opts.FieldSelector)
+			if err != nil {
+				return nil, err
+			}
+			listOpts.FieldSelector = selector
+		}
+		return listOpts, nil
+	}
+
+	return &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			listOpts, err := listOptions(opts)
+			if err != nil {
+				return nil, err
+			}
+			list := newList()
+			if err := ip.client.List(ip.ctx, list, listOpts); err != nil {
+				return nil, err
+			}
+			if metadataList, ok := list.(*metav1.PartialObjectMetadataList); ok {
+				for i := range metadataList.Items {
+					metadataList.Items[i].SetGroupVersionKind(gvk)
+				}
+			}
+			return list, nil
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			listOpts, err := listOptions(opts)
+			if err != nil {
+				return nil, err
+			}
+			return ip.client.Watch(ip.ctx, newList(), listOpts)
+		},
+	}, nil
+}
+
 // newGVKFixupWatcher adds a wrapper that preserves the GVK information when
 // events come in.
 //
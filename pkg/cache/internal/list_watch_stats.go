@@ -0,0 +1,268 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	clientfeatures "k8s.io/client-go/features"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache/internal/metrics"
+)
+
+// Health describes the health of a single informer.
+type Health struct {
+	// GroupVersionKind is the GVK of the objects the informer caches.
+	GroupVersionKind schema.GroupVersionKind
+
+	// Namespace is the namespace the informer is restricted to, if any.
+	Namespace string
+
+	// Synced is true once the informer completed its initial list.
+	Synced bool
+
+	// Stopped is true if the informer was stopped.
+	Stopped bool
+
+	// LastList is the time the last list completed successfully.
+	LastList time.Time
+
+	// LastEvent is the time the last watch event, including bookmarks, was received.
+	LastEvent time.Time
+
+	// LastActivity is the latest of LastList, LastEvent and the time the
+	// last watch was opened successfully.
+	LastActivity time.Time
+
+	// ConsecutiveWatchErrors is the number of errors the informer's watch
+	// error handler was called with since the last successful list or watch.
+	ConsecutiveWatchErrors int
+
+	// LastWatchError is the last error the watch error handler was called with.
+	LastWatchError error
+
+	// ErroringSince is the time of the first of the consecutive watch errors.
+	// It is zero if ConsecutiveWatchErrors is zero.
+	ErroringSince time.Time
+}
+
+// listWatchStats records the lists and watches issued by the reflector of a
+// single informer.
+type listWatchStats struct {
+	gvk    schema.GroupVersionKind
+	labels []string
+
+	mu sync.Mutex
+	// listed is true once the initial list completed, either through a list
+	// request or through the initial events of a streaming list.
+	listed bool
+	// watched is true once the first watch was opened.
+	watched bool
+	// listStart is the time the first page of the current list was requested.
+	listStart time.Time
+
+	lastList               time.Time
+	lastEvent              time.Time
+	lastActivity           time.Time
+	consecutiveWatchErrors int
+	lastWatchError         error
+	erroringSince          time.Time
+}
+
+func newListWatchStats(gvk schema.GroupVersionKind) *listWatchStats {
+	return &listWatchStats{gvk: gvk, labels: []string{gvk.Group, gvk.Version, gvk.Kind}}
+}
+
+// wrap returns a ListWatch that records all lists and watches of lw.
+func (s *listWatchStats) wrap(lw *cache.ListWatch) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			// Paginated lists call ListFunc once per page, the first of which
+			// has no continue token, and complete with the last page, which
+			// has none either.
+			if opts.Continue == "" {
+				s.listStarted()
+			}
+			res, err := lw.ListFunc(opts)
+			if err == nil && !hasContinue(res) {
+				s.listCompleted(s.listStartTime())
+			}
+			return res, err
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			start := time.Now()
+			s.watchStarted()
+			w, err := lw.WatchFunc(opts)
+			if err != nil {
+				return nil, err
+			}
+			s.recordActivity(false)
+			streaming := ptr.Deref(opts.SendInitialEvents, false)
+			var once sync.Once
+			return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+				if in.Type != watch.Error {
+					s.recordActivity(true)
+				}
+				// A streaming list signals the end of its initial events through a bookmark.
+				if streaming && in.Type == watch.Bookmark && isInitialEventsEnd(in.Object) {
+					once.Do(func() { s.listCompleted(start) })
+				}
+				return in, true
+			}), nil
+		},
+	}
+}
+
+func (s *listWatchStats) listStarted() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listStart = time.Now()
+}
+
+func (s *listWatchStats) listStartTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listStart
+}
+
+func (s *listWatchStats) listCompleted(start time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastList = time.Now()
+	s.lastActivity = s.lastList
+	s.resetErrorsLocked()
+	if !s.listed {
+		s.listed = true
+		metrics.InitialListDuration.WithLabelValues(s.labels...).Observe(time.Since(start).Seconds())
+		return
+	}
+	metrics.RelistTotal.WithLabelValues(s.labels...).Inc()
+}
+
+// seeded records that the initial list was served from a snapshot. It
+// doesn't observe the initial list duration, as no list request was issued.
+func (s *listWatchStats) seeded() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastList = time.Now()
+	s.lastActivity = s.lastList
+	s.resetErrorsLocked()
+	s.listed = true
+}
+
+func (s *listWatchStats) watchStarted() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watched {
+		metrics.WatchRestartTotal.WithLabelValues(s.labels...).Inc()
+	}
+	s.watched = true
+}
+
+// recordActivity records that a watch was opened or, if isEvent is true,
+// that a watch event was received.
+func (s *listWatchStats) recordActivity(isEvent bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastActivity = time.Now()
+	if isEvent {
+		s.lastEvent = s.lastActivity
+	}
+	s.resetErrorsLocked()
+}
+
+// watchFailed records an error the informer's watch error handler was called with.
+func (s *listWatchStats) watchFailed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.consecutiveWatchErrors == 0 {
+		s.erroringSince = time.Now()
+	}
+	s.consecutiveWatchErrors++
+	s.lastWatchError = err
+}
+
+func (s *listWatchStats) resetErrorsLocked() {
+	s.consecutiveWatchErrors = 0
+	s.erroringSince = time.Time{}
+}
+
+// health returns the recorded health information. Fields that are not
+// tracked by the stats, like Synced, are left empty.
+func (s *listWatchStats) health() Health {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Health{
+		GroupVersionKind:       s.gvk,
+		LastList:               s.lastList,
+		LastEvent:              s.lastEvent,
+		LastActivity:           s.lastActivity,
+		ConsecutiveWatchErrors: s.consecutiveWatchErrors,
+		LastWatchError:         s.lastWatchError,
+		ErroringSince:          s.erroringSince,
+	}
+}
+
+// hasContinue returns true if obj is a page of a list that has more pages.
+func hasContinue(obj runtime.Object) bool {
+	accessor, err := meta.ListAccessor(obj)
+	return err == nil && accessor.GetContinue() != ""
+}
+
+func isInitialEventsEnd(obj runtime.Object) bool {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	return accessor.GetAnnotations()[metav1.InitialEventsAnnotationKey] == "true"
+}
+
+// watchListGates overrides the WatchListClient feature gate of client-go,
+// which decides whether reflectors use streaming lists.
+type watchListGates struct {
+	clientfeatures.Gates
+	enabled bool
+}
+
+func (g *watchListGates) Enabled(key clientfeatures.Feature) bool {
+	if key == clientfeatures.WatchListClient {
+		return g.enabled
+	}
+	return g.Gates.Enabled(key)
+}
+
+// SetWatchList enables or disables streaming lists for all reflectors that
+// are created afterwards. The setting is process-wide, as client-go only
+// offers it through a feature gate.
+func SetWatchList(enabled bool) {
+	gates := clientfeatures.FeatureGates()
+	if wrapped, ok := gates.(*watchListGates); ok {
+		if wrapped.enabled == enabled {
+			return
+		}
+		gates = wrapped.Gates
+	}
+	clientfeatures.ReplaceFeatureGates(&watchListGates{Gates: gates, enabled: enabled})
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	clientfeatures "k8s.io/client-go/features"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache/internal/metrics"
+)
+
+var _ = Describe("listWatchStats", func() {
+	var (
+		gvk    schema.GroupVersionKind
+		fake   *watch.FakeWatcher
+		lw     *cache.ListWatch
+		labels []string
+	)
+
+	BeforeEach(func() {
+		// Her test kendi GVK'sını kullanır, böylece metrikler testler arasında paylaşılmaz.
+		gvk = schema.GroupVersionKind{Group: "test", Version: "v1", Kind: CurrentSpecReport().LeafNodeText}
+		labels = []string{gvk.Group, gvk.Version, gvk.Kind}
+		fake = watch.NewFakeWithChanSize(10, false)
+		lw = newListWatchStats(gvk).wrap(&cache.ListWatch{
+			ListFunc: func(metav1.ListOptions) (runtime.Object, error) {
+				return &metav1.PartialObjectMetadataList{}, nil
+			},
+			WatchFunc: func(metav1.ListOptions) (watch.Interface, error) {
+				return fake, nil
+			},
+		})
+	})
+
+	It("ilk listeden sonraki listeleri yeniden listeleme olarak sayar", func() {
+		_, err := lw.List(metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(histogramCount(labels)).To(Equal(uint64(1)))
+		Expect(testutil.ToFloat64(metrics.RelistTotal.WithLabelValues(labels...))).To(BeZero())
+
+		_, err = lw.List(metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(testutil.ToFloat64(metrics.RelistTotal.WithLabelValues(labels...))).To(Equal(1.0))
+	})
+
+	It("sayfalı bir listeyi son sayfasıyla tamamlanmış sayar", func() {
+		lw := newListWatchStats(gvk).wrap(&cache.ListWatch{
+			ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+				if opts.Continue == "" {
+					return &metav1.PartialObjectMetadataList{ListMeta: metav1.ListMeta{Continue: "page-2"}}, nil
+				}
+				time.Sleep(20 * time.Millisecond)
+				return &metav1.PartialObjectMetadataList{}, nil
+			},
+		})
+
+		_, err := lw.List(metav1.ListOptions{Limit: 500})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(histogramCount(labels)).To(BeZero())
+		_, err = lw.List(metav1.ListOptions{Limit: 500, Continue: "page-2"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(histogramCount(labels)).To(Equal(uint64(1)))
+		Expect(histogramSum(labels)).To(BeNumerically(">=", 0.02), "süre ilk sayfadan itibaren ölçülmeli")
+		Expect(testutil.ToFloat64(metrics.RelistTotal.WithLabelValues(labels...))).To(BeZero())
+
+		By("sonraki sayfalı listeyi bir kez yeniden listeleme olarak sayar")
+		_, err = lw.List(metav1.ListOptions{Limit: 500})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = lw.List(metav1.ListOptions{Limit: 500, Continue: "page-2"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(testutil.ToFloat64(metrics.RelistTotal.WithLabelValues(labels...))).To(Equal(1.0))
+	})
+
+	It("anlık görüntüden sunulan ilk liste için süre kaydetmez", func() {
+		s := newListWatchStats(gvk)
+		s.seeded()
+		Expect(histogramCount(labels)).To(BeZero())
+		Expect(s.health().LastList).NotTo(BeZero())
+
+		By("sonraki listeyi yeniden listeleme olarak sayar")
+		_, err := s.wrap(&cache.ListWatch{
+			ListFunc: func(metav1.ListOptions) (runtime.Object, error) {
+				return &metav1.PartialObjectMetadataList{}, nil
+			},
+		}).List(metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(histogramCount(labels)).To(BeZero())
+		Expect(testutil.ToFloat64(metrics.RelistTotal.WithLabelValues(labels...))).To(Equal(1.0))
+	})
+
+	It("ilk izlemeden sonraki izlemeleri yeniden başlatma olarak sayar", func() {
+		_, err := lw.Watch(metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(testutil.ToFloat64(metrics.WatchRestartTotal.WithLabelValues(labels...))).To(BeZero())
+
+		_, err = lw.Watch(metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(testutil.ToFloat64(metrics.WatchRestartTotal.WithLabelValues(labels...))).To(Equal(1.0))
+	})
+
+	It("akışlı listenin sonunu bookmark ile algılar", func() {
+		w, err := lw.Watch(metav1.ListOptions{SendInitialEvents: ptr.To(true)})
+		Expect(err).NotTo(HaveOccurred())
+
+		bookmark := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{metav1.InitialEventsAnnotationKey: "true"},
+		}}
+		fake.Add(&metav1.PartialObjectMetadata{})
+		fake.Action(watch.Bookmark, bookmark)
+		Eventually(w.ResultChan()).Should(Receive())
+		Eventually(w.ResultChan()).Should(Receive())
+		Expect(histogramCount(labels)).To(Equal(uint64(1)))
+
+		By("sonraki listeyi yeniden listeleme olarak sayar")
+		_, err = lw.List(metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(testutil.ToFloat64(metrics.RelistTotal.WithLabelValues(labels...))).To(Equal(1.0))
+	})
+})
+
+func histogramCount(labels []string) uint64 {
+	m := &dto.Metric{}
+	Expect(metrics.InitialListDuration.WithLabelValues(labels...).(prometheus.Metric).Write(m)).To(Succeed())
+	return m.GetHistogram().GetSampleCount()
+}
+
+func histogramSum(labels []string) float64 {
+	m := &dto.Metric{}
+	Expect(metrics.InitialListDuration.WithLabelValues(labels...).(prometheus.Metric).Write(m)).To(Succeed())
+	return m.GetHistogram().GetSampleSum()
+}
+
+var _ = Describe("SetWatchList", func() {
+	It("WatchListClient özellik kapısını geçersiz kılar", func() {
+		original := clientfeatures.FeatureGates()
+		DeferCleanup(func() { clientfeatures.ReplaceFeatureGates(original) })
+
+		SetWatchList(true)
+		Expect(clientfeatures.FeatureGates().Enabled(clientfeatures.WatchListClient)).To(BeTrue())
+		SetWatchList(false)
+		Expect(clientfeatures.FeatureGates().Enabled(clientfeatures.WatchListClient)).To(BeFalse())
+		Expect(clientfeatures.FeatureGates().(*watchListGates).Gates).To(BeIdenticalTo(original))
+	})
+})
+
+var _ = Describe("listWatchStats sağlığı", func() {
+	var s *listWatchStats
+
+	BeforeEach(func() {
+		s = newListWatchStats(schema.GroupVersionKind{Version: "v1", Kind: "Pod"})
+	})
+
+	It("ardışık izleme hatalarını sayar", func() {
+		s.watchFailed(errors.New("ilk"))
+		first := s.health().ErroringSince
+		Expect(first).NotTo(BeZero())
+
+		s.watchFailed(errors.New("ikinci"))
+		h := s.health()
+		Expect(h.ConsecutiveWatchErrors).To(Equal(2))
+		Expect(h.LastWatchError).To(MatchError("ikinci"))
+		Expect(h.ErroringSince).To(Equal(first))
+	})
+
+	It("başarılı bir liste veya olaydan sonra hataları sıfırlar", func() {
+		s.watchFailed(errors.New("hata"))
+		s.listCompleted(time.Now())
+		h := s.health()
+		Expect(h.ConsecutiveWatchErrors).To(BeZero())
+		Expect(h.ErroringSince).To(BeZero())
+		Expect(h.LastList).NotTo(BeZero())
+		Expect(h.LastActivity).To(Equal(h.LastList))
+
+		s.watchFailed(errors.New("hata"))
+		s.recordActivity(true)
+		h = s.health()
+		Expect(h.ConsecutiveWatchErrors).To(BeZero())
+		Expect(h.LastEvent).To(Equal(h.LastActivity))
+	})
+
+	It("izleme olaylarını etkinlik olarak kaydeder", func() {
+		fake := watch.NewFakeWithChanSize(1, false)
+		lw := s.wrap(&cache.ListWatch{
+			WatchFunc: func(metav1.ListOptions) (watch.Interface, error) { return fake, nil },
+		})
+		w, err := lw.Watch(metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.health().LastActivity).NotTo(BeZero())
+		Expect(s.health().LastEvent).To(BeZero())
+
+		fake.Add(&metav1.PartialObjectMetadata{})
+		Eventually(w.ResultChan()).Should(Receive())
+		Expect(s.health().LastEvent).NotTo(BeZero())
+	})
+})
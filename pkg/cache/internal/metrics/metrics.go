@@ -0,0 +1,56 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// InitialListDuration, her GVK için bir informer'ın ilk listesini tamamlamasının
+	// ne kadar sürdüğünü tutan bir prometheus histogram metrikidir. Akışlı listelerde
+	// (sendInitialEvents) süre, ilk olayların sonunu işaretleyen bookmark'a kadar ölçülür.
+	InitialListDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "controller_runtime_cache_initial_list_duration_seconds",
+		Help:    "Her GVK için informer'ın ilk listesinin süresi",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"group", "version", "kind"})
+
+	// RelistTotal, her GVK için ilk listeden sonra yapılan toplam liste sayısını tutan
+	// bir prometheus sayaç metrikidir. Yeniden listeleme, bir izleme kaldığı
+	// resourceVersion'dan devam ettirilemediğinde gerçekleşir.
+	RelistTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_cache_relists_total",
+		Help: "Her GVK için ilk listeden sonraki toplam liste sayısı",
+	}, []string{"group", "version", "kind"})
+
+	// WatchRestartTotal, her GVK için ilk izlemeden sonra yeniden başlatılan toplam
+	// izleme sayısını tutan bir prometheus sayaç metrikidir.
+	WatchRestartTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_cache_watch_restarts_total",
+		Help: "Her GVK için toplam izleme yeniden başlatma sayısı",
+	}, []string{"group", "version", "kind"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		InitialListDuration,
+		RelistTotal,
+		WatchRestartTotal,
+	)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/internal/log"
+)
+
+var snapshotLog = logf.RuntimeLog.WithName("cache").WithName("snapshot")
+
+// snapshot persists the contents of a single informer to a file, so that the
+// informer can be seeded from it instead of listing all objects on the next
+// start.
+type snapshot struct {
+	// path is the file the snapshot is stored in.
+	path string
+
+	// newList returns an empty list of the informer's type.
+	newList func() runtime.Object
+}
+
+// wrap returns a ListWatch that serves the first list from the snapshot, if
+// one exists. The reflector then resumes watching from the snapshot's
+// resourceVersion. Should that be too old, the watch fails with 410 Gone and
+// the reflector falls back to a regular list, which is served by lw.
+// If not nil, seeded is called when the list is served from the snapshot.
+func (s *snapshot) wrap(lw *cache.ListWatch, seeded func()) *cache.ListWatch {
+	var once sync.Once
+	return &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			var list runtime.Object
+			once.Do(func() {
+				var err error
+				list, err = s.read()
+				if err != nil {
+					if !errors.Is(err, fs.ErrNotExist) {
+						snapshotLog.Error(err, "Failed to read cache snapshot, listing instead", "path", s.path)
+					}
+					list = nil
+				}
+			})
+			if list != nil {
+				if seeded != nil {
+					seeded()
+				}
+				return list, nil
+			}
+			return lw.ListFunc(opts)
+		},
+		WatchFunc: lw.WatchFunc,
+	}
+}
+
+func (s *snapshot) read() (runtime.Object, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	list := s.newList()
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	listAccessor, err := meta.ListAccessor(list)
+	if err != nil {
+		return nil, err
+	}
+	if listAccessor.GetResourceVersion() == "" {
+		return nil, errors.New("snapshot has no resourceVersion")
+	}
+	snapshotLog.V(1).Info("Seeding informer from cache snapshot", "path", s.path, "resourceVersion", listAccessor.GetResourceVersion())
+	return list, nil
+}
+
+// write stores the current contents of the given informer. Informers that
+// haven't synced yet are skipped, as they don't have a consistent state.
+func (s *snapshot) write(informer cache.SharedIndexInformer) error {
+	resourceVersion := informer.LastSyncResourceVersion()
+	if !informer.HasSynced() || resourceVersion == "" {
+		return nil
+	}
+
+	items := informer.GetStore().List()
+	objs := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(runtime.Object)
+		if !ok {
+			return fmt.Errorf("cache contains %T, which is not a runtime.Object", item)
+		}
+		objs = append(objs, obj)
+	}
+	list := s.newList()
+	if err := meta.SetList(list, objs); err != nil {
+		return err
+	}
+	listAccessor, err := meta.ListAccessor(list)
+	if err != nil {
+		return err
+	}
+	listAccessor.SetResourceVersion(resourceVersion)
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that a crash never leaves a partial snapshot behind.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// snapshotPath returns the file a snapshot for an informer is stored in. It is
+// unique for the informer's type, GVK, namespace and selectors, as informers
+// for the same GVK might be restricted differently.
+func snapshotPath(dir, typeName string, gvk schema.GroupVersionKind, namespace string, selector Selector) string {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%s|%s|%s", typeName, gvk, namespace)
+	if selector.Label != nil {
+		_, _ = fmt.Fprintf(h, "|l=%s", selector.Label)
+	}
+	if selector.Field != nil {
+		_, _ = fmt.Fprintf(h, "|f=%s", selector.Field)
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%s-%016x.json", strings.ToLower(gvk.Kind), typeName, h.Sum64()))
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+var _ = Describe("snapshot", func() {
+	var (
+		snap   *snapshot
+		lists  int
+		source *cache.ListWatch
+	)
+
+	BeforeEach(func() {
+		lists = 0
+		snap = &snapshot{
+			path:    filepath.Join(GinkgoT().TempDir(), "pods.json"),
+			newList: func() runtime.Object { return &corev1.PodList{} },
+		}
+		source = &cache.ListWatch{
+			ListFunc: func(metav1.ListOptions) (runtime.Object, error) {
+				lists++
+				return &corev1.PodList{
+					ListMeta: metav1.ListMeta{ResourceVersion: "42"},
+					Items: []corev1.Pod{
+						{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", ResourceVersion: "41"}},
+						{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bar", ResourceVersion: "42"}},
+					},
+				}, nil
+			},
+			WatchFunc: func(metav1.ListOptions) (watch.Interface, error) {
+				return watch.NewFake(), nil
+			},
+		}
+	})
+
+	It("anlık görüntü yoksa API sunucusundan listeler", func() {
+		list, err := snap.wrap(source, nil).List(metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.(*corev1.PodList).Items).To(HaveLen(2))
+		Expect(lists).To(Equal(1))
+	})
+
+	It("informer'ın içeriğini yazar ve ilk listeyi ondan sunar", func() {
+		informer := cache.NewSharedIndexInformer(source, &corev1.Pod{}, 0, cache.Indexers{})
+		stop := make(chan struct{})
+		go informer.Run(stop)
+		defer close(stop)
+		Eventually(informer.HasSynced).Should(BeTrue())
+
+		Expect(snap.write(informer)).To(Succeed())
+		Expect(lists).To(Equal(1))
+
+		lw := snap.wrap(source, nil)
+		list, err := lw.List(metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lists).To(Equal(1), "ilk liste anlık görüntüden sunulmalı")
+		podList := list.(*corev1.PodList)
+		Expect(podList.ResourceVersion).To(Equal("42"))
+		Expect(podList.Items).To(ConsistOf(
+			HaveField("Name", "foo"),
+			HaveField("Name", "bar"),
+		))
+
+		By("sonraki listeleri API sunucusuna yönlendirir")
+		_, err = lw.List(metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lists).To(Equal(2))
+	})
+
+	It("senkronize olmamış informer'lar için anlık görüntü yazmaz", func() {
+		informer := cache.NewSharedIndexInformer(source, &corev1.Pod{}, 0, cache.Indexers{})
+		Expect(snap.write(informer)).To(Succeed())
+		_, err := os.Stat(snap.path)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("bozuk anlık görüntüde API sunucusundan listeler", func() {
+		Expect(os.WriteFile(snap.path, []byte("{"), 0o600)).To(Succeed())
+		_, err := snap.wrap(source, nil).List(metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lists).To(Equal(1))
+	})
+
+	It("farklı seçiciler için farklı dosyalar kullanır", func() {
+		gvk := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+		all := snapshotPath("dir", "structured", gvk, "", Selector{})
+		Expect(all).To(HavePrefix(filepath.Join("dir", "pod-structured-")))
+		Expect(snapshotPath("dir", "structured", gvk, "default", Selector{})).NotTo(Equal(all))
+		Expect(snapshotPath("dir", "metadata", gvk, "", Selector{})).NotTo(Equal(all))
+		Expect(snapshotPath("dir", "structured", gvk, "", Selector{Label: labels.SelectorFromSet(labels.Set{"a": "b"})})).NotTo(Equal(all))
+		Expect(snapshotPath("dir", "structured", gvk, "", Selector{Field: fields.OneTermEqualSelector("metadata.name", "foo")})).NotTo(Equal(all))
+	})
+})
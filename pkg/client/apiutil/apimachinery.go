@@ -157,6 +157,8 @@ func GVKForObject(obj runtime.Object, scheme *runtime.Scheme) (schema.GroupVersi
 
 // RESTClientForGVK, verilen GroupVersionKind ile ilişkili kaynağa erişebilen yeni bir rest.Interface oluşturur.
 // REST istemcisi, ayarlanmışsa baseConfig'ten müzakere edilmiş serileştiriciyi kullanacak şekilde yapılandırılacaktır, aksi takdirde varsayılan bir serileştirici ayarlanacaktır.
+// baseConfig'te ContentType ayarlanmamışsa, protobufScheme'deki türler için Protokol Tamponları kullanılır.
+// ContentType Protokol Tamponları olarak ayarlanmışsa, onları desteklemeyen türler (ör. CRD'ler) için JSON kullanılır.
 func RESTClientForGVK(gvk schema.GroupVersionKind, isUnstructured bool, baseConfig *rest.Config, codecs serializer.CodecFactory, httpClient *http.Client) (rest.Interface, error) {
 	if httpClient == nil {
 		return nil, fmt.Errorf("httpClient boş olmamalıdır, bir istemci oluşturmak için rest.HTTPClientFor(c) kullanmayı düşünün")
@@ -179,12 +181,18 @@ func createRestConfig(gvk schema.GroupVersionKind, isUnstructured bool, baseConf
 		cfg.UserAgent = rest.DefaultKubernetesUserAgent()
 	}
 	// TODO: Uzun vadede, bunun gerçekten doğru olduğundan emin olmak için keşif veya başka bir şey kontrol etmek istiyoruz.
-	if cfg.ContentType == "" && !isUnstructured {
+	if !isUnstructured {
 		protobufSchemeLock.RLock()
-		if protobufScheme.Recognizes(gvk) {
+		supportsProtobuf := protobufScheme.Recognizes(gvk)
+		protobufSchemeLock.RUnlock()
+		switch {
+		case cfg.ContentType == "" && supportsProtobuf:
 			cfg.ContentType = runtime.ContentTypeProtobuf
+		case cfg.ContentType == runtime.ContentTypeProtobuf && !supportsProtobuf:
+			// CRD'ler Protokol Tamponlarını desteklemez, bu yüzden JSON'a geri dönülür.
+			cfg.ContentType = runtime.ContentTypeJSON
+			cfg.AcceptContentTypes = runtime.ContentTypeJSON
 		}
-		protobufSchemeLock.RUnlock()
 	}
 
 	if isUnstructured {
@@ -0,0 +1,142 @@
+/*
+2024 Kubernetes Yazarları.
+
+Apache Lisansı, Sürüm 2.0 ("Lisans") uyarınca lisanslanmıştır;
+bu dosyayı ancak Lisans'a uygun olarak kullanabilirsiniz.
+Lisansın bir kopyasını aşağıdaki adreste bulabilirsiniz:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Geçerli yasa gereği veya yazılı olarak kabul edilmedikçe,
+Lisans kapsamında dağıtılan yazılım "OLDUĞU GİBİ" dağıtılır,
+HERHANGİ BİR GARANTİ VEYA KOŞUL OLMAKSIZIN, açık veya zımni.
+Lisans kapsamında izin verilen özel haklar ve
+sınırlamalar için Lisansa bakın.
+*/
+
+package apiutil_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// podListServer, Accept başlığına göre JSON veya Protokol Tamponları ile
+// kodlanmış bir PodList döndüren bir test sunucusu başlatır.
+func podListServer(tb testing.TB, pods int) (*httptest.Server, *[]string) {
+	list := &corev1.PodList{}
+	for i := range pods {
+		list.Items = append(list.Items, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      fmt.Sprintf("pod-%d", i),
+				Labels:    map[string]string{"app": "bench", "index": fmt.Sprint(i)},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "registry.k8s.io/pause:3.9",
+				Env:   []corev1.EnvVar{{Name: "FOO", Value: "bar"}},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.1"},
+		})
+	}
+	bodies := map[string][]byte{}
+	for _, mediaType := range []string{runtime.ContentTypeJSON, runtime.ContentTypeProtobuf} {
+		info, ok := runtime.SerializerInfoForMediaType(scheme.Codecs.SupportedMediaTypes(), mediaType)
+		if !ok {
+			tb.Fatalf("no serializer for %s", mediaType)
+		}
+		body, err := runtime.Encode(scheme.Codecs.EncoderForVersion(info.Serializer, corev1.SchemeGroupVersion), list)
+		if err != nil {
+			tb.Fatalf("unexpected error: %v", err)
+		}
+		bodies[mediaType] = body
+	}
+
+	var accepted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header.Get("Accept")
+		accepted = append(accepted, accept)
+		mediaType := runtime.ContentTypeJSON
+		if strings.HasPrefix(accept, runtime.ContentTypeProtobuf) {
+			mediaType = runtime.ContentTypeProtobuf
+		}
+		w.Header().Set("Content-Type", mediaType)
+		_, _ = w.Write(bodies[mediaType])
+	}))
+	tb.Cleanup(server.Close)
+	return server, &accepted
+}
+
+func TestRESTClientForGVKContentType(t *testing.T) {
+	server, accepted := podListServer(t, 1)
+	podGVK := corev1.SchemeGroupVersion.WithKind("Pod")
+	crdGVK := schema.GroupVersionKind{Group: "crew.example.com", Version: "v1", Kind: "Driver"}
+
+	for _, tc := range []struct {
+		name        string
+		gvk         schema.GroupVersionKind
+		contentType string
+		expected    string
+	}{
+		{name: "yerleşik türler varsayılan olarak protobuf kullanır", gvk: podGVK, expected: runtime.ContentTypeProtobuf},
+		{name: "yerleşik türler JSON'a zorlanabilir", gvk: podGVK, contentType: runtime.ContentTypeJSON, expected: runtime.ContentTypeJSON},
+		{name: "yerleşik türler açıkça protobuf kullanabilir", gvk: podGVK, contentType: runtime.ContentTypeProtobuf, expected: runtime.ContentTypeProtobuf},
+		{name: "CRD'ler varsayılan olarak JSON kullanır", gvk: crdGVK, expected: runtime.ContentTypeJSON},
+		{name: "CRD'ler protobuf istendiğinde JSON'a geri döner", gvk: crdGVK, contentType: runtime.ContentTypeProtobuf, expected: runtime.ContentTypeJSON},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			*accepted = nil
+			cfg := &rest.Config{Host: server.URL, ContentConfig: rest.ContentConfig{ContentType: tc.contentType}}
+			c, err := apiutil.RESTClientForGVK(tc.gvk, false, cfg, scheme.Codecs, http.DefaultClient)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := c.Get().Resource("things").DoRaw(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(*accepted) != 1 || !strings.HasPrefix((*accepted)[0], tc.expected) {
+				t.Errorf("expected request to accept %s, got %v", tc.expected, *accepted)
+			}
+		})
+	}
+}
+
+// BenchmarkListPods, 1000 Pod'luk bir listenin JSON ve Protokol Tamponları ile
+// alınıp çözülmesinin maliyetini karşılaştırır.
+func BenchmarkListPods(b *testing.B) {
+	server, _ := podListServer(b, 1000)
+	for _, contentType := range []string{runtime.ContentTypeJSON, runtime.ContentTypeProtobuf} {
+		b.Run(contentType, func(b *testing.B) {
+			cfg := &rest.Config{Host: server.URL, QPS: -1, ContentConfig: rest.ContentConfig{ContentType: contentType}}
+			c, err := apiutil.RESTClientForGVK(corev1.SchemeGroupVersion.WithKind("Pod"), false, cfg, scheme.Codecs, http.DefaultClient)
+			if err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				list := &corev1.PodList{}
+				if err := c.Get().Resource("pods").Do(context.Background()).Into(list); err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+				if len(list.Items) != 1000 {
+					b.Fatalf("expected 1000 pods, got %d", len(list.Items))
+				}
+			}
+		})
+	}
+}
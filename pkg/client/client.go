@@ -18,11 +18,16 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
 	"strings"
+	"time"
 
+	"golang.org/x/sync/singleflight"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -33,6 +38,7 @@ import (
 	"k8s.io/client-go/rest"
 
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/internal/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -52,6 +58,29 @@ type Options struct {
 
 	// DryRun instructs the client to only perform dry run requests.
 	DryRun *bool
+
+	// ContentType is the content type used to encode requests and decode
+	// responses for types that support it, i.e. types registered with
+	// apiutil.AddToProtobufScheme, which includes all built-in types.
+	// Set it to runtime.ContentTypeProtobuf to reduce the CPU and bandwidth
+	// used for e.g. high-volume lists of Pods, or to runtime.ContentTypeJSON
+	// to opt out of it. Custom resources and unstructured objects always use
+	// JSON, as the API server doesn't support protobuf for them.
+	//
+	// Defaults to the ContentType of the rest.Config. If that is unset too,
+	// protobuf is used for all types that support it.
+	ContentType string
+
+	// CoalesceLiveReads deduplicates identical Get and List requests to the
+	// API server that are in flight at the same time, e.g. for objects that
+	// are configured in Cache.DisableFor. Requests are identical if they are
+	// for the same GVK, key and options. Only one request is sent and each
+	// caller receives a deep copy of its result.
+	//
+	// The shared request uses the context of the caller that issued it, so
+	// all callers receive its error if that context is cancelled.
+	// Reads served by the cache are never coalesced.
+	CoalesceLiveReads bool
 }
 
 // CacheOptions are options for creating a cache-backed client.
@@ -66,6 +95,15 @@ type CacheOptions struct {
 	// read unstructured objects or lists from the cache.
 	// If false, unstructured objects will always result in a live lookup.
 	Unstructured bool
+	// QuorumReadOnNotFoundMaxAge, if non-zero, makes Get retry NotFound
+	// results from the cache with a live quorum read against the API server.
+	// This covers objects that were created recently but not observed by the
+	// cache yet. The live result is only returned if the object was created
+	// within the given duration, as older objects are missing from the cache
+	// on purpose, e.g. because of the cache's label selectors.
+	// The fallback can also be requested for a single call with the
+	// QuorumReadOnNotFound GetOption, which ignores the object's age.
+	QuorumReadOnNotFoundMaxAge time.Duration
 }
 
 // NewClientFunc allows a user to define how to create a client.
@@ -94,12 +132,25 @@ type NewClientFunc func(config *rest.Config, options Options) (Client, error)
 // corresponding group, version, and kind for the given type.  In the
 // case of unstructured types, the group, version, and kind will be extracted
 // from the corresponding fields on the object.
+//
+// Single calls can impersonate another user with the Impersonate option, see
+// also WithImpersonation.
+//
+// Calls made with the context of a reconcile wait for the ClientRateLimiter of
+// the controller, if it has one.
+//
+// Calls made with a context that carries an OpenTelemetry span, e.g. within a
+// reconcile of a manager with a TracerProvider, create child spans.
 func New(config *rest.Config, options Options) (c Client, err error) {
-	c, err = newClient(config, options)
-	if err == nil && options.DryRun != nil && *options.DryRun {
+	cl, err := newClient(config, options)
+	if err != nil {
+		return nil, err
+	}
+	c = newTracingClient(cl)
+	if options.DryRun != nil && *options.DryRun {
 		c = NewDryRunClient(c)
 	}
-	return c, err
+	return c, nil
 }
 
 func newClient(config *rest.Config, options Options) (*client, error) {
@@ -108,6 +159,9 @@ func newClient(config *rest.Config, options Options) (*client, error) {
 	}
 
 	config = rest.CopyConfig(config)
+	if options.ContentType != "" {
+		config.ContentType = options.ContentType
+	}
 	if config.UserAgent == "" {
 		config.UserAgent = rest.DefaultKubernetesUserAgent()
 	}
@@ -130,6 +184,11 @@ func newClient(config *rest.Config, options Options) (*client, error) {
 			return nil, err
 		}
 	}
+	// Use a copy of the HTTP client that shares its transport, and with it the
+	// connections, but honors Impersonate options and controller rate limits.
+	httpClient := *options.HTTPClient
+	httpClient.Transport = wrapClientTransport(httpClient.Transport)
+	options.HTTPClient = &httpClient
 
 	// Init a scheme if none provided
 	if options.Scheme == nil {
@@ -176,6 +235,12 @@ func newClient(config *rest.Config, options Options) (*client, error) {
 		},
 		scheme: options.Scheme,
 		mapper: options.Mapper,
+
+		configImpersonates: config.Impersonate.UserName != "" || config.Impersonate.UID != "" ||
+			len(config.Impersonate.Groups) > 0 || len(config.Impersonate.Extra) > 0,
+	}
+	if options.CoalesceLiveReads {
+		c.liveReads = &singleflight.Group{}
 	}
 	if options.Cache == nil || options.Cache.Reader == nil {
 		return c, nil
@@ -187,6 +252,7 @@ func newClient(config *rest.Config, options Options) (*client, error) {
 
 	// Load uncached GVKs.
 	c.cacheUnstructured = options.Cache.Unstructured
+	c.quorumReadMaxAge = options.Cache.QuorumReadOnNotFoundMaxAge
 	c.uncachedGVKs = map[schema.GroupVersionKind]struct{}{}
 	for _, obj := range options.Cache.DisableFor {
 		gvk, err := c.GroupVersionKindFor(obj)
@@ -210,9 +276,17 @@ type client struct {
 	scheme             *runtime.Scheme
 	mapper             meta.RESTMapper
 
+	// configImpersonates is set if the rest.Config impersonates a user, in
+	// which case Impersonate options are rejected.
+	configImpersonates bool
+
 	cache             Reader
 	uncachedGVKs      map[schema.GroupVersionKind]struct{}
 	cacheUnstructured bool
+	quorumReadMaxAge  time.Duration
+
+	// liveReads coalesces identical live reads. It is nil if disabled.
+	liveReads *singleflight.Group
 }
 
 func (c *client) shouldBypassCache(obj runtime.Object) (bool, error) {
@@ -270,6 +344,10 @@ func (c *client) RESTMapper() meta.RESTMapper {
 
 // Create implements client.Client.
 func (c *client) Create(ctx context.Context, obj Object, opts ...CreateOption) error {
+	ctx, _, err := withImpersonation(ctx, c, opts)
+	if err != nil {
+		return err
+	}
 	switch obj.(type) {
 	case runtime.Unstructured:
 		return c.unstructuredClient.Create(ctx, obj, opts...)
@@ -282,6 +360,10 @@ func (c *client) Create(ctx context.Context, obj Object, opts ...CreateOption) e
 
 // Update implements client.Client.
 func (c *client) Update(ctx context.Context, obj Object, opts ...UpdateOption) error {
+	ctx, _, err := withImpersonation(ctx, c, opts)
+	if err != nil {
+		return err
+	}
 	defer c.resetGroupVersionKind(obj, obj.GetObjectKind().GroupVersionKind())
 	switch obj.(type) {
 	case runtime.Unstructured:
@@ -295,6 +377,10 @@ func (c *client) Update(ctx context.Context, obj Object, opts ...UpdateOption) e
 
 // Delete implements client.Client.
 func (c *client) Delete(ctx context.Context, obj Object, opts ...DeleteOption) error {
+	ctx, _, err := withImpersonation(ctx, c, opts)
+	if err != nil {
+		return err
+	}
 	switch obj.(type) {
 	case runtime.Unstructured:
 		return c.unstructuredClient.Delete(ctx, obj, opts...)
@@ -307,6 +393,10 @@ func (c *client) Delete(ctx context.Context, obj Object, opts ...DeleteOption) e
 
 // DeleteAllOf implements client.Client.
 func (c *client) DeleteAllOf(ctx context.Context, obj Object, opts ...DeleteAllOfOption) error {
+	ctx, _, err := withImpersonation(ctx, c, opts)
+	if err != nil {
+		return err
+	}
 	switch obj.(type) {
 	case runtime.Unstructured:
 		return c.unstructuredClient.DeleteAllOf(ctx, obj, opts...)
@@ -319,6 +409,10 @@ func (c *client) DeleteAllOf(ctx context.Context, obj Object, opts ...DeleteAllO
 
 // Patch implements client.Client.
 func (c *client) Patch(ctx context.Context, obj Object, patch Patch, opts ...PatchOption) error {
+	ctx, _, err := withImpersonation(ctx, c, opts)
+	if err != nil {
+		return err
+	}
 	defer c.resetGroupVersionKind(obj, obj.GetObjectKind().GroupVersionKind())
 	switch obj.(type) {
 	case runtime.Unstructured:
@@ -332,14 +426,128 @@ func (c *client) Patch(ctx context.Context, obj Object, patch Patch, opts ...Pat
 
 // Get implements client.Client.
 func (c *client) Get(ctx context.Context, key ObjectKey, obj Object, opts ...GetOption) error {
+	ctx, impersonating, err := withImpersonation(ctx, c, opts)
+	if err != nil {
+		return err
+	}
+	if impersonating {
+		return c.liveGet(ctx, key, obj, opts...)
+	}
 	if isUncached, err := c.shouldBypassCache(obj); err != nil {
 		return err
 	} else if !isUncached {
 		// Attempt to get from the cache.
-		return c.cache.Get(ctx, key, obj, opts...)
+		err := c.cache.Get(ctx, key, obj, opts...)
+		if apierrors.IsNotFound(err) {
+			return c.quorumGet(ctx, key, obj, err, opts...)
+		}
+		return err
 	}
 
-	// Perform a live lookup.
+	return c.coalescedGet(ctx, key, obj, opts...)
+}
+
+// quorumGet retries a Get that wasn't found in the cache with a live quorum
+// read, if configured through CacheOptions.QuorumReadOnNotFoundMaxAge or the
+// QuorumReadOnNotFound GetOption. Otherwise, it returns notFound.
+func (c *client) quorumGet(ctx context.Context, key ObjectKey, obj Object, notFound error, opts ...GetOption) error {
+	getOpts := (&GetOptions{}).ApplyOptions(opts)
+	maxAge := c.quorumReadMaxAge
+	if getOpts.QuorumReadOnNotFound != nil {
+		if !*getOpts.QuorumReadOnNotFound {
+			return notFound
+		}
+		maxAge = 0
+	} else if maxAge == 0 {
+		return notFound
+	}
+
+	gvk, err := c.GroupVersionKindFor(obj)
+	if err != nil {
+		return err
+	}
+	result := func(result string) {
+		metrics.QuorumReadTotal.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, result).Inc()
+	}
+
+	// A quorum read requires an empty resourceVersion.
+	raw := getOpts.AsGetOptions().DeepCopy()
+	raw.ResourceVersion = ""
+	live := obj.DeepCopyObject().(Object)
+	if err := c.coalescedGet(ctx, key, live, append(opts, &GetOptions{Raw: raw})...); err != nil {
+		if apierrors.IsNotFound(err) {
+			result("not_found")
+			return notFound
+		}
+		result("error")
+		return err
+	}
+	if maxAge > 0 && time.Since(live.GetCreationTimestamp().Time) > maxAge {
+		result("too_old")
+		return notFound
+	}
+	result("found")
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(live).Elem())
+	return nil
+}
+
+// coalescedGet reads the object from the API server, sharing the request with
+// identical in-flight reads if enabled.
+func (c *client) coalescedGet(ctx context.Context, key ObjectKey, obj Object, opts ...GetOption) error {
+	if c.liveReads == nil {
+		return c.liveGet(ctx, key, obj, opts...)
+	}
+	return c.coalesce("get", obj, key.String(), (&GetOptions{}).ApplyOptions(opts).AsGetOptions(), func(into runtime.Object) error {
+		return c.liveGet(ctx, key, into.(Object), opts...)
+	})
+}
+
+// coalescedList lists the objects from the API server, sharing the request
+// with identical in-flight lists if enabled.
+func (c *client) coalescedList(ctx context.Context, obj ObjectList, opts ...ListOption) error {
+	if c.liveReads == nil {
+		return c.liveList(ctx, obj, opts...)
+	}
+	listOpts := (&ListOptions{}).ApplyOptions(opts)
+	return c.coalesce("list", obj, listOpts.Namespace, listOpts.AsListOptions(), func(into runtime.Object) error {
+		return c.liveList(ctx, into.(ObjectList), opts...)
+	})
+}
+
+// coalesce runs read with a copy of obj, unless an identical read is already
+// in flight, and copies the result into obj.
+func (c *client) coalesce(verb string, obj runtime.Object, key string, opts any, read func(into runtime.Object) error) error {
+	gvk, err := c.GroupVersionKindFor(obj)
+	if err != nil {
+		return err
+	}
+	encodedOpts, err := json.Marshal(opts)
+	if err != nil {
+		return err
+	}
+
+	leader := false
+	res, err, _ := c.liveReads.Do(fmt.Sprintf("%s|%T|%s|%s|%s", verb, obj, gvk, key, encodedOpts), func() (any, error) {
+		leader = true
+		into := obj.DeepCopyObject()
+		if err := read(into); err != nil {
+			return nil, err
+		}
+		return into, nil
+	})
+	if !leader {
+		metrics.CoalescedReadTotal.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, verb).Inc()
+	}
+	if err != nil {
+		return err
+	}
+	// The result is shared between all callers, so each of them gets its own copy.
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(res.(runtime.Object).DeepCopyObject()).Elem())
+	return nil
+}
+
+// liveGet reads the object from the API server.
+func (c *client) liveGet(ctx context.Context, key ObjectKey, obj Object, opts ...GetOption) error {
 	switch obj.(type) {
 	case runtime.Unstructured:
 		return c.unstructuredClient.Get(ctx, key, obj, opts...)
@@ -354,6 +562,13 @@ func (c *client) Get(ctx context.Context, key ObjectKey, obj Object, opts ...Get
 
 // List implements client.Client.
 func (c *client) List(ctx context.Context, obj ObjectList, opts ...ListOption) error {
+	ctx, impersonating, err := withImpersonation(ctx, c, opts)
+	if err != nil {
+		return err
+	}
+	if impersonating {
+		return c.liveList(ctx, obj, opts...)
+	}
 	if isUncached, err := c.shouldBypassCache(obj); err != nil {
 		return err
 	} else if !isUncached {
@@ -361,7 +576,11 @@ func (c *client) List(ctx context.Context, obj ObjectList, opts ...ListOption) e
 		return c.cache.List(ctx, obj, opts...)
 	}
 
-	// Perform a live lookup.
+	return c.coalescedList(ctx, obj, opts...)
+}
+
+// liveList lists the objects from the API server.
+func (c *client) liveList(ctx context.Context, obj ObjectList, opts ...ListOption) error {
 	switch x := obj.(type) {
 	case runtime.Unstructured:
 		return c.unstructuredClient.List(ctx, obj, opts...)
@@ -536,6 +755,10 @@ func (po *SubResourcePatchOptions) ApplyToSubResourcePatch(o *SubResourcePatchOp
 }
 
 func (sc *subResourceClient) Get(ctx context.Context, obj Object, subResource Object, opts ...SubResourceGetOption) error {
+	ctx, _, err := withImpersonation(ctx, sc.client, opts)
+	if err != nil {
+		return err
+	}
 	switch obj.(type) {
 	case runtime.Unstructured:
 		return sc.client.unstructuredClient.GetSubResource(ctx, obj, subResource, sc.subResource, opts...)
@@ -548,6 +771,10 @@ func (sc *subResourceClient) Get(ctx context.Context, obj Object, subResource Ob
 
 // Create implements client.SubResourceClient
 func (sc *subResourceClient) Create(ctx context.Context, obj Object, subResource Object, opts ...SubResourceCreateOption) error {
+	ctx, _, err := withImpersonation(ctx, sc.client, opts)
+	if err != nil {
+		return err
+	}
 	defer sc.client.resetGroupVersionKind(obj, obj.GetObjectKind().GroupVersionKind())
 	defer sc.client.resetGroupVersionKind(subResource, subResource.GetObjectKind().GroupVersionKind())
 
@@ -563,6 +790,10 @@ func (sc *subResourceClient) Create(ctx context.Context, obj Object, subResource
 
 // Update implements client.SubResourceClient
 func (sc *subResourceClient) Update(ctx context.Context, obj Object, opts ...SubResourceUpdateOption) error {
+	ctx, _, err := withImpersonation(ctx, sc.client, opts)
+	if err != nil {
+		return err
+	}
 	defer sc.client.resetGroupVersionKind(obj, obj.GetObjectKind().GroupVersionKind())
 	switch obj.(type) {
 	case runtime.Unstructured:
@@ -576,6 +807,10 @@ func (sc *subResourceClient) Update(ctx context.Context, obj Object, opts ...Sub
 
 // Patch implements client.SubResourceWriter.
 func (sc *subResourceClient) Patch(ctx context.Context, obj Object, patch Patch, opts ...SubResourcePatchOption) error {
+	ctx, _, err := withImpersonation(ctx, sc.client, opts)
+	if err != nil {
+		return err
+	}
 	defer sc.client.resetGroupVersionKind(obj, obj.GetObjectKind().GroupVersionKind())
 	switch obj.(type) {
 	case runtime.Unstructured:
@@ -586,3 +821,13 @@ func (sc *subResourceClient) Patch(ctx context.Context, obj Object, patch Patch,
 		return sc.client.typedClient.PatchSubResource(ctx, obj, sc.subResource, patch, opts...)
 	}
 }
+
+// wrapClientTransport wraps rt with the round-trippers that clients created by
+// New add to their HTTP client, which honor Impersonate options and the client
+// rate limiters of controllers.
+func wrapClientTransport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &impersonatingRoundTripper{delegate: &controllerRateLimitingRoundTripper{delegate: rt}}
+}
@@ -3747,6 +3747,70 @@ var _ = Describe("ClientWithCache", func() {
 			})
 		})
 	})
+	Describe("Get with quorum read fallback", func() {
+		var dep *appsv1.Deployment
+
+		BeforeEach(func() {
+			dep = &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{GenerateName: "quorum-read-", Namespace: "default"},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "quorum"}},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "quorum"}},
+						Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "x", Image: "x"}}},
+					},
+				},
+			}
+			var err error
+			dep, err = clientset.AppsV1().Deployments("default").Create(context.Background(), dep, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() {
+			Expect(clientset.AppsV1().Deployments("default").Delete(context.Background(), dep.Name, metav1.DeleteOptions{})).To(Succeed())
+		})
+
+		It("should return NotFound from the cache by default", func() {
+			cl, err := client.New(cfg, client.Options{Cache: &client.CacheOptions{Reader: &notFoundReader{}}})
+			Expect(err).NotTo(HaveOccurred())
+			err = cl.Get(context.Background(), client.ObjectKeyFromObject(dep), &appsv1.Deployment{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should read recently created objects from the API server", func() {
+			cl, err := client.New(cfg, client.Options{Cache: &client.CacheOptions{
+				Reader:                     &notFoundReader{},
+				QuorumReadOnNotFoundMaxAge: time.Hour,
+			}})
+			Expect(err).NotTo(HaveOccurred())
+			actual := &appsv1.Deployment{}
+			Expect(cl.Get(context.Background(), client.ObjectKeyFromObject(dep), actual)).To(Succeed())
+			Expect(actual.UID).To(Equal(dep.UID))
+
+			By("not falling back if disabled for the call")
+			err = cl.Get(context.Background(), client.ObjectKeyFromObject(dep), &appsv1.Deployment{}, client.QuorumReadOnNotFoundOption(false))
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should return NotFound for objects older than the max age", func() {
+			cl, err := client.New(cfg, client.Options{Cache: &client.CacheOptions{
+				Reader:                     &notFoundReader{},
+				QuorumReadOnNotFoundMaxAge: time.Nanosecond,
+			}})
+			Expect(err).NotTo(HaveOccurred())
+			actual := &appsv1.Deployment{}
+			err = cl.Get(context.Background(), client.ObjectKeyFromObject(dep), actual)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(actual.UID).To(BeEmpty())
+		})
+
+		It("should read from the API server when requested through the GetOption", func() {
+			cl, err := client.New(cfg, client.Options{Cache: &client.CacheOptions{Reader: &notFoundReader{}}})
+			Expect(err).NotTo(HaveOccurred())
+			actual := &appsv1.Deployment{}
+			Expect(cl.Get(context.Background(), client.ObjectKeyFromObject(dep), actual, client.QuorumReadOnNotFound)).To(Succeed())
+			Expect(actual.UID).To(Equal(dep.UID))
+		})
+	})
 	Describe("List", func() {
 		It("should call cache reader when structured object", func() {
 			cachedReader := &fakeReader{}
@@ -4013,6 +4077,16 @@ func (f *fakeReader) List(ctx context.Context, list client.ObjectList, opts ...c
 	return nil
 }
 
+type notFoundReader struct{}
+
+func (f *notFoundReader) Get(_ context.Context, key client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+	return apierrors.NewNotFound(schema.GroupResource{}, key.Name)
+}
+
+func (f *notFoundReader) List(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+	return nil
+}
+
 type fakeUncachedReader struct {
 	Called int
 }
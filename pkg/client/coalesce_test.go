@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestCoalesceLiveReads(t *testing.T) {
+	var requests atomic.Int32
+	var release chan struct{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		var obj any = &corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		}
+		if r.URL.Path == "/api/v1/namespaces/default/pods" {
+			obj = &corev1.PodList{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PodList"}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(obj)
+	}))
+	defer server.Close()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	c, err := client.New(&rest.Config{Host: server.URL}, client.Options{
+		Scheme:            scheme.Scheme,
+		Mapper:            mapper,
+		CoalesceLiveReads: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	read := func(n int, f func() error) {
+		t.Helper()
+		requests.Store(0)
+		release = make(chan struct{})
+		var wg sync.WaitGroup
+		errs := make(chan error, n)
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- f()
+			}()
+		}
+		// Give the callers time to join the in-flight request before the
+		// server answers it.
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	pods := make([]*corev1.Pod, 5)
+	var i atomic.Int32
+	read(5, func() error {
+		pod := &corev1.Pod{}
+		pods[i.Add(1)-1] = pod
+		return c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "foo"}, pod)
+	})
+	if n := requests.Load(); n >= 5 {
+		t.Errorf("expected concurrent gets to be coalesced, got %d requests", n)
+	}
+	for _, pod := range pods {
+		if pod.Name != "foo" {
+			t.Errorf("expected every caller to receive pod foo, got %q", pod.Name)
+		}
+	}
+	pods[0].Labels = map[string]string{"mutated": "true"}
+	for _, pod := range pods[1:] {
+		if pod.Labels != nil {
+			t.Error("expected every caller to receive its own copy")
+		}
+	}
+
+	read(3, func() error {
+		return c.List(context.Background(), &corev1.PodList{}, client.InNamespace("default"))
+	})
+	if n := requests.Load(); n >= 3 {
+		t.Errorf("expected concurrent lists to be coalesced, got %d requests", n)
+	}
+}
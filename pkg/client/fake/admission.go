@@ -0,0 +1,272 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	// Using v4 to match upstream
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// WithMutatingWebhook registers an admission handler that is called for
+// creates and updates of objects of the same GroupVersionKind as obj, like a
+// mutating webhook would be called by the API server. The patches it returns
+// are applied to the object. Requests with DryRun=All are admitted as well,
+// but neither the patches are applied to the passed object nor is it persisted.
+//
+// The handlers built by admission.WithCustomDefaulter, which
+// builder.WebhookManagedBy registers for WithDefaulter, can be passed directly.
+func (f *ClientBuilder) WithMutatingWebhook(obj runtime.Object, handler admission.Handler) *ClientBuilder {
+	f.webhooks = append(f.webhooks, webhookRegistration{obj: obj, handler: handler, mutating: true})
+	return f
+}
+
+// WithValidatingWebhook registers an admission handler that is called for
+// creates, updates and deletes of objects of the same GroupVersionKind as obj,
+// like a validating webhook would be called by the API server. Denials are
+// returned as the API errors the API server would return.
+//
+// The handlers built by admission.WithCustomValidator, which
+// builder.WebhookManagedBy registers for WithValidator, can be passed directly.
+func (f *ClientBuilder) WithValidatingWebhook(obj runtime.Object, handler admission.Handler) *ClientBuilder {
+	f.webhooks = append(f.webhooks, webhookRegistration{obj: obj, handler: handler})
+	return f
+}
+
+type webhookRegistration struct {
+	obj      runtime.Object
+	handler  admission.Handler
+	mutating bool
+}
+
+// webhook is an admission handler registered for a single kind. Like for the
+// API server, mutating webhooks are called in the order they were registered,
+// before the object is validated against its schema, and validating webhooks
+// are called afterwards. Requests to subresources don't go through webhooks.
+type webhook struct {
+	name     string
+	gvk      schema.GroupVersionKind
+	handler  admission.Handler
+	mutating bool
+}
+
+func newWebhooks(registrations []webhookRegistration, s *runtime.Scheme) []webhook {
+	webhooks := make([]webhook, 0, len(registrations))
+	for _, r := range registrations {
+		gvk, err := apiutil.GVKForObject(r.obj, s)
+		if err != nil {
+			panic(fmt.Errorf("failed to get gvk for webhook object %T: %w", r.obj, err))
+		}
+		// Name the webhooks like the paths builder.WebhookManagedBy registers them at.
+		prefix := "validate-"
+		if r.mutating {
+			prefix = "mutate-"
+		}
+		webhooks = append(webhooks, webhook{
+			name:     prefix + strings.ReplaceAll(gvk.Group, ".", "-") + "-" + gvk.Version + "-" + strings.ToLower(gvk.Kind),
+			gvk:      gvk,
+			handler:  r.handler,
+			mutating: r.mutating,
+		})
+	}
+	return webhooks
+}
+
+// callWebhooks calls the mutating or validating webhooks registered for the kind
+// of obj, which is updated in place with the patches of mutating webhooks.
+// oldObj is nil for creates, obj is nil for deletes.
+func (t versionedTracker) callWebhooks(mutating bool, operation admissionv1.Operation, gvr schema.GroupVersionResource, obj, oldObj runtime.Object, dryRun []string) error {
+	if len(t.webhooks) == 0 {
+		return nil
+	}
+	current := obj
+	if current == nil {
+		current = oldObj
+	}
+	gvk, err := apiutil.GVKForObject(current, t.scheme)
+	if err != nil {
+		return err
+	}
+	accessor, err := meta.Accessor(current)
+	if err != nil {
+		return err
+	}
+
+	for _, wh := range t.webhooks {
+		if wh.mutating != mutating || wh.gvk != gvk {
+			continue
+		}
+		req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:             uuid.NewUUID(),
+			Kind:            metav1.GroupVersionKind(gvk),
+			Resource:        metav1.GroupVersionResource(gvr),
+			RequestKind:     ptr.To(metav1.GroupVersionKind(gvk)),
+			RequestResource: ptr.To(metav1.GroupVersionResource(gvr)),
+			Name:            accessor.GetName(),
+			Namespace:       accessor.GetNamespace(),
+			Operation:       operation,
+			DryRun:          ptr.To(len(dryRun) > 0),
+		}}
+		if obj != nil {
+			if req.Object.Raw, err = encodeForWebhook(obj, gvk); err != nil {
+				return err
+			}
+		}
+		if oldObj != nil {
+			if req.OldObject.Raw, err = encodeForWebhook(oldObj, gvk); err != nil {
+				return err
+			}
+		}
+
+		resp := wh.handler.Handle(context.Background(), req)
+		if !resp.Allowed {
+			return webhookDeniedError(wh.name, resp.Result)
+		}
+		if mutating && obj != nil {
+			if err := applyWebhookPatch(obj, req.Object.Raw, resp); err != nil {
+				return apierrors.NewInternalError(fmt.Errorf("failed to apply patch of admission webhook %q: %w", wh.name, err))
+			}
+		}
+	}
+	return nil
+}
+
+// admitDelete calls the validating webhooks for the deletion of the stored object.
+func (c *fakeClient) admitDelete(gvr schema.GroupVersionResource, accessor metav1.Object, dryRun []string) error {
+	if len(c.tracker.webhooks) == 0 {
+		return nil
+	}
+	stored, err := c.tracker.Get(gvr, accessor.GetNamespace(), accessor.GetName())
+	if err != nil {
+		return err
+	}
+	return c.tracker.callWebhooks(false, admissionv1.Delete, gvr, nil, stored, dryRun)
+}
+
+// admitDryRun runs the admission of a create or update with DryRun=All, which
+// is not persisted afterwards. It works on a copy of obj, so that obj is left
+// as it was passed in.
+func (t versionedTracker) admitDryRun(operation admissionv1.Operation, gvr schema.GroupVersionResource, obj, oldObj runtime.Object, dryRun []string) error {
+	obj = obj.DeepCopyObject()
+	if err := t.callWebhooks(true, operation, gvr, obj, oldObj, dryRun); err != nil {
+		return err
+	}
+	if err := t.admit(obj, oldObj); err != nil {
+		return err
+	}
+	return t.callWebhooks(false, operation, gvr, obj, oldObj, dryRun)
+}
+
+// admitsWrites returns true if writes are admitted by webhooks or CRD schemas.
+func (t versionedTracker) admitsWrites() bool {
+	return len(t.webhooks) > 0 || len(t.crdSchemas) > 0
+}
+
+// encodeForWebhook encodes obj with its apiVersion and kind set, which typed
+// objects of the fake client don't necessarily have.
+func encodeForWebhook(obj runtime.Object, gvk schema.GroupVersionKind) ([]byte, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u["apiVersion"], u["kind"] = gvk.GroupVersion().String(), gvk.Kind
+	return json.Marshal(u)
+}
+
+// applyWebhookPatch applies the JSON patch of a mutating webhook response to obj.
+func applyWebhookPatch(obj runtime.Object, original []byte, resp admission.Response) error {
+	patch := resp.Patch
+	if len(resp.Patches) > 0 {
+		var err error
+		if patch, err = json.Marshal(resp.Patches); err != nil {
+			return err
+		}
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+	if resp.PatchType != nil && *resp.PatchType != admissionv1.PatchTypeJSONPatch {
+		return fmt.Errorf("unsupported patch type %q", *resp.PatchType)
+	}
+
+	decoded, err := jsonpatch.DecodePatch(patch)
+	if err != nil {
+		return err
+	}
+	patched, err := decoded.Apply(original)
+	if err != nil {
+		return err
+	}
+
+	if unstructuredObj, isUnstructured := obj.(runtime.Unstructured); isUnstructured {
+		u := map[string]interface{}{}
+		if err := json.Unmarshal(patched, &u); err != nil {
+			return err
+		}
+		unstructuredObj.SetUnstructuredContent(u)
+		return nil
+	}
+	// Keep the TypeMeta of typed objects as it was passed in.
+	objGVK := obj.GetObjectKind().GroupVersionKind()
+	zero(obj)
+	if err := json.Unmarshal(patched, obj); err != nil {
+		return err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(objGVK)
+	return nil
+}
+
+// webhookDeniedError converts the result of a denied admission request to
+// an error the same way the API server does.
+func webhookDeniedError(name string, result *metav1.Status) error {
+	status := metav1.Status{Status: metav1.StatusFailure}
+	if result != nil {
+		status = *result.DeepCopy()
+	}
+	if status.Code < http.StatusBadRequest {
+		status.Code = http.StatusBadRequest
+	}
+	if status.Status == "" || status.Status == metav1.StatusSuccess {
+		status.Status = metav1.StatusFailure
+	}
+	deniedBy := fmt.Sprintf("admission webhook %q denied the request", name)
+	switch {
+	case status.Message != "":
+		status.Message = fmt.Sprintf("%s: %s", deniedBy, status.Message)
+	case status.Reason != "":
+		status.Message = fmt.Sprintf("%s: %s", deniedBy, status.Reason)
+	default:
+		status.Message = deniedBy + " without explanation"
+	}
+	return &apierrors.StatusError{ErrStatus: status}
+}
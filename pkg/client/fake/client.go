@@ -31,12 +31,14 @@ import (
 
 	// Using v4 to match upstream
 	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	admissionv1 "k8s.io/api/admission/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -45,6 +47,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -58,7 +61,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
-	"sigs.k8s.io/controller-runtime/pkg/internal/field/selector"
 	"sigs.k8s.io/controller-runtime/pkg/internal/objectutil"
 )
 
@@ -66,6 +68,10 @@ type versionedTracker struct {
 	testing.ObjectTracker
 	scheme                *runtime.Scheme
 	withStatusSubresource sets.Set[schema.GroupVersionKind]
+	gc                    *garbageCollector
+	crdSchemas            map[schema.GroupVersionKind]*crdSchema
+	history               *watchHistory
+	webhooks              []webhook
 }
 
 type fakeClient struct {
@@ -78,6 +84,15 @@ type fakeClient struct {
 	// The inner map maps from index name to IndexerFunc.
 	indexes map[schema.GroupVersionKind]map[string]client.IndexerFunc
 
+	// selectableFields maps the GVKs of CRDs to the selectableFields of their versions.
+	selectableFields map[schema.GroupVersionKind]map[string]selectableField
+
+	podLogs     map[podContainerKey]string
+	podExecFunc PodExecFunc
+
+	// gc simulates the garbage collector. It is nil if disabled.
+	gc *garbageCollector
+
 	schemeWriteLock sync.Mutex
 }
 
@@ -116,6 +131,18 @@ type ClientBuilder struct {
 	// indexes maps each GroupVersionKind (GVK) to the indexes registered for that GVK.
 	// The inner map maps from index name to IndexerFunc.
 	indexes map[schema.GroupVersionKind]map[string]client.IndexerFunc
+
+	podLogs     map[podContainerKey]string
+	podExecFunc PodExecFunc
+
+	garbageCollection bool
+
+	crds []*apiextensionsv1.CustomResourceDefinition
+
+	webhooks []webhookRegistration
+
+	watchHistorySize      int
+	watchBookmarkInterval time.Duration
 }
 
 // WithScheme sets this builder's internal scheme.
@@ -164,6 +191,10 @@ func (f *ClientBuilder) WithObjectTracker(ot testing.ObjectTracker) *ClientBuild
 // Invoking WithIndex twice with the same `field` and GVK (via `obj`) arguments will panic.
 // WithIndex retrieves the GVK of `obj` using the scheme registered via WithScheme if
 // WithScheme was previously invoked, the default scheme otherwise.
+//
+// Indexes are not needed for the fields the API server supports in field selectors,
+// like metadata.name, spec.nodeName of Pods or the selectableFields of CRDs passed to
+// WithCRDs. An index registered for such a field takes precedence.
 func (f *ClientBuilder) WithIndex(obj runtime.Object, field string, extractValue client.IndexerFunc) *ClientBuilder {
 	objScheme := f.scheme
 	if objScheme == nil {
@@ -215,10 +246,24 @@ func (f *ClientBuilder) Build() client.WithWatch {
 		f.scheme = scheme.Scheme
 	}
 	if f.restMapper == nil {
-		f.restMapper = meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+		restMapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+		crdKinds(f.crds, func(gvk schema.GroupVersionKind, scope meta.RESTScope, _ bool) {
+			restMapper.Add(gvk, scope)
+		})
+		f.restMapper = restMapper
+	}
+	crdSchemas, err := newCRDSchemas(f.crds)
+	if err != nil {
+		panic(fmt.Errorf("failed to load CRDs: %w", err))
 	}
 
+	webhooks := newWebhooks(f.webhooks, f.scheme)
+
 	var tracker versionedTracker
+	var gc *garbageCollector
+	if f.garbageCollection {
+		gc = &garbageCollector{kinds: map[schema.GroupVersionResource]schema.GroupVersionKind{}}
+	}
 
 	withStatusSubResource := sets.New(inTreeResourcesWithStatus()...)
 	for _, o := range f.withStatusSubresource {
@@ -228,11 +273,20 @@ func (f *ClientBuilder) Build() client.WithWatch {
 		}
 		withStatusSubResource.Insert(gvk)
 	}
+	crdKinds(f.crds, func(gvk schema.GroupVersionKind, _ meta.RESTScope, status bool) {
+		if status {
+			withStatusSubResource.Insert(gvk)
+		}
+	})
 
 	if f.objectTracker == nil {
-		tracker = versionedTracker{ObjectTracker: testing.NewObjectTracker(f.scheme, scheme.Codecs.UniversalDecoder()), scheme: f.scheme, withStatusSubresource: withStatusSubResource}
+		tracker = versionedTracker{ObjectTracker: testing.NewObjectTracker(f.scheme, scheme.Codecs.UniversalDecoder()), scheme: f.scheme, withStatusSubresource: withStatusSubResource, gc: gc, crdSchemas: crdSchemas, webhooks: webhooks}
 	} else {
-		tracker = versionedTracker{ObjectTracker: f.objectTracker, scheme: f.scheme, withStatusSubresource: withStatusSubResource}
+		tracker = versionedTracker{ObjectTracker: f.objectTracker, scheme: f.scheme, withStatusSubresource: withStatusSubResource, gc: gc, crdSchemas: crdSchemas, webhooks: webhooks}
+	}
+	if f.watchHistorySize > 0 || f.watchBookmarkInterval > 0 {
+		tracker.history = newWatchHistory(f.watchHistorySize, f.watchBookmarkInterval)
+		tracker.ObjectTracker = historyTracker{ObjectTracker: tracker.ObjectTracker, scheme: f.scheme, history: tracker.history}
 	}
 
 	for _, obj := range f.initObject {
@@ -256,7 +310,11 @@ func (f *ClientBuilder) Build() client.WithWatch {
 		scheme:                f.scheme,
 		restMapper:            f.restMapper,
 		indexes:               f.indexes,
+		selectableFields:      crdSelectableFields(f.crds),
 		withStatusSubresource: withStatusSubResource,
+		podLogs:               f.podLogs,
+		podExecFunc:           f.podExecFunc,
+		gc:                    gc,
 	}
 
 	if f.interceptorFuncs != nil {
@@ -269,6 +327,8 @@ func (f *ClientBuilder) Build() client.WithWatch {
 const trackerAddResourceVersion = "999"
 
 func (t versionedTracker) Add(obj runtime.Object) error {
+	defer t.lockWrite()()
+
 	var objects []runtime.Object
 	if meta.IsListType(obj) {
 		var err error
@@ -287,13 +347,17 @@ func (t versionedTracker) Add(obj runtime.Object) error {
 		if accessor.GetDeletionTimestamp() != nil && len(accessor.GetFinalizers()) == 0 {
 			return fmt.Errorf("refusing to create obj %s with metadata.deletionTimestamp but no finalizers", accessor.GetName())
 		}
-		if accessor.GetResourceVersion() == "" {
+		switch {
+		case t.history != nil:
+			accessor.SetResourceVersion(t.history.nextResourceVersion())
+		case accessor.GetResourceVersion() == "":
 			// We use a "magic" value of 999 here because this field
 			// is parsed as uint and and 0 is already used in Update.
 			// As we can't go lower, go very high instead so this can
 			// be recognized
 			accessor.SetResourceVersion(trackerAddResourceVersion)
 		}
+		t.gc.setUID(accessor)
 
 		obj, err = convertFromUnstructuredIfNecessary(t.scheme, obj)
 		if err != nil {
@@ -302,6 +366,9 @@ func (t versionedTracker) Add(obj runtime.Object) error {
 		if err := t.ObjectTracker.Add(obj); err != nil {
 			return err
 		}
+		if err := t.gc.record(obj, t.scheme); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -312,6 +379,13 @@ func (t versionedTracker) Create(gvr schema.GroupVersionResource, obj runtime.Ob
 	if err != nil {
 		return fmt.Errorf("failed to get accessor for object: %w", err)
 	}
+	if accessor.GetName() == "" && accessor.GetGenerateName() != "" {
+		base := accessor.GetGenerateName()
+		if len(base) > maxGeneratedNameLength {
+			base = base[:maxGeneratedNameLength]
+		}
+		accessor.SetName(fmt.Sprintf("%s%s", base, utilrand.String(randomLength)))
+	}
 	if accessor.GetName() == "" {
 		return apierrors.NewInvalid(
 			obj.GetObjectKind().GroupVersionKind().GroupKind(),
@@ -321,7 +395,22 @@ func (t versionedTracker) Create(gvr schema.GroupVersionResource, obj runtime.Ob
 	if accessor.GetResourceVersion() != "" {
 		return apierrors.NewBadRequest("resourceVersion can not be set for Create requests")
 	}
-	accessor.SetResourceVersion("1")
+	if err := t.callWebhooks(true, admissionv1.Create, gvr, obj, nil, nil); err != nil {
+		return err
+	}
+	if err := t.admit(obj, nil); err != nil {
+		return err
+	}
+	if err := t.callWebhooks(false, admissionv1.Create, gvr, obj, nil, nil); err != nil {
+		return err
+	}
+	defer t.lockWrite()()
+	if t.history != nil {
+		accessor.SetResourceVersion(t.history.nextResourceVersion())
+	} else {
+		accessor.SetResourceVersion("1")
+	}
+	t.gc.setUID(accessor)
 	obj, err = convertFromUnstructuredIfNecessary(t.scheme, obj)
 	if err != nil {
 		return err
@@ -331,7 +420,7 @@ func (t versionedTracker) Create(gvr schema.GroupVersionResource, obj runtime.Ob
 		return err
 	}
 
-	return nil
+	return t.gc.record(obj, t.scheme)
 }
 
 // convertFromUnstructuredIfNecessary will convert runtime.Unstructured for a GVK that is recognized
@@ -375,13 +464,14 @@ func (t versionedTracker) Update(gvr schema.GroupVersionResource, obj runtime.Ob
 }
 
 func (t versionedTracker) update(gvr schema.GroupVersionResource, obj runtime.Object, ns string, isStatus, deleting bool, opts metav1.UpdateOptions) error {
-	obj, err := t.updateObject(gvr, obj, ns, isStatus, deleting, opts.DryRun)
+	obj, unlock, err := t.updateObject(gvr, obj, ns, isStatus, deleting, opts.DryRun)
 	if err != nil {
 		return err
 	}
 	if obj == nil {
 		return nil
 	}
+	defer unlock()
 
 	return t.ObjectTracker.Update(gvr, obj, ns, opts)
 }
@@ -399,25 +489,26 @@ func (t versionedTracker) Patch(gvr schema.GroupVersionResource, obj runtime.Obj
 		isStatus = true
 	}
 
-	obj, err = t.updateObject(gvr, obj, ns, isStatus, false, patchOptions.DryRun)
+	obj, unlock, err := t.updateObject(gvr, obj, ns, isStatus, false, patchOptions.DryRun)
 	if err != nil {
 		return err
 	}
 	if obj == nil {
 		return nil
 	}
+	defer unlock()
 
 	return t.ObjectTracker.Patch(gvr, obj, ns, patchOptions)
 }
 
-func (t versionedTracker) updateObject(gvr schema.GroupVersionResource, obj runtime.Object, ns string, isStatus, deleting bool, dryRun []string) (runtime.Object, error) {
+func (t versionedTracker) updateObject(gvr schema.GroupVersionResource, obj runtime.Object, ns string, isStatus, deleting bool, dryRun []string) (runtime.Object, func(), error) {
 	accessor, err := meta.Accessor(obj)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get accessor for object: %w", err)
+		return nil, nil, fmt.Errorf("failed to get accessor for object: %w", err)
 	}
 
 	if accessor.GetName() == "" {
-		return nil, apierrors.NewInvalid(
+		return nil, nil, apierrors.NewInvalid(
 			obj.GetObjectKind().GroupVersionKind().GroupKind(),
 			accessor.GetName(),
 			field.ErrorList{field.Required(field.NewPath("metadata.name"), "name is required")})
@@ -425,7 +516,7 @@ func (t versionedTracker) updateObject(gvr schema.GroupVersionResource, obj runt
 
 	gvk, err := apiutil.GVKForObject(obj, t.scheme)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	oldObject, err := t.ObjectTracker.Get(gvr, ns, accessor.GetName())
@@ -433,33 +524,50 @@ func (t versionedTracker) updateObject(gvr schema.GroupVersionResource, obj runt
 		// If the resource is not found and the resource allows create on update, issue a
 		// create instead.
 		if apierrors.IsNotFound(err) && allowsCreateOnUpdate(gvk) {
-			return nil, t.Create(gvr, obj, ns)
+			return nil, nil, t.Create(gvr, obj, ns)
 		}
-		return nil, err
+		return nil, nil, err
 	}
 
 	if t.withStatusSubresource.Has(gvk) {
 		if isStatus { // copy everything but status and metadata.ResourceVersion from original object
 			if err := copyStatusFrom(obj, oldObject); err != nil {
-				return nil, fmt.Errorf("failed to copy non-status field for object with status subresouce: %w", err)
+				return nil, nil, fmt.Errorf("failed to copy non-status field for object with status subresouce: %w", err)
 			}
 			passedRV := accessor.GetResourceVersion()
 			if err := copyFrom(oldObject, obj); err != nil {
-				return nil, fmt.Errorf("failed to restore non-status fields: %w", err)
+				return nil, nil, fmt.Errorf("failed to restore non-status fields: %w", err)
 			}
 			accessor.SetResourceVersion(passedRV)
 		} else { // copy status from original object
 			if err := copyStatusFrom(oldObject, obj); err != nil {
-				return nil, fmt.Errorf("failed to copy the status for object with status subresource: %w", err)
+				return nil, nil, fmt.Errorf("failed to copy the status for object with status subresource: %w", err)
 			}
 		}
 	} else if isStatus {
-		return nil, apierrors.NewNotFound(gvr.GroupResource(), accessor.GetName())
+		return nil, nil, apierrors.NewNotFound(gvr.GroupResource(), accessor.GetName())
+	}
+
+	// Webhooks are neither called for the status subresource nor for the
+	// update that marks the object as being deleted.
+	callWebhooks := !isStatus && !deleting
+	if callWebhooks {
+		if err := t.callWebhooks(true, admissionv1.Update, gvr, obj, oldObject, dryRun); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := t.admit(obj, oldObject); err != nil {
+		return nil, nil, err
+	}
+	if callWebhooks {
+		if err := t.callWebhooks(false, admissionv1.Update, gvr, obj, oldObject, dryRun); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	oldAccessor, err := meta.Accessor(oldObject)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// If the new object does not have the resource version set and it allows unconditional update,
@@ -477,26 +585,49 @@ func (t versionedTracker) updateObject(gvr schema.GroupVersionResource, obj runt
 	}
 
 	if accessor.GetResourceVersion() != oldAccessor.GetResourceVersion() {
-		return nil, apierrors.NewConflict(gvr.GroupResource(), accessor.GetName(), errors.New("object was modified"))
+		return nil, nil, apierrors.NewConflict(gvr.GroupResource(), accessor.GetName(), errors.New("object was modified"))
 	}
 	if oldAccessor.GetResourceVersion() == "" {
 		oldAccessor.SetResourceVersion("0")
 	}
 	intResourceVersion, err := strconv.ParseUint(oldAccessor.GetResourceVersion(), 10, 64)
 	if err != nil {
-		return nil, fmt.Errorf("can not convert resourceVersion %q to int: %w", oldAccessor.GetResourceVersion(), err)
+		return nil, nil, fmt.Errorf("can not convert resourceVersion %q to int: %w", oldAccessor.GetResourceVersion(), err)
 	}
 	intResourceVersion++
-	accessor.SetResourceVersion(strconv.FormatUint(intResourceVersion, 10))
+	unlock := t.lockWrite()
+	if t.history != nil {
+		accessor.SetResourceVersion(t.history.nextResourceVersion())
+	} else {
+		accessor.SetResourceVersion(strconv.FormatUint(intResourceVersion, 10))
+	}
 
 	if !deleting && !deletionTimestampEqual(accessor, oldAccessor) {
-		return nil, fmt.Errorf("error: Unable to edit %s: metadata.deletionTimestamp field is immutable", accessor.GetName())
+		unlock()
+		return nil, nil, fmt.Errorf("error: Unable to edit %s: metadata.deletionTimestamp field is immutable", accessor.GetName())
 	}
 
 	if !accessor.GetDeletionTimestamp().IsZero() && len(accessor.GetFinalizers()) == 0 {
-		return nil, t.ObjectTracker.Delete(gvr, accessor.GetNamespace(), accessor.GetName(), metav1.DeleteOptions{DryRun: dryRun})
+		unlock()
+		return nil, nil, t.ObjectTracker.Delete(gvr, accessor.GetNamespace(), accessor.GetName(), metav1.DeleteOptions{DryRun: dryRun})
+	}
+	obj, err = convertFromUnstructuredIfNecessary(t.scheme, obj)
+	if err != nil {
+		unlock()
+		return nil, nil, err
 	}
-	return convertFromUnstructuredIfNecessary(t.scheme, obj)
+	return obj, unlock, nil
+}
+
+// lockWrite prevents List from reading the revision of the watch history
+// while an object with an assigned resourceVersion isn't stored yet, see
+// watchHistory.writes. The returned function unlocks it again.
+func (t versionedTracker) lockWrite() func() {
+	if t.history == nil {
+		return func() {}
+	}
+	t.history.writes.RLock()
+	return t.history.writes.RUnlock
 }
 
 func (c *fakeClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
@@ -544,8 +675,108 @@ func (c *fakeClient) Watch(ctx context.Context, list client.ObjectList, opts ...
 	listOpts := client.ListOptions{}
 	listOpts.ApplyOptions(opts)
 
+	match, err := c.watchFilter(gvk, listOpts.LabelSelector, listOpts.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
 	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
-	return c.tracker.Watch(gvr, listOpts.Namespace)
+	var w watch.Interface
+	if c.tracker.history == nil {
+		w, err = c.tracker.Watch(gvr, listOpts.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if match != nil {
+			w = watch.Filter(w, func(ev watch.Event) (watch.Event, bool) {
+				if ev.Type == watch.Error || ev.Type == watch.Bookmark {
+					return ev, true
+				}
+				return ev, match(ev.Object)
+			})
+		}
+	} else {
+		rawOpts := listOpts.AsListOptions()
+		var bookmark func(resourceVersion string) runtime.Object
+		if rawOpts.AllowWatchBookmarks {
+			bookmark = func(resourceVersion string) runtime.Object {
+				var obj client.Object = &unstructured.Unstructured{}
+				if typed, err := c.scheme.New(gvk); err == nil {
+					obj = typed.(client.Object)
+				}
+				obj.GetObjectKind().SetGroupVersionKind(gvk)
+				obj.SetResourceVersion(resourceVersion)
+				return obj
+			}
+		}
+		w, err = c.tracker.history.watch(gvr, listOpts.Namespace, rawOpts.ResourceVersion, match, bookmark)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return watchAs(w, list, gvk), nil
+}
+
+// watchAs converts the objects sent by w to the item type of list, like the
+// API server does for unstructured and metadata-only watches.
+func watchAs(w watch.Interface, list client.ObjectList, gvk schema.GroupVersionKind) watch.Interface {
+	var convert func(obj runtime.Object) (runtime.Object, error)
+	switch list.(type) {
+	case runtime.Unstructured:
+		convert = func(obj runtime.Object) (runtime.Object, error) {
+			if _, isUnstructured := obj.(runtime.Unstructured); isUnstructured {
+				return obj, nil
+			}
+			u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+			if err != nil {
+				return nil, err
+			}
+			return &unstructured.Unstructured{Object: u}, nil
+		}
+	case *metav1.PartialObjectMetadataList:
+		convert = func(obj runtime.Object) (runtime.Object, error) {
+			if _, isPartial := obj.(*metav1.PartialObjectMetadata); isPartial {
+				return obj, nil
+			}
+			j, err := json.Marshal(obj)
+			if err != nil {
+				return nil, err
+			}
+			partial := &metav1.PartialObjectMetadata{}
+			return partial, json.Unmarshal(j, partial)
+		}
+	default:
+		return w
+	}
+
+	return watch.Filter(w, func(ev watch.Event) (watch.Event, bool) {
+		if ev.Type == watch.Error {
+			return ev, true
+		}
+		obj, err := convert(ev.Object)
+		if err != nil {
+			return watch.Event{Type: watch.Error, Object: &apierrors.NewInternalError(err).ErrStatus}, true
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		ev.Object = obj
+		return ev, true
+	})
+}
+
+// watchFilter returns a function that matches objects against the given
+// selectors the same way List does, or nil if there are no selectors.
+func (c *fakeClient) watchFilter(gvk schema.GroupVersionKind, ls labels.Selector, fs fields.Selector) (func(runtime.Object) bool, error) {
+	if ls == nil && fs == nil {
+		return nil, nil
+	}
+	// Validate the field selector upfront, it can only be used with indexes and selectable fields.
+	if _, err := c.filterList(nil, gvk, ls, fs); err != nil {
+		return nil, err
+	}
+	return func(obj runtime.Object) bool {
+		filtered, err := c.filterList([]runtime.Object{obj}, gvk, ls, fs)
+		return err == nil && len(filtered) == 1
+	}, nil
 }
 
 func (c *fakeClient) List(ctx context.Context, obj client.ObjectList, opts ...client.ListOption) error {
@@ -570,7 +801,15 @@ func (c *fakeClient) List(ctx context.Context, obj client.ObjectList, opts ...cl
 	listOpts.ApplyOptions(opts)
 
 	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
-	o, err := c.tracker.List(gvr, gvk, listOpts.Namespace)
+	var o runtime.Object
+	var resourceVersion string
+	if c.tracker.history != nil {
+		o, resourceVersion, err = c.tracker.history.list(func() (runtime.Object, error) {
+			return c.tracker.List(gvr, gvk, listOpts.Namespace)
+		})
+	} else {
+		o, err = c.tracker.List(gvr, gvk, listOpts.Namespace)
+	}
 	if err != nil {
 		return err
 	}
@@ -592,6 +831,9 @@ func (c *fakeClient) List(ctx context.Context, obj client.ObjectList, opts ...cl
 	if err := json.Unmarshal(j, obj); err != nil {
 		return err
 	}
+	if c.tracker.history != nil {
+		obj.SetResourceVersion(resourceVersion)
+	}
 
 	if listOpts.LabelSelector == nil && listOpts.FieldSelector == nil {
 		return nil
@@ -636,17 +878,19 @@ func (c *fakeClient) filterList(list []runtime.Object, gvk schema.GroupVersionKi
 }
 
 func (c *fakeClient) filterWithFields(list []runtime.Object, gvk schema.GroupVersionKind, fs fields.Selector) ([]runtime.Object, error) {
-	requiresExact := selector.RequiresExactMatch(fs)
-	if !requiresExact {
-		return nil, fmt.Errorf("field selector %s is not in one of the two supported forms \"key==val\" or \"key=val\"",
-			fs)
-	}
-
-	// Field selection is mimicked via indexes, so there's no sane answer this function can give
-	// if there are no indexes registered for the GroupVersionKind of the objects in the list.
+	// Field selection is mimicked via indexes for fields the API server doesn't support,
+	// so there's no sane answer this function can give if there are no indexes registered
+	// for them for the GroupVersionKind of the objects in the list.
 	indexes := c.indexes[gvk]
 	for _, req := range fs.Requirements() {
-		if len(indexes) == 0 || indexes[req.Field] == nil {
+		if indexes[req.Field] != nil {
+			if req.Operator != selection.Equals && req.Operator != selection.DoubleEquals {
+				return nil, fmt.Errorf("field selector %s is not in one of the two supported forms \"key==val\" or \"key=val\"",
+					fs)
+			}
+			continue
+		}
+		if _, ok := c.selectableField(gvk, req.Field); !ok {
 			return nil, fmt.Errorf("List on GroupVersionKind %v specifies selector on field %s, but no "+
 				"index with name %s has been registered for GroupVersionKind %v", gvk, req.Field, req.Field, gvk)
 		}
@@ -656,9 +900,17 @@ func (c *fakeClient) filterWithFields(list []runtime.Object, gvk schema.GroupVer
 	for _, obj := range list {
 		matches := true
 		for _, req := range fs.Requirements() {
-			indexExtractor := indexes[req.Field]
-			if !c.objMatchesFieldSelector(obj, indexExtractor, req.Value) {
-				matches = false
+			if indexExtractor := indexes[req.Field]; indexExtractor != nil {
+				matches = c.objMatchesFieldSelector(obj, indexExtractor, req.Value)
+			} else {
+				f, _ := c.selectableField(gvk, req.Field)
+				val, err := c.selectableFieldValue(obj, gvk, f)
+				if err != nil {
+					return nil, err
+				}
+				matches = (val == req.Value) != (req.Operator == selection.NotEquals)
+			}
+			if !matches {
 				break
 			}
 		}
@@ -708,7 +960,14 @@ func (c *fakeClient) Create(ctx context.Context, obj client.Object, opts ...clie
 
 	for _, dryRunOpt := range createOptions.DryRun {
 		if dryRunOpt == metav1.DryRunAll {
-			return nil
+			if !c.tracker.admitsWrites() {
+				return nil
+			}
+			gvr, err := getGVRFromObject(obj, c.scheme)
+			if err != nil {
+				return err
+			}
+			return c.tracker.admitDryRun(admissionv1.Create, gvr, obj, nil, createOptions.DryRun)
 		}
 	}
 
@@ -721,13 +980,6 @@ func (c *fakeClient) Create(ctx context.Context, obj client.Object, opts ...clie
 		return err
 	}
 
-	if accessor.GetName() == "" && accessor.GetGenerateName() != "" {
-		base := accessor.GetGenerateName()
-		if len(base) > maxGeneratedNameLength {
-			base = base[:maxGeneratedNameLength]
-		}
-		accessor.SetName(fmt.Sprintf("%s%s", base, utilrand.String(randomLength)))
-	}
 	// Ignore attempts to set deletion timestamp
 	if !accessor.GetDeletionTimestamp().IsZero() {
 		accessor.SetDeletionTimestamp(nil)
@@ -748,12 +1000,6 @@ func (c *fakeClient) Delete(ctx context.Context, obj client.Object, opts ...clie
 	delOptions := client.DeleteOptions{}
 	delOptions.ApplyOptions(opts)
 
-	for _, dryRunOpt := range delOptions.DryRun {
-		if dryRunOpt == metav1.DryRunAll {
-			return nil
-		}
-	}
-
 	// Check the ResourceVersion if that Precondition was specified.
 	if delOptions.Preconditions != nil && delOptions.Preconditions.ResourceVersion != nil {
 		name := accessor.GetName()
@@ -776,6 +1022,17 @@ func (c *fakeClient) Delete(ctx context.Context, obj client.Object, opts ...clie
 		}
 	}
 
+	if err := c.admitDelete(gvr, accessor, delOptions.DryRun); err != nil {
+		return err
+	}
+	for _, dryRunOpt := range delOptions.DryRun {
+		if dryRunOpt == metav1.DryRunAll {
+			return nil
+		}
+	}
+	if c.gc != nil {
+		return c.deleteWithGarbageCollection(gvr, accessor, delOptions.AsDeleteOptions())
+	}
 	return c.deleteObject(gvr, accessor)
 }
 
@@ -788,9 +1045,13 @@ func (c *fakeClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ..
 	dcOptions := client.DeleteAllOfOptions{}
 	dcOptions.ApplyOptions(opts)
 
+	dryRun := false
 	for _, dryRunOpt := range dcOptions.DryRun {
 		if dryRunOpt == metav1.DryRunAll {
-			return nil
+			if len(c.tracker.webhooks) == 0 {
+				return nil
+			}
+			dryRun = true
 		}
 	}
 
@@ -804,7 +1065,7 @@ func (c *fakeClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ..
 	if err != nil {
 		return err
 	}
-	filteredObjs, err := objectutil.FilterWithLabels(objs, dcOptions.LabelSelector)
+	filteredObjs, err := c.filterList(objs, gvk, dcOptions.LabelSelector, dcOptions.FieldSelector)
 	if err != nil {
 		return err
 	}
@@ -813,7 +1074,24 @@ func (c *fakeClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ..
 		if err != nil {
 			return err
 		}
-		err = c.deleteObject(gvr, accessor)
+		if err := c.admitDelete(gvr, accessor, dcOptions.DryRun); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if dryRun {
+			continue
+		}
+		if c.gc != nil {
+			err = c.deleteWithGarbageCollection(gvr, accessor, dcOptions.AsDeleteOptions())
+			// The object might have been collected as a dependent of a previously deleted object.
+			if apierrors.IsNotFound(err) {
+				err = nil
+			}
+		} else {
+			err = c.deleteObject(gvr, accessor)
+		}
 		if err != nil {
 			return err
 		}
@@ -822,7 +1100,9 @@ func (c *fakeClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ..
 }
 
 func (c *fakeClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
-	return c.update(obj, false, opts...)
+	return c.collectGarbageAfter(obj, func() error {
+		return c.update(obj, false, opts...)
+	})
 }
 
 func (c *fakeClient) update(obj client.Object, isStatus bool, opts ...client.UpdateOption) error {
@@ -831,7 +1111,19 @@ func (c *fakeClient) update(obj client.Object, isStatus bool, opts ...client.Upd
 
 	for _, dryRunOpt := range updateOptions.DryRun {
 		if dryRunOpt == metav1.DryRunAll {
-			return nil
+			// Like for regular updates, the status subresource isn't admitted.
+			if isStatus || !c.tracker.admitsWrites() {
+				return nil
+			}
+			gvr, err := getGVRFromObject(obj, c.scheme)
+			if err != nil {
+				return err
+			}
+			oldObj, err := c.tracker.Get(gvr, obj.GetNamespace(), obj.GetName())
+			if err != nil {
+				return err
+			}
+			return c.tracker.admitDryRun(admissionv1.Update, gvr, obj, oldObj, updateOptions.DryRun)
 		}
 	}
 
@@ -847,16 +1139,22 @@ func (c *fakeClient) update(obj client.Object, isStatus bool, opts ...client.Upd
 }
 
 func (c *fakeClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
-	return c.patch(obj, patch, opts...)
+	return c.collectGarbageAfter(obj, func() error {
+		return c.patch(obj, patch, opts...)
+	})
 }
 
 func (c *fakeClient) patch(obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
 	patchOptions := &client.PatchOptions{}
 	patchOptions.ApplyOptions(opts)
 
+	dryRun := false
 	for _, dryRunOpt := range patchOptions.DryRun {
 		if dryRunOpt == metav1.DryRunAll {
-			return nil
+			if !c.tracker.admitsWrites() {
+				return nil
+			}
+			dryRun = true
 		}
 	}
 
@@ -908,6 +1206,9 @@ func (c *fakeClient) patch(obj client.Object, patch client.Patch, opts ...client
 	if !deletionTimestampEqual(newObj, oldAccessor) {
 		return fmt.Errorf("rejected patch, metadata.deletionTimestamp immutable")
 	}
+	if dryRun {
+		return c.tracker.admitDryRun(admissionv1.Update, gvr, o, oldObj, patchOptions.DryRun)
+	}
 
 	reaction := testing.ObjectReaction(c.tracker)
 	handled, o, err := reaction(action)
@@ -1101,7 +1402,7 @@ func (c *fakeClient) deleteObject(gvr schema.GroupVersionResource, accessor meta
 		}
 	}
 
-	//TODO: implement propagation
+	// Propagation policies are handled by deleteWithGarbageCollection, if enabled.
 	return c.tracker.Delete(gvr, accessor.GetNamespace(), accessor.GetName())
 }
 
@@ -1223,6 +1524,12 @@ func (sw *fakeSubResourceClient) Patch(ctx context.Context, obj client.Object, p
 }
 
 func (sw *fakeSubResourceClient) statusPatch(body client.Object, patch client.Patch, patchOptions client.SubResourcePatchOptions) error {
+	// Like for regular patches, the status subresource isn't admitted.
+	for _, dryRunOpt := range patchOptions.DryRun {
+		if dryRunOpt == metav1.DryRunAll {
+			return nil
+		}
+	}
 	return sw.client.patch(body, patch, &patchOptions.PatchOptions)
 }
 
@@ -17,15 +17,21 @@ limitations under the License.
 package fake
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
@@ -34,6 +40,7 @@ import (
 	policyv1 "k8s.io/api/policy/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -42,13 +49,20 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/remotecommand"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 const (
@@ -2337,3 +2351,925 @@ var _ = Describe("Fake client builder", func() {
 		Expect(called).To(BeTrue())
 	})
 })
+
+var _ = Describe("Fake client streaming subresources", func() {
+	var pod *corev1.Pod
+	key := client.ObjectKey{Namespace: "default", Name: "pod"}
+
+	BeforeEach(func() {
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app"},
+				{Name: "sidecar"},
+			}},
+		}
+	})
+
+	readLogs := func(sc client.StreamClient, opts *corev1.PodLogOptions) string {
+		logs, err := sc.PodLogs(context.Background(), key, opts)
+		Expect(err).NotTo(HaveOccurred())
+		defer logs.Close()
+		b, err := io.ReadAll(logs)
+		Expect(err).NotTo(HaveOccurred())
+		return string(b)
+	}
+
+	It("should stream the configured logs of a container", func() {
+		cl := NewClientBuilder().WithObjects(pod).
+			WithPodLogs(key, "app", "first\nsecond\nthird\n").
+			Build()
+		sc, err := client.StreamClientFor(cl)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(readLogs(sc, &corev1.PodLogOptions{Container: "app"})).To(Equal("first\nsecond\nthird\n"))
+		Expect(readLogs(sc, &corev1.PodLogOptions{Container: "app", TailLines: ptr.To[int64](2)})).To(Equal("second\nthird\n"))
+		Expect(readLogs(sc, &corev1.PodLogOptions{Container: "app", LimitBytes: ptr.To[int64](5)})).To(Equal("first"))
+		Expect(readLogs(sc, &corev1.PodLogOptions{Container: "sidecar"})).To(BeEmpty())
+	})
+
+	It("should reject negative TailLines and LimitBytes", func() {
+		sc, err := client.StreamClientFor(NewClientBuilder().WithObjects(pod).WithPodLogs(key, "app", "logs").Build())
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = sc.PodLogs(context.Background(), key, &corev1.PodLogOptions{Container: "app", TailLines: ptr.To[int64](-1)})
+		Expect(apierrors.IsBadRequest(err)).To(BeTrue())
+		_, err = sc.PodLogs(context.Background(), key, &corev1.PodLogOptions{Container: "app", LimitBytes: ptr.To[int64](-1)})
+		Expect(apierrors.IsBadRequest(err)).To(BeTrue())
+		Expect(readLogs(sc, &corev1.PodLogOptions{Container: "app", TailLines: ptr.To[int64](0)})).To(BeEmpty())
+	})
+
+	It("should require a container name for pods with multiple containers", func() {
+		sc, err := client.StreamClientFor(NewClientBuilder().WithObjects(pod).Build())
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = sc.PodLogs(context.Background(), key, nil)
+		Expect(apierrors.IsBadRequest(err)).To(BeTrue())
+		_, err = sc.PodLogs(context.Background(), key, &corev1.PodLogOptions{Container: "missing"})
+		Expect(apierrors.IsBadRequest(err)).To(BeTrue())
+	})
+
+	It("should return NotFound for missing pods", func() {
+		sc, err := client.StreamClientFor(NewClientBuilder().WithPodLogs(key, "app", "logs").Build())
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = sc.PodLogs(context.Background(), key, nil)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should stream logs through an interceptor", func() {
+		cl := NewClientBuilder().WithObjects(pod).
+			WithPodLogs(key, "app", "logs").
+			WithInterceptorFuncs(interceptor.Funcs{}).
+			Build()
+		sc, err := client.StreamClientFor(cl)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(readLogs(sc, &corev1.PodLogOptions{Container: "app"})).To(Equal("logs"))
+	})
+
+	It("should execute commands with the configured PodExecFunc", func() {
+		cl := NewClientBuilder().WithObjects(pod).
+			WithPodExecFunc(func(ctx context.Context, key client.ObjectKey, opts *corev1.PodExecOptions, streams remotecommand.StreamOptions) error {
+				_, err := fmt.Fprintf(streams.Stdout, "%s in %s/%s", strings.Join(opts.Command, " "), key.Name, opts.Container)
+				return err
+			}).
+			Build()
+		sc, err := client.StreamClientFor(cl)
+		Expect(err).NotTo(HaveOccurred())
+
+		exec, err := sc.PodExec(key, &corev1.PodExecOptions{Container: "app", Command: []string{"echo", "hi"}, Stdout: true})
+		Expect(err).NotTo(HaveOccurred())
+		stdout := &bytes.Buffer{}
+		Expect(exec.StreamWithContext(context.Background(), remotecommand.StreamOptions{Stdout: stdout})).To(Succeed())
+		Expect(stdout.String()).To(Equal("echo hi in pod/app"))
+	})
+
+	It("should fail to execute commands without a PodExecFunc", func() {
+		sc, err := client.StreamClientFor(NewClientBuilder().WithObjects(pod).Build())
+		Expect(err).NotTo(HaveOccurred())
+
+		exec, err := sc.PodExec(key, &corev1.PodExecOptions{Container: "app", Command: []string{"true"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exec.StreamWithContext(context.Background(), remotecommand.StreamOptions{})).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("Fake client garbage collection", func() {
+	var (
+		ctx   context.Context
+		cl    client.WithWatch
+		owner *appsv1.Deployment
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cl = NewClientBuilder().WithGarbageCollection().Build()
+		owner = &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "owner"}}
+		Expect(cl.Create(ctx, owner)).To(Succeed())
+		Expect(owner.UID).NotTo(BeEmpty())
+	})
+
+	createDependent := func(name string, owners ...client.Object) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+		for i, o := range owners {
+			if i == 0 {
+				Expect(controllerutil.SetControllerReference(o, cm, cl.Scheme())).To(Succeed())
+			} else {
+				Expect(controllerutil.SetOwnerReference(o, cm, cl.Scheme())).To(Succeed())
+			}
+		}
+		Expect(cl.Create(ctx, cm)).To(Succeed())
+		return cm
+	}
+
+	exists := func(obj client.Object) bool {
+		err := cl.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).NotTo(HaveOccurred())
+		return true
+	}
+
+	It("should delete dependents in the background by default", func() {
+		dependent := createDependent("dependent", owner)
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "grand-dependent"}}
+		Expect(controllerutil.SetControllerReference(dependent, secret, cl.Scheme())).To(Succeed())
+		Expect(cl.Create(ctx, secret)).To(Succeed())
+
+		Expect(cl.Delete(ctx, owner)).To(Succeed())
+		Expect(exists(owner)).To(BeFalse())
+		Expect(exists(dependent)).To(BeFalse())
+		Expect(exists(secret)).To(BeFalse())
+	})
+
+	It("should orphan dependents", func() {
+		dependent := createDependent("dependent", owner)
+
+		Expect(cl.Delete(ctx, owner, client.PropagationPolicy(metav1.DeletePropagationOrphan))).To(Succeed())
+		Expect(exists(owner)).To(BeFalse())
+		Expect(exists(dependent)).To(BeTrue())
+		Expect(dependent.OwnerReferences).To(BeEmpty())
+	})
+
+	It("should only remove the owner reference of dependents with other owners", func() {
+		other := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other"}}
+		Expect(cl.Create(ctx, other)).To(Succeed())
+		dependent := createDependent("dependent", owner, other)
+
+		Expect(cl.Delete(ctx, owner)).To(Succeed())
+		Expect(exists(dependent)).To(BeTrue())
+		Expect(dependent.OwnerReferences).To(HaveLen(1))
+		Expect(dependent.OwnerReferences[0].UID).To(Equal(other.UID))
+	})
+
+	It("should wait for the finalizers of the owner before deleting dependents", func() {
+		owner.Finalizers = []string{"example.com/finalizer"}
+		Expect(cl.Update(ctx, owner)).To(Succeed())
+		dependent := createDependent("dependent", owner)
+
+		Expect(cl.Delete(ctx, owner)).To(Succeed())
+		Expect(exists(owner)).To(BeTrue())
+		Expect(exists(dependent)).To(BeTrue())
+
+		owner.Finalizers = nil
+		Expect(cl.Update(ctx, owner)).To(Succeed())
+		Expect(exists(owner)).To(BeFalse())
+		Expect(exists(dependent)).To(BeFalse())
+	})
+
+	It("should delete the owner in the foreground once dependents that block it are gone", func() {
+		blocking := createDependent("blocking", owner)
+		Expect(*blocking.OwnerReferences[0].BlockOwnerDeletion).To(BeTrue())
+		blocking.Finalizers = []string{"example.com/finalizer"}
+		Expect(cl.Update(ctx, blocking)).To(Succeed())
+		nonBlocking := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "non-blocking"}}
+		Expect(controllerutil.SetOwnerReference(owner, nonBlocking, cl.Scheme())).To(Succeed())
+		Expect(cl.Create(ctx, nonBlocking)).To(Succeed())
+
+		Expect(cl.Delete(ctx, owner, client.PropagationPolicy(metav1.DeletePropagationForeground))).To(Succeed())
+		Expect(exists(nonBlocking)).To(BeFalse())
+		Expect(exists(blocking)).To(BeTrue())
+		Expect(blocking.DeletionTimestamp).NotTo(BeNil())
+		Expect(exists(owner)).To(BeTrue())
+		Expect(owner.DeletionTimestamp).NotTo(BeNil())
+		Expect(owner.Finalizers).To(ConsistOf(metav1.FinalizerDeleteDependents))
+
+		blocking.Finalizers = nil
+		Expect(cl.Update(ctx, blocking)).To(Succeed())
+		Expect(exists(blocking)).To(BeFalse())
+		Expect(exists(owner)).To(BeFalse())
+	})
+
+	It("should delete the owner in the foreground right away without dependents", func() {
+		Expect(cl.Delete(ctx, owner, client.PropagationPolicy(metav1.DeletePropagationForeground))).To(Succeed())
+		Expect(exists(owner)).To(BeFalse())
+	})
+
+	It("should collect the dependents of all objects deleted by DeleteAllOf", func() {
+		dependent := createDependent("dependent", owner)
+
+		Expect(cl.DeleteAllOf(ctx, &appsv1.Deployment{}, client.InNamespace("default"))).To(Succeed())
+		Expect(exists(owner)).To(BeFalse())
+		Expect(exists(dependent)).To(BeFalse())
+	})
+
+	It("should not collect garbage unless enabled", func() {
+		cl = NewClientBuilder().Build()
+		owner = &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "owner", UID: "owner-uid"}}
+		Expect(cl.Create(ctx, owner)).To(Succeed())
+		dependent := createDependent("dependent", owner)
+
+		Expect(cl.Delete(ctx, owner)).To(Succeed())
+		Expect(exists(dependent)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Fake client with CRDs", func() {
+	const crdManifest = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    listKind: WidgetList
+    plural: widgets
+    singular: widget
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    subresources:
+      status: {}
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            x-kubernetes-validations:
+            - rule: "self.minReplicas <= self.replicas"
+              message: "minReplicas must not exceed replicas"
+            properties:
+              replicas:
+                type: integer
+                default: 1
+                minimum: 0
+              minReplicas:
+                type: integer
+                default: 0
+              class:
+                type: string
+                x-kubernetes-validations:
+                - rule: "self == oldSelf"
+                  message: "class is immutable"
+            required:
+            - class
+          status:
+            type: object
+            properties:
+              ready:
+                type: boolean
+`
+
+	var (
+		ctx context.Context
+		cl  client.WithWatch
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		Expect(yaml.Unmarshal([]byte(crdManifest), crd)).To(Succeed())
+		cl = NewClientBuilder().WithCRDs(crd).Build()
+	})
+
+	newWidget := func(spec map[string]interface{}) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+		u.SetGroupVersionKind(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"})
+		u.SetNamespace("default")
+		u.SetName("widget")
+		return u
+	}
+
+	It("should apply defaults on create", func() {
+		widget := newWidget(map[string]interface{}{"class": "small"})
+		Expect(cl.Create(ctx, widget)).To(Succeed())
+		Expect(widget.Object["spec"]).To(HaveKeyWithValue("replicas", int64(1)))
+
+		fetched := newWidget(nil)
+		Expect(cl.Get(ctx, client.ObjectKeyFromObject(widget), fetched)).To(Succeed())
+		Expect(fetched.Object["spec"]).To(HaveKeyWithValue("replicas", int64(1)))
+		Expect(fetched.Object["spec"]).To(HaveKeyWithValue("minReplicas", int64(0)))
+	})
+
+	It("should prune unknown fields", func() {
+		widget := newWidget(map[string]interface{}{"class": "small", "unknown": "field"})
+		Expect(cl.Create(ctx, widget)).To(Succeed())
+		Expect(widget.Object["spec"]).NotTo(HaveKey("unknown"))
+	})
+
+	It("should reject objects that don't match the schema", func() {
+		err := cl.Create(ctx, newWidget(map[string]interface{}{"class": "small", "replicas": int64(-1)}))
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.replicas"))
+
+		err = cl.Create(ctx, newWidget(map[string]interface{}{}))
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.class"))
+	})
+
+	It("should reject objects without a namespace if the CRD is namespaced", func() {
+		widget := newWidget(map[string]interface{}{"class": "small"})
+		widget.SetNamespace("")
+		Expect(apierrors.IsInvalid(cl.Create(ctx, widget))).To(BeTrue())
+	})
+
+	It("should evaluate CEL rules on create", func() {
+		err := cl.Create(ctx, newWidget(map[string]interface{}{"class": "small", "replicas": int64(1), "minReplicas": int64(2)}))
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("minReplicas must not exceed replicas"))
+	})
+
+	It("should evaluate CEL transition rules on update and patch", func() {
+		widget := newWidget(map[string]interface{}{"class": "small"})
+		Expect(cl.Create(ctx, widget)).To(Succeed())
+
+		Expect(unstructured.SetNestedField(widget.Object, int64(3), "spec", "replicas")).To(Succeed())
+		Expect(cl.Update(ctx, widget)).To(Succeed())
+
+		Expect(unstructured.SetNestedField(widget.Object, "large", "spec", "class")).To(Succeed())
+		err := cl.Update(ctx, widget)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("class is immutable"))
+
+		err = cl.Patch(ctx, newWidget(nil), client.RawPatch(types.MergePatchType, []byte(`{"spec":{"class":"large"}}`)))
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+	})
+
+	It("should treat versions with a status subresource like WithStatusSubresource", func() {
+		widget := newWidget(map[string]interface{}{"class": "small"})
+		Expect(cl.Create(ctx, widget)).To(Succeed())
+
+		Expect(unstructured.SetNestedField(widget.Object, true, "status", "ready")).To(Succeed())
+		Expect(cl.Update(ctx, widget)).To(Succeed())
+		Expect(cl.Get(ctx, client.ObjectKeyFromObject(widget), widget)).To(Succeed())
+		Expect(widget.Object).NotTo(HaveKey("status"))
+
+		Expect(unstructured.SetNestedField(widget.Object, true, "status", "ready")).To(Succeed())
+		Expect(cl.Status().Update(ctx, widget)).To(Succeed())
+		Expect(cl.Get(ctx, client.ObjectKeyFromObject(widget), widget)).To(Succeed())
+		Expect(widget.Object).To(HaveKeyWithValue("status", map[string]interface{}{"ready": true}))
+	})
+
+	It("should register the CRD kinds with the default RESTMapper", func() {
+		gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+		namespaced, err := cl.IsObjectNamespaced(newWidget(nil))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaced).To(BeTrue())
+
+		mapping, err := cl.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mapping.Resource.Resource).To(Equal("widgets"))
+	})
+
+	It("should generate names when creating through the tracker", func() {
+		tracker := NewClientBuilder().Build().(*fakeClient).tracker
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", GenerateName: "cm-"}}
+		Expect(tracker.Create(corev1.SchemeGroupVersion.WithResource("configmaps"), cm, "default")).To(Succeed())
+		Expect(cm.Name).To(HavePrefix("cm-"))
+		Expect(cm.Name).To(HaveLen(len("cm-") + randomLength))
+	})
+})
+
+var _ = Describe("Fake client watch", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	newConfigMap := func(name string, labels map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels}}
+	}
+
+	nextEvent := func(w watch.Interface) watch.Event {
+		var ev watch.Event
+		Eventually(w.ResultChan()).Should(Receive(&ev))
+		return ev
+	}
+
+	It("should filter events by label selector", func() {
+		cl := NewClientBuilder().Build()
+		w, err := cl.Watch(ctx, &corev1.ConfigMapList{}, client.MatchingLabels{"app": "foo"})
+		Expect(err).NotTo(HaveOccurred())
+		defer w.Stop()
+
+		Expect(cl.Create(ctx, newConfigMap("bar", map[string]string{"app": "bar"}))).To(Succeed())
+		Expect(cl.Create(ctx, newConfigMap("foo", map[string]string{"app": "foo"}))).To(Succeed())
+
+		ev := nextEvent(w)
+		Expect(ev.Type).To(Equal(watch.Added))
+		Expect(ev.Object.(*corev1.ConfigMap).Name).To(Equal("foo"))
+		Consistently(w.ResultChan()).ShouldNot(Receive())
+	})
+
+	It("should filter events by indexed field selector", func() {
+		cl := NewClientBuilder().WithIndex(&corev1.ConfigMap{}, "key", func(o client.Object) []string {
+			return []string{o.(*corev1.ConfigMap).Data["key"]}
+		}).Build()
+
+		_, err := cl.Watch(ctx, &corev1.ConfigMapList{}, client.MatchingFields{"unindexed": "value"})
+		Expect(err).To(HaveOccurred())
+
+		w, err := cl.Watch(ctx, &corev1.ConfigMapList{}, client.MatchingFields{"key": "value"})
+		Expect(err).NotTo(HaveOccurred())
+		defer w.Stop()
+
+		other := newConfigMap("other", nil)
+		other.Data = map[string]string{"key": "other"}
+		Expect(cl.Create(ctx, other)).To(Succeed())
+		matching := newConfigMap("matching", nil)
+		matching.Data = map[string]string{"key": "value"}
+		Expect(cl.Create(ctx, matching)).To(Succeed())
+
+		ev := nextEvent(w)
+		Expect(ev.Object.(*corev1.ConfigMap).Name).To(Equal("matching"))
+	})
+
+	Context("with a watch history", func() {
+		It("should use a single resourceVersion counter for all objects", func() {
+			cl := NewClientBuilder().WithWatchHistory(10).WithObjects(newConfigMap("initial", nil)).Build()
+
+			first := newConfigMap("first", nil)
+			Expect(cl.Create(ctx, first)).To(Succeed())
+			Expect(first.ResourceVersion).To(Equal("2"))
+			second := newConfigMap("second", nil)
+			Expect(cl.Create(ctx, second)).To(Succeed())
+			Expect(second.ResourceVersion).To(Equal("3"))
+			Expect(cl.Update(ctx, first)).To(Succeed())
+			Expect(first.ResourceVersion).To(Equal("4"))
+
+			list := &corev1.ConfigMapList{}
+			Expect(cl.List(ctx, list)).To(Succeed())
+			Expect(list.ResourceVersion).To(Equal("4"))
+		})
+
+		It("should replay the events after the given resourceVersion", func() {
+			cl := NewClientBuilder().WithWatchHistory(10).WithObjects(newConfigMap("initial", nil)).Build()
+
+			list := &corev1.ConfigMapList{}
+			Expect(cl.List(ctx, list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+
+			created := newConfigMap("created", nil)
+			Expect(cl.Create(ctx, created)).To(Succeed())
+			Expect(cl.Delete(ctx, &list.Items[0])).To(Succeed())
+
+			w, err := cl.Watch(ctx, &corev1.ConfigMapList{}, &client.ListOptions{Raw: &metav1.ListOptions{ResourceVersion: list.ResourceVersion}})
+			Expect(err).NotTo(HaveOccurred())
+			defer w.Stop()
+
+			ev := nextEvent(w)
+			Expect(ev.Type).To(Equal(watch.Added))
+			Expect(ev.Object.(*corev1.ConfigMap).Name).To(Equal("created"))
+			ev = nextEvent(w)
+			Expect(ev.Type).To(Equal(watch.Deleted))
+			Expect(ev.Object.(*corev1.ConfigMap).Name).To(Equal("initial"))
+			Expect(ev.Object.(*corev1.ConfigMap).ResourceVersion).To(Equal("3"))
+
+			Expect(cl.Create(ctx, newConfigMap("live", nil))).To(Succeed())
+			ev = nextEvent(w)
+			Expect(ev.Type).To(Equal(watch.Added))
+			Expect(ev.Object.(*corev1.ConfigMap).Name).To(Equal("live"))
+		})
+
+		It("should return 410 Gone for resourceVersions that are no longer in the history", func() {
+			cl := NewClientBuilder().WithWatchHistory(2).Build()
+			for _, name := range []string{"a", "b", "c"} {
+				Expect(cl.Create(ctx, newConfigMap(name, nil))).To(Succeed())
+			}
+
+			_, err := cl.Watch(ctx, &corev1.ConfigMapList{}, &client.ListOptions{Raw: &metav1.ListOptions{ResourceVersion: "0"}})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = cl.Watch(ctx, &corev1.ConfigMapList{}, &client.ListOptions{Raw: &metav1.ListOptions{ResourceVersion: "1"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			cl = NewClientBuilder().WithWatchHistory(1).Build()
+			for _, name := range []string{"a", "b", "c"} {
+				Expect(cl.Create(ctx, newConfigMap(name, nil))).To(Succeed())
+			}
+			_, err = cl.Watch(ctx, &corev1.ConfigMapList{}, &client.ListOptions{Raw: &metav1.ListOptions{ResourceVersion: "1"}})
+			Expect(apierrors.IsResourceExpired(err)).To(BeTrue())
+		})
+
+		It("should send objects that stop or start matching the selector as deleted or added", func() {
+			cl := NewClientBuilder().WithWatchHistory(10).Build()
+			cm := newConfigMap("cm", map[string]string{"app": "foo"})
+			Expect(cl.Create(ctx, cm)).To(Succeed())
+
+			w, err := cl.Watch(ctx, &corev1.ConfigMapList{}, client.MatchingLabels{"app": "foo"})
+			Expect(err).NotTo(HaveOccurred())
+			defer w.Stop()
+
+			cm.Labels["app"] = "bar"
+			Expect(cl.Update(ctx, cm)).To(Succeed())
+			ev := nextEvent(w)
+			Expect(ev.Type).To(Equal(watch.Deleted))
+
+			cm.Labels["app"] = "foo"
+			Expect(cl.Update(ctx, cm)).To(Succeed())
+			ev = nextEvent(w)
+			Expect(ev.Type).To(Equal(watch.Added))
+
+			cm.Data = map[string]string{"key": "value"}
+			Expect(cl.Update(ctx, cm)).To(Succeed())
+			ev = nextEvent(w)
+			Expect(ev.Type).To(Equal(watch.Modified))
+		})
+
+		It("should not list a resourceVersion whose object isn't stored yet", func() {
+			tracker := &blockingTracker{
+				ObjectTracker: testing.NewObjectTracker(clientgoscheme.Scheme, clientgoscheme.Codecs.UniversalDecoder()),
+				entered:       make(chan struct{}),
+				release:       make(chan struct{}),
+			}
+			cl := NewClientBuilder().WithWatchHistory(10).WithObjectTracker(tracker).Build()
+
+			created := newConfigMap("created", nil)
+			createDone := make(chan error, 1)
+			go func() { createDone <- cl.Create(ctx, created) }()
+			Eventually(tracker.entered).Should(BeClosed())
+
+			list := &corev1.ConfigMapList{}
+			listDone := make(chan error, 1)
+			go func() { listDone <- cl.List(ctx, list) }()
+			Consistently(listDone, 50*time.Millisecond).ShouldNot(Receive())
+
+			close(tracker.release)
+			Eventually(createDone).Should(Receive(BeNil()))
+			Eventually(listDone).Should(Receive(BeNil()))
+			Expect(list.ResourceVersion).To(Equal(created.ResourceVersion))
+			Expect(list.Items).To(ConsistOf(HaveField("Name", "created")))
+		})
+
+		It("should send bookmarks to watches that allow them", func() {
+			cl := NewClientBuilder().WithWatchBookmarks(10 * time.Millisecond).Build()
+			Expect(cl.Create(ctx, newConfigMap("cm", nil))).To(Succeed())
+
+			w, err := cl.Watch(ctx, &corev1.ConfigMapList{}, &client.ListOptions{Raw: &metav1.ListOptions{AllowWatchBookmarks: true}})
+			Expect(err).NotTo(HaveOccurred())
+			defer w.Stop()
+
+			ev := nextEvent(w)
+			Expect(ev.Type).To(Equal(watch.Bookmark))
+			Expect(ev.Object.(*corev1.ConfigMap).ResourceVersion).To(Equal("1"))
+
+			withoutBookmarks, err := cl.Watch(ctx, &corev1.ConfigMapList{})
+			Expect(err).NotTo(HaveOccurred())
+			defer withoutBookmarks.Stop()
+			Consistently(withoutBookmarks.ResultChan(), 50*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+})
+
+var _ = Describe("Fake client field selectors", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	newPod := func(name, nodeName string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	It("should filter lists by built-in fields without an index", func() {
+		cl := NewClientBuilder().WithObjects(
+			newPod("a", "node-1", corev1.PodRunning),
+			newPod("b", "node-2", corev1.PodRunning),
+			newPod("c", "node-1", corev1.PodPending),
+		).Build()
+
+		pods := &corev1.PodList{}
+		Expect(cl.List(ctx, pods, client.MatchingFields{"spec.nodeName": "node-1", "status.phase": "Running"})).To(Succeed())
+		Expect(pods.Items).To(HaveLen(1))
+		Expect(pods.Items[0].Name).To(Equal("a"))
+
+		Expect(cl.List(ctx, pods, client.MatchingFields{"metadata.name": "b"})).To(Succeed())
+		Expect(pods.Items).To(HaveLen(1))
+		Expect(pods.Items[0].Name).To(Equal("b"))
+
+		Expect(cl.List(ctx, pods, client.MatchingFields{"spec.hostNetwork": "false"})).To(Succeed())
+		Expect(pods.Items).To(HaveLen(3))
+
+		Expect(cl.List(ctx, pods, client.MatchingFieldsSelector{Selector: fields.OneTermNotEqualSelector("spec.nodeName", "node-1")})).To(Succeed())
+		Expect(pods.Items).To(HaveLen(1))
+		Expect(pods.Items[0].Name).To(Equal("b"))
+	})
+
+	It("should filter metadata-only lists by fields outside of metadata", func() {
+		cl := NewClientBuilder().WithObjects(newPod("a", "node-1", ""), newPod("b", "node-2", "")).Build()
+
+		pods := &metav1.PartialObjectMetadataList{}
+		pods.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("PodList"))
+		Expect(cl.List(ctx, pods, client.MatchingFields{"spec.nodeName": "node-2"})).To(Succeed())
+		Expect(pods.Items).To(HaveLen(1))
+		Expect(pods.Items[0].Name).To(Equal("b"))
+	})
+
+	It("should filter events by involved object", func() {
+		cl := NewClientBuilder().WithObjects(
+			&corev1.Event{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a"}, InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "a"}},
+			&corev1.Event{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b"}, InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "b"}},
+		).Build()
+
+		events := &corev1.EventList{}
+		Expect(cl.List(ctx, events, client.MatchingFields{"involvedObject.kind": "Pod", "involvedObject.name": "b"})).To(Succeed())
+		Expect(events.Items).To(HaveLen(1))
+		Expect(events.Items[0].Name).To(Equal("b"))
+	})
+
+	It("should prefer registered indexes over built-in fields", func() {
+		cl := NewClientBuilder().
+			WithObjects(newPod("a", "node-1", ""), newPod("b", "node-2", "")).
+			WithIndex(&corev1.Pod{}, "spec.nodeName", func(o client.Object) []string {
+				return []string{"indexed-" + o.(*corev1.Pod).Spec.NodeName}
+			}).
+			Build()
+
+		pods := &corev1.PodList{}
+		Expect(cl.List(ctx, pods, client.MatchingFields{"spec.nodeName": "indexed-node-2"})).To(Succeed())
+		Expect(pods.Items).To(HaveLen(1))
+		Expect(pods.Items[0].Name).To(Equal("b"))
+	})
+
+	It("should still require an index for fields the API server doesn't support", func() {
+		cl := NewClientBuilder().Build()
+		err := cl.List(ctx, &corev1.PodList{}, client.MatchingFields{"spec.priority": "1"})
+		Expect(err).To(MatchError(ContainSubstring("no index with name spec.priority has been registered")))
+	})
+
+	It("should delete only the objects matching the field selector in DeleteAllOf", func() {
+		cl := NewClientBuilder().WithObjects(
+			newPod("a", "node-1", corev1.PodSucceeded),
+			newPod("b", "node-1", corev1.PodRunning),
+		).Build()
+
+		Expect(cl.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace("default"), client.MatchingFields{"status.phase": "Succeeded"})).To(Succeed())
+
+		pods := &corev1.PodList{}
+		Expect(cl.List(ctx, pods)).To(Succeed())
+		Expect(pods.Items).To(HaveLen(1))
+		Expect(pods.Items[0].Name).To(Equal("b"))
+
+		err := cl.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace("default"), client.MatchingFields{"unknown": "value"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should filter by the selectableFields of CRDs", func() {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		Expect(yaml.Unmarshal([]byte(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    listKind: WidgetList
+    plural: widgets
+    singular: widget
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    selectableFields:
+    - jsonPath: .spec.color
+    - jsonPath: .spec.size
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              color:
+                type: string
+              size:
+                type: integer
+`), crd)).To(Succeed())
+		cl := NewClientBuilder().WithCRDs(crd).Build()
+
+		for name, color := range map[string]string{"red": "red", "blue": "blue"} {
+			u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{"color": color, "size": int64(len(name))}}}
+			u.SetGroupVersionKind(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"})
+			u.SetNamespace("default")
+			u.SetName(name)
+			Expect(cl.Create(ctx, u)).To(Succeed())
+		}
+
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "WidgetList"})
+		Expect(cl.List(ctx, list, client.MatchingFields{"spec.color": "blue"})).To(Succeed())
+		Expect(list.Items).To(HaveLen(1))
+		Expect(list.Items[0].GetName()).To(Equal("blue"))
+
+		Expect(cl.List(ctx, list, client.MatchingFields{"spec.size": "3"})).To(Succeed())
+		Expect(list.Items).To(HaveLen(1))
+		Expect(list.Items[0].GetName()).To(Equal("red"))
+	})
+})
+
+type configMapDefaulter struct{}
+
+func (configMapDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	cm := obj.(*corev1.ConfigMap)
+	if cm.Labels == nil {
+		cm.Labels = map[string]string{}
+	}
+	cm.Labels["defaulted"] = "true"
+	return nil
+}
+
+type configMapValidator struct{}
+
+func (configMapValidator) validate(obj runtime.Object) (admission.Warnings, error) {
+	cm := obj.(*corev1.ConfigMap)
+	if cm.Labels["defaulted"] != "true" {
+		return nil, errors.New("not defaulted")
+	}
+	if _, ok := cm.Data["invalid"]; ok {
+		return nil, apierrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, cm.Name, field.ErrorList{
+			field.Forbidden(field.NewPath("data", "invalid"), "must not be set"),
+		})
+	}
+	return nil, nil
+}
+
+func (v configMapValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return v.validate(obj)
+}
+
+func (v configMapValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return v.validate(newObj)
+}
+
+func (configMapValidator) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	if obj.(*corev1.ConfigMap).Labels["protected"] == "true" {
+		return nil, errors.New("protected")
+	}
+	return nil, nil
+}
+
+var _ = Describe("Fake client webhooks", func() {
+	var (
+		ctx context.Context
+		cl  client.WithWatch
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		s := runtime.NewScheme()
+		Expect(corev1.AddToScheme(s)).To(Succeed())
+		cl = NewClientBuilder().
+			WithScheme(s).
+			WithMutatingWebhook(&corev1.ConfigMap{}, admission.WithCustomDefaulter(s, &corev1.ConfigMap{}, configMapDefaulter{})).
+			WithValidatingWebhook(&corev1.ConfigMap{}, admission.WithCustomValidator(s, &corev1.ConfigMap{}, configMapValidator{})).
+			Build()
+	})
+
+	It("should apply the patches of mutating webhooks before calling validating webhooks", func() {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cm"}}
+		Expect(cl.Create(ctx, cm)).To(Succeed())
+		Expect(cm.Labels).To(HaveKeyWithValue("defaulted", "true"))
+
+		stored := &corev1.ConfigMap{}
+		Expect(cl.Get(ctx, client.ObjectKeyFromObject(cm), stored)).To(Succeed())
+		Expect(stored.Labels).To(HaveKeyWithValue("defaulted", "true"))
+
+		stored.Labels = nil
+		Expect(cl.Update(ctx, stored)).To(Succeed())
+		Expect(stored.Labels).To(HaveKeyWithValue("defaulted", "true"))
+	})
+
+	It("should return denials as API errors", func() {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cm"},
+			Data:       map[string]string{"invalid": ""},
+		}
+		err := cl.Create(ctx, cm)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring(`admission webhook "validate--v1-configmap" denied the request`)))
+		Expect(apierrors.IsNotFound(cl.Get(ctx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{}))).To(BeTrue())
+
+		cm.Data = nil
+		Expect(cl.Create(ctx, cm)).To(Succeed())
+		cm.Data = map[string]string{"invalid": ""}
+		Expect(apierrors.IsInvalid(cl.Update(ctx, cm))).To(BeTrue())
+	})
+
+	It("should call validating webhooks for deletes", func() {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cm", Labels: map[string]string{"protected": "true"}}}
+		Expect(cl.Create(ctx, cm)).To(Succeed())
+
+		err := cl.Delete(ctx, cm)
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("protected")))
+		err = cl.DeleteAllOf(ctx, &corev1.ConfigMap{}, client.InNamespace("default"))
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+
+		cm.Labels["protected"] = "false"
+		Expect(cl.Update(ctx, cm)).To(Succeed())
+		Expect(cl.Delete(ctx, cm)).To(Succeed())
+	})
+
+	It("should call webhooks for dry-run requests without persisting them", func() {
+		var dryRuns []bool
+		cl := NewClientBuilder().
+			WithValidatingWebhook(&corev1.ConfigMap{}, admission.HandlerFunc(func(_ context.Context, req admission.Request) admission.Response {
+				dryRuns = append(dryRuns, ptr.Deref(req.DryRun, false))
+				if req.Operation != admissionv1.Delete && strings.Contains(string(req.Object.Raw), "invalid") {
+					return admission.Denied("invalid")
+				}
+				return admission.Allowed("")
+			})).
+			Build()
+
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cm"}, Data: map[string]string{"invalid": ""}}
+		Expect(apierrors.IsForbidden(cl.Create(ctx, cm, client.DryRunAll))).To(BeTrue())
+		cm.Data = nil
+		Expect(cl.Create(ctx, cm, client.DryRunAll)).To(Succeed())
+		Expect(apierrors.IsNotFound(cl.Get(ctx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{}))).To(BeTrue())
+		Expect(dryRuns).To(Equal([]bool{true, true}))
+
+		Expect(cl.Create(ctx, cm)).To(Succeed())
+		invalid := cm.DeepCopy()
+		invalid.Data = map[string]string{"invalid": ""}
+		Expect(apierrors.IsForbidden(cl.Update(ctx, invalid, client.DryRunAll))).To(BeTrue())
+		patch := client.MergeFrom(cm.DeepCopy())
+		Expect(apierrors.IsForbidden(cl.Patch(ctx, invalid, patch, client.DryRunAll))).To(BeTrue())
+		Expect(cl.Delete(ctx, cm, client.DryRunAll)).To(Succeed())
+		Expect(dryRuns).To(Equal([]bool{true, true, false, true, true, true}))
+
+		stored := &corev1.ConfigMap{}
+		Expect(cl.Get(ctx, client.ObjectKeyFromObject(cm), stored)).To(Succeed())
+		Expect(stored.Data).To(BeEmpty())
+	})
+
+	It("should not call webhooks for status updates", func() {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"}}
+		calls := 0
+		cl := NewClientBuilder().
+			WithValidatingWebhook(&corev1.Pod{}, admission.HandlerFunc(func(_ context.Context, req admission.Request) admission.Response {
+				calls++
+				return admission.Allowed("")
+			})).
+			Build()
+
+		Expect(cl.Create(ctx, pod)).To(Succeed())
+		Expect(calls).To(Equal(1))
+		pod.Status.Phase = corev1.PodRunning
+		Expect(cl.Status().Update(ctx, pod)).To(Succeed())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should reject requests with a default reason if the webhook doesn't give one", func() {
+		cl := NewClientBuilder().
+			WithValidatingWebhook(&corev1.Pod{}, admission.HandlerFunc(func(context.Context, admission.Request) admission.Response {
+				return admission.Response{}
+			})).
+			Build()
+
+		err := cl.Create(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"}})
+		Expect(apierrors.IsBadRequest(err)).To(BeTrue())
+		Expect(err).To(MatchError(`admission webhook "validate--v1-pod" denied the request without explanation`))
+	})
+})
+
+// blockingTracker blocks the first Create until release is closed.
+type blockingTracker struct {
+	testing.ObjectTracker
+	once    sync.Once
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (t *blockingTracker) Create(gvr schema.GroupVersionResource, obj runtime.Object, ns string, opts ...metav1.CreateOptions) error {
+	t.once.Do(func() {
+		close(t.entered)
+		<-t.release
+	})
+	return t.ObjectTracker.Create(gvr, obj, ns, opts...)
+}
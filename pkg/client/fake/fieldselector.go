@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// selectableField is a field that can be used in field selectors without
+// registering an index for it.
+type selectableField struct {
+	// path is the path of the field in the object.
+	path []string
+	// defaultValue is the value used if the field is unset. It is the string
+	// representation of the zero value of non-pointer fields of built-in types.
+	defaultValue string
+}
+
+// metadataSelectableFields are supported by the API server for all resources.
+var metadataSelectableFields = map[string]selectableField{
+	"metadata.name":      {path: []string{"metadata", "name"}},
+	"metadata.namespace": {path: []string{"metadata", "namespace"}},
+}
+
+// builtinSelectableFields are the additional fields the API server supports in
+// field selectors for built-in resources.
+var builtinSelectableFields = map[schema.GroupKind]map[string]selectableField{
+	{Kind: "Pod"}: {
+		"spec.nodeName":            {path: []string{"spec", "nodeName"}},
+		"spec.restartPolicy":       {path: []string{"spec", "restartPolicy"}},
+		"spec.schedulerName":       {path: []string{"spec", "schedulerName"}},
+		"spec.serviceAccountName":  {path: []string{"spec", "serviceAccountName"}},
+		"spec.hostNetwork":         {path: []string{"spec", "hostNetwork"}, defaultValue: "false"},
+		"status.phase":             {path: []string{"status", "phase"}},
+		"status.podIP":             {path: []string{"status", "podIP"}},
+		"status.nominatedNodeName": {path: []string{"status", "nominatedNodeName"}},
+	},
+	{Kind: "Node"}: {
+		"spec.unschedulable": {path: []string{"spec", "unschedulable"}, defaultValue: "false"},
+	},
+	{Kind: "Namespace"}: {
+		"status.phase": {path: []string{"status", "phase"}},
+	},
+	{Kind: "Secret"}: {
+		"type": {path: []string{"type"}},
+	},
+	{Kind: "ReplicationController"}: {
+		"status.replicas": {path: []string{"status", "replicas"}, defaultValue: "0"},
+	},
+	{Kind: "Event"}: {
+		"involvedObject.kind":            {path: []string{"involvedObject", "kind"}},
+		"involvedObject.namespace":       {path: []string{"involvedObject", "namespace"}},
+		"involvedObject.name":            {path: []string{"involvedObject", "name"}},
+		"involvedObject.uid":             {path: []string{"involvedObject", "uid"}},
+		"involvedObject.apiVersion":      {path: []string{"involvedObject", "apiVersion"}},
+		"involvedObject.resourceVersion": {path: []string{"involvedObject", "resourceVersion"}},
+		"involvedObject.fieldPath":       {path: []string{"involvedObject", "fieldPath"}},
+		"reason":                         {path: []string{"reason"}},
+		"reportingComponent":             {path: []string{"reportingComponent"}},
+		"source":                         {path: []string{"source", "component"}},
+		"type":                           {path: []string{"type"}},
+	},
+	{Group: "apps", Kind: "ReplicaSet"}: {
+		"status.replicas": {path: []string{"status", "replicas"}, defaultValue: "0"},
+	},
+	{Group: "batch", Kind: "Job"}: {
+		"status.successful": {path: []string{"status", "succeeded"}, defaultValue: "0"},
+	},
+	{Group: "certificates.k8s.io", Kind: "CertificateSigningRequest"}: {
+		"spec.signerName": {path: []string{"spec", "signerName"}},
+	},
+}
+
+// crdSelectableFields returns the selectableFields of all versions of the given CRDs.
+func crdSelectableFields(crds []*apiextensionsv1.CustomResourceDefinition) map[schema.GroupVersionKind]map[string]selectableField {
+	res := map[schema.GroupVersionKind]map[string]selectableField{}
+	for _, crd := range crds {
+		for _, version := range crd.Spec.Versions {
+			if len(version.SelectableFields) == 0 {
+				continue
+			}
+			gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+			res[gvk] = map[string]selectableField{}
+			for _, f := range version.SelectableFields {
+				name := strings.TrimPrefix(f.JSONPath, ".")
+				res[gvk][name] = selectableField{path: strings.Split(name, ".")}
+			}
+		}
+	}
+	return res
+}
+
+// selectableField returns the selectable field with the given name of gvk, if any.
+func (c *fakeClient) selectableField(gvk schema.GroupVersionKind, name string) (selectableField, bool) {
+	if f, ok := metadataSelectableFields[name]; ok {
+		return f, true
+	}
+	if f, ok := builtinSelectableFields[gvk.GroupKind()][name]; ok {
+		return f, true
+	}
+	f, ok := c.selectableFields[gvk][name]
+	return f, ok
+}
+
+// selectableFieldValue returns the value of the field of obj, formatted like
+// the API server does for field selectors.
+func (c *fakeClient) selectableFieldValue(obj runtime.Object, gvk schema.GroupVersionKind, f selectableField) (string, error) {
+	// Metadata-only objects don't contain the fields outside of metadata, so
+	// they are taken from the stored object.
+	if partial, isPartial := obj.(*metav1.PartialObjectMetadata); isPartial && f.path[0] != "metadata" {
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		stored, err := c.tracker.Get(gvr, partial.Namespace, partial.Name)
+		if err != nil {
+			return "", err
+		}
+		obj = stored
+	}
+
+	var u map[string]interface{}
+	if unstructuredObj, isUnstructured := obj.(runtime.Unstructured); isUnstructured {
+		u = unstructuredObj.UnstructuredContent()
+	} else {
+		var err error
+		if u, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return "", err
+		}
+	}
+
+	val, found, err := unstructured.NestedFieldNoCopy(u, f.path...)
+	if err != nil {
+		return "", err
+	}
+	if !found || val == nil {
+		return f.defaultValue, nil
+	}
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("field %s has unsupported type %T", strings.Join(f.path, "."), val)
+	}
+}
@@ -0,0 +1,409 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"slices"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// garbageCollector simulates the garbage collector of kube-controller-manager.
+// It keeps track of all kinds stored in the tracker, so that it can find the
+// dependents of an object.
+type garbageCollector struct {
+	mu    sync.Mutex
+	kinds map[schema.GroupVersionResource]schema.GroupVersionKind
+}
+
+// WithGarbageCollection makes the fake client simulate the garbage collector,
+// which deletes objects whose owners, as set by e.g.
+// controllerutil.SetControllerReference, were deleted. Unlike the real
+// garbage collector, it runs synchronously within the calls to Delete,
+// DeleteAllOf, Update and Patch, so the dependents are deleted once they
+// return. It supports the Background (default), Foreground and Orphan
+// propagation policies, the foregroundDeletion finalizer and
+// blockOwnerDeletion.
+//
+// Like the API server, the fake client sets the UIDs of created objects, which
+// owner references refer to. Only objects created through the fake client or
+// the builder are considered.
+func (f *ClientBuilder) WithGarbageCollection() *ClientBuilder {
+	f.garbageCollection = true
+	return f
+}
+
+func (gc *garbageCollector) record(obj runtime.Object, scheme *runtime.Scheme) error {
+	if gc == nil {
+		return nil
+	}
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return err
+	}
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	gc.kinds[gvr] = gvk
+	return nil
+}
+
+// setUID sets the UID of the object if it's unset, like the API server does
+// on create. It's a no-op if garbage collection is disabled.
+func (gc *garbageCollector) setUID(accessor metav1.Object) {
+	if gc != nil && accessor.GetUID() == "" {
+		accessor.SetUID(uuid.NewUUID())
+	}
+}
+
+// storedObject is an object in the tracker together with its resource.
+type storedObject struct {
+	gvr schema.GroupVersionResource
+	obj client.Object
+}
+
+func (o storedObject) ownedBy(ref metav1.OwnerReference, namespace string) bool {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return false
+	}
+	gvk := o.obj.GetObjectKind().GroupVersionKind()
+	if gv.Group != gvk.Group || ref.Kind != gvk.Kind || ref.Name != o.obj.GetName() {
+		return false
+	}
+	// Cluster-scoped owners can own objects in any namespace.
+	if o.obj.GetNamespace() != "" && o.obj.GetNamespace() != namespace {
+		return false
+	}
+	return ref.UID == "" || o.obj.GetUID() == "" || ref.UID == o.obj.GetUID()
+}
+
+func (o storedObject) waitingForDependents() bool {
+	return o.obj.GetDeletionTimestamp() != nil && slices.Contains(o.obj.GetFinalizers(), metav1.FinalizerDeleteDependents)
+}
+
+// allObjects returns all objects of the kinds recorded by the garbage collector.
+func (c *fakeClient) allObjects() ([]storedObject, error) {
+	c.gc.mu.Lock()
+	kinds := make(map[schema.GroupVersionResource]schema.GroupVersionKind, len(c.gc.kinds))
+	for gvr, gvk := range c.gc.kinds {
+		kinds[gvr] = gvk
+	}
+	c.gc.mu.Unlock()
+
+	var res []storedObject
+	for gvr, gvk := range kinds {
+		if !c.scheme.Recognizes(gvk.GroupVersion().WithKind(gvk.Kind + "List")) {
+			c.schemeWriteLock.Lock()
+			c.scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+			c.schemeWriteLock.Unlock()
+		}
+		list, err := c.tracker.List(gvr, gvk, metav1.NamespaceAll)
+		if err != nil {
+			return nil, err
+		}
+		objs, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range objs {
+			obj, ok := o.(client.Object)
+			if !ok {
+				continue
+			}
+			obj.GetObjectKind().SetGroupVersionKind(gvk)
+			res = append(res, storedObject{gvr: gvr, obj: obj})
+		}
+	}
+	return res, nil
+}
+
+// dependents returns the objects that have an owner reference to owner.
+func (c *fakeClient) dependents(owner storedObject) ([]storedObject, error) {
+	objs, err := c.allObjects()
+	if err != nil {
+		return nil, err
+	}
+	var res []storedObject
+	for _, o := range objs {
+		for _, ref := range o.obj.GetOwnerReferences() {
+			if owner.ownedBy(ref, o.obj.GetNamespace()) {
+				res = append(res, o)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// owner returns the owner the reference of dependent refers to, if it exists.
+func (c *fakeClient) owner(dependent storedObject, ref metav1.OwnerReference) (storedObject, bool, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return storedObject{}, false, err
+	}
+	gvk := gv.WithKind(ref.Kind)
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+	// The owner is either in the namespace of the dependent or cluster-scoped.
+	for _, ns := range []string{dependent.obj.GetNamespace(), metav1.NamespaceNone} {
+		o, err := c.tracker.Get(gvr, ns, ref.Name)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return storedObject{}, false, err
+		}
+		obj, ok := o.(client.Object)
+		if !ok {
+			continue
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		owner := storedObject{gvr: gvr, obj: obj}
+		if owner.ownedBy(ref, dependent.obj.GetNamespace()) {
+			return owner, true, nil
+		}
+	}
+	return storedObject{}, false, nil
+}
+
+// exists returns whether the object is still stored in the tracker.
+func (c *fakeClient) exists(o storedObject) (bool, error) {
+	_, err := c.tracker.Get(o.gvr, o.obj.GetNamespace(), o.obj.GetName())
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// deleteWithGarbageCollection deletes the object like the API server with the
+// propagation policy of the options, which defaults to Background.
+func (c *fakeClient) deleteWithGarbageCollection(gvr schema.GroupVersionResource, accessor metav1.Object, opts *metav1.DeleteOptions) error {
+	stored, err := c.tracker.Get(gvr, accessor.GetNamespace(), accessor.GetName())
+	if err != nil {
+		return err
+	}
+	obj, ok := stored.(client.Object)
+	if !ok {
+		return c.deleteObject(gvr, accessor)
+	}
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+
+	policy := metav1.DeletePropagationBackground
+	switch {
+	case opts.PropagationPolicy != nil:
+		policy = *opts.PropagationPolicy
+	case opts.OrphanDependents != nil && *opts.OrphanDependents:
+		policy = metav1.DeletePropagationOrphan
+	}
+	return c.deleteWithPropagation(storedObject{gvr: gvr, obj: obj}, policy)
+}
+
+// deleteWithPropagation deletes the object with the given propagation policy
+// and collects the garbage the deletion leaves behind.
+func (c *fakeClient) deleteWithPropagation(o storedObject, policy metav1.DeletionPropagation) error {
+	switch policy {
+	case metav1.DeletePropagationOrphan:
+		dependents, err := c.dependents(o)
+		if err != nil {
+			return err
+		}
+		for _, d := range dependents {
+			var refs []metav1.OwnerReference
+			for _, ref := range d.obj.GetOwnerReferences() {
+				if !o.ownedBy(ref, d.obj.GetNamespace()) {
+					refs = append(refs, ref)
+				}
+			}
+			d.obj.SetOwnerReferences(refs)
+			if err := c.tracker.update(d.gvr, d.obj, d.obj.GetNamespace(), false, false, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+		}
+	case metav1.DeletePropagationForeground:
+		if o.obj.GetDeletionTimestamp() == nil {
+			now := metav1.Now()
+			o.obj.SetDeletionTimestamp(&now)
+		}
+		if !slices.Contains(o.obj.GetFinalizers(), metav1.FinalizerDeleteDependents) {
+			o.obj.SetFinalizers(append(o.obj.GetFinalizers(), metav1.FinalizerDeleteDependents))
+		}
+		if err := c.tracker.update(o.gvr, o.obj, o.obj.GetNamespace(), false, true, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		return c.collectDependents(o, true)
+	}
+
+	if err := c.deleteObject(o.gvr, o.obj); err != nil {
+		return err
+	}
+	return c.collectGarbageOf(o)
+}
+
+// collectGarbageOf collects the garbage left behind by o, if it was removed.
+func (c *fakeClient) collectGarbageOf(o storedObject) error {
+	if exists, err := c.exists(o); err != nil || exists {
+		return err
+	}
+	if err := c.collectDependents(o, false); err != nil {
+		return err
+	}
+	// The owners of o that wait for their dependents might be done now.
+	for _, ref := range o.obj.GetOwnerReferences() {
+		owner, found, err := c.owner(o, ref)
+		if err != nil {
+			return err
+		}
+		if found && owner.waitingForDependents() {
+			if err := c.finishForegroundDeletion(owner); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectDependents deletes the dependents of owner that don't have other
+// owners and removes the owner reference from the others. If waiting, the
+// owner is being deleted in the foreground and is finished once no dependent
+// blocks its deletion anymore.
+func (c *fakeClient) collectDependents(owner storedObject, waiting bool) error {
+	dependents, err := c.dependents(owner)
+	if err != nil {
+		return err
+	}
+	for _, d := range dependents {
+		if err := c.collectDependent(d); err != nil {
+			return err
+		}
+	}
+	if waiting {
+		return c.finishForegroundDeletion(owner)
+	}
+	return nil
+}
+
+// collectDependent handles a dependent whose owners are gone or wait for it.
+func (c *fakeClient) collectDependent(d storedObject) error {
+	if exists, err := c.exists(d); err != nil || !exists {
+		return err
+	}
+	var solid []metav1.OwnerReference
+	var waiting, dangling int
+	for _, ref := range d.obj.GetOwnerReferences() {
+		owner, found, err := c.owner(d, ref)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !found:
+			dangling++
+		case owner.waitingForDependents():
+			waiting++
+		default:
+			solid = append(solid, ref)
+		}
+	}
+	if dangling+waiting == 0 {
+		return nil
+	}
+	if len(solid) > 0 {
+		d.obj.SetOwnerReferences(solid)
+		return c.tracker.update(d.gvr, d.obj, d.obj.GetNamespace(), false, false, metav1.UpdateOptions{})
+	}
+	if d.obj.GetDeletionTimestamp() != nil && (waiting == 0 || d.waitingForDependents()) {
+		// Already being deleted.
+		return nil
+	}
+	policy := metav1.DeletePropagationBackground
+	if waiting > 0 {
+		policy = metav1.DeletePropagationForeground
+	}
+	return c.deleteWithPropagation(d, policy)
+}
+
+// finishForegroundDeletion removes the foregroundDeletion finalizer from
+// owner once none of its dependents block its deletion anymore.
+func (c *fakeClient) finishForegroundDeletion(owner storedObject) error {
+	dependents, err := c.dependents(owner)
+	if err != nil {
+		return err
+	}
+	for _, d := range dependents {
+		for _, ref := range d.obj.GetOwnerReferences() {
+			if owner.ownedBy(ref, d.obj.GetNamespace()) && ref.BlockOwnerDeletion != nil && *ref.BlockOwnerDeletion {
+				return nil
+			}
+		}
+	}
+
+	current, err := c.tracker.Get(owner.gvr, owner.obj.GetNamespace(), owner.obj.GetName())
+	if err != nil {
+		return err
+	}
+	obj := current.(client.Object)
+	var finalizers []string
+	for _, f := range obj.GetFinalizers() {
+		if f != metav1.FinalizerDeleteDependents {
+			finalizers = append(finalizers, f)
+		}
+	}
+	obj.SetFinalizers(finalizers)
+	if err := c.tracker.update(owner.gvr, obj, obj.GetNamespace(), false, true, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	return c.collectGarbageOf(owner)
+}
+
+// collectGarbageAfter runs write on obj and collects the garbage left
+// behind, if write removed obj, e.g. by removing its last finalizer.
+func (c *fakeClient) collectGarbageAfter(obj client.Object, write func() error) error {
+	if c.gc == nil {
+		return write()
+	}
+	gvr, err := getGVRFromObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	old, err := c.tracker.Get(gvr, obj.GetNamespace(), obj.GetName())
+	if err != nil {
+		return write()
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	oldObj := old.(client.Object)
+	oldObj.GetObjectKind().SetGroupVersionKind(gvk)
+	return c.collectGarbageOf(storedObject{gvr: gvr, obj: oldObj})
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	apiextensionshelpers "k8s.io/apiextensions-apiserver/pkg/apihelpers"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/cel"
+	structuraldefaulting "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
+	structurallisttype "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/listtype"
+	schemaobjectmeta "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/objectmeta"
+	structuralpruning "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/pruning"
+	apiservervalidation "k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// WithCRDs registers CustomResourceDefinitions with the fake client. Objects of
+// the kinds they define are pruned, defaulted and validated against the schema
+// of their version on Create, Update and Patch, including the CEL rules in
+// x-kubernetes-validations, like the API server would do. Versions with a
+// status subresource are treated as if they were passed to WithStatusSubresource.
+//
+// The CRDs can be read from the same manifests envtest installs with
+// envtest.ReadCRDs.
+func (f *ClientBuilder) WithCRDs(crds ...*apiextensionsv1.CustomResourceDefinition) *ClientBuilder {
+	f.crds = append(f.crds, crds...)
+	return f
+}
+
+// crdSchema holds everything needed to admit objects of a single version of a CRD.
+type crdSchema struct {
+	namespaced bool
+	structural *structuralschema.Structural
+	validator  apiservervalidation.SchemaValidator
+	cel        *cel.Validator
+}
+
+// newCRDSchemas builds the schemas of all versions of the given CRDs.
+func newCRDSchemas(crds []*apiextensionsv1.CustomResourceDefinition) (map[schema.GroupVersionKind]*crdSchema, error) {
+	schemas := map[schema.GroupVersionKind]*crdSchema{}
+	for _, crd := range crds {
+		for _, version := range crd.Spec.Versions {
+			gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+			validation, err := apiextensionshelpers.GetSchemaForVersion(crd, version.Name)
+			if err != nil {
+				return nil, err
+			}
+			if validation == nil || validation.OpenAPIV3Schema == nil {
+				continue
+			}
+
+			internal := &apiextensions.CustomResourceValidation{}
+			if err := apiextensionsv1.Convert_v1_CustomResourceValidation_To_apiextensions_CustomResourceValidation(validation, internal, nil); err != nil {
+				return nil, fmt.Errorf("failed to convert schema of %s: %w", gvk, err)
+			}
+			structural, err := structuralschema.NewStructural(internal.OpenAPIV3Schema)
+			if err != nil {
+				return nil, fmt.Errorf("schema of %s is not structural: %w", gvk, err)
+			}
+			if err := structuraldefaulting.PruneDefaults(structural); err != nil {
+				return nil, fmt.Errorf("failed to prune defaults of %s: %w", gvk, err)
+			}
+			validator, _, err := apiservervalidation.NewSchemaValidator(internal.OpenAPIV3Schema)
+			if err != nil {
+				return nil, fmt.Errorf("failed to build validator for %s: %w", gvk, err)
+			}
+
+			schemas[gvk] = &crdSchema{
+				namespaced: crd.Spec.Scope == apiextensionsv1.NamespaceScoped,
+				structural: structural,
+				validator:  validator,
+				cel:        cel.NewValidator(structural, true, celconfig.PerCallLimit),
+			}
+		}
+	}
+	return schemas, nil
+}
+
+// crdKinds calls fn for every kind defined by the given CRDs, along with its
+// scope and whether it has a status subresource.
+func crdKinds(crds []*apiextensionsv1.CustomResourceDefinition, fn func(gvk schema.GroupVersionKind, scope meta.RESTScope, status bool)) {
+	for _, crd := range crds {
+		scope := meta.RESTScopeRoot
+		if crd.Spec.Scope == apiextensionsv1.NamespaceScoped {
+			scope = meta.RESTScopeNamespace
+		}
+		for _, version := range crd.Spec.Versions {
+			gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+			fn(gvk, scope, version.Subresources != nil && version.Subresources.Status != nil)
+		}
+	}
+}
+
+// admit prunes, defaults and validates obj if a CRD was registered for its kind.
+// oldObj is nil for creates. obj is updated in place with the defaulted content.
+func (t versionedTracker) admit(obj, oldObj runtime.Object) error {
+	if len(t.crdSchemas) == 0 {
+		return nil
+	}
+	gvk, err := apiutil.GVKForObject(obj, t.scheme)
+	if err != nil {
+		return err
+	}
+	s, ok := t.crdSchemas[gvk]
+	if !ok {
+		return nil
+	}
+
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	u["apiVersion"], u["kind"] = gvk.GroupVersion().String(), gvk.Kind
+
+	structuralpruning.Prune(u, s.structural, true)
+	structuraldefaulting.PruneNonNullableNullsWithoutDefaults(u, s.structural)
+	structuraldefaulting.Default(u, s.structural)
+
+	var old map[string]interface{}
+	if oldObj != nil {
+		if old, err = runtime.DefaultUnstructuredConverter.ToUnstructured(oldObj); err != nil {
+			return err
+		}
+		old["apiVersion"], old["kind"] = gvk.GroupVersion().String(), gvk.Kind
+	}
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	var errs field.ErrorList
+	errs = append(errs, validation.ValidateObjectMetaAccessor(accessor, s.namespaced, validation.NameIsDNSSubdomain, field.NewPath("metadata"))...)
+	if old == nil {
+		errs = append(errs, apiservervalidation.ValidateCustomResource(nil, u, s.validator)...)
+	} else {
+		errs = append(errs, apiservervalidation.ValidateCustomResourceUpdate(nil, u, old, s.validator)...)
+	}
+	errs = append(errs, schemaobjectmeta.Validate(nil, u, s.structural, false)...)
+	errs = append(errs, structurallisttype.ValidateListSetsAndMaps(nil, s.structural, u)...)
+	// CEL rules are only evaluated against objects that match the schema, as
+	// they rely on the types it declares.
+	if s.cel != nil && len(errs) == 0 {
+		celErrs, _ := s.cel.Validate(context.Background(), nil, s.structural, u, old, celconfig.RuntimeCELCostBudget)
+		errs = append(errs, celErrs...)
+	}
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(gvk.GroupKind(), accessor.GetName(), errs)
+	}
+
+	if unstructuredObj, isUnstructured := obj.(runtime.Unstructured); isUnstructured {
+		unstructuredObj.SetUnstructuredContent(u)
+		return nil
+	}
+	// Keep the TypeMeta of typed objects as it was passed in.
+	objGVK := obj.GetObjectKind().GroupVersionKind()
+	zero(obj)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, obj); err != nil {
+		return err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(objGVK)
+	return nil
+}
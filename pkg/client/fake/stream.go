@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PodExecFunc handles a command executed in a pod by the fake client. It
+// reads the command's input from streams.Stdin and writes its output to
+// streams.Stdout and streams.Stderr, if set. The returned error is returned
+// by the executor's StreamWithContext.
+type PodExecFunc func(ctx context.Context, key client.ObjectKey, opts *corev1.PodExecOptions, streams remotecommand.StreamOptions) error
+
+type podContainerKey struct {
+	key       client.ObjectKey
+	container string
+}
+
+// WithPodLogs configures the logs that the fake client streams for the given
+// container of the pod with the given key. The pod itself must exist.
+// Containers without logs have empty logs.
+func (f *ClientBuilder) WithPodLogs(key client.ObjectKey, container, logs string) *ClientBuilder {
+	if f.podLogs == nil {
+		f.podLogs = map[podContainerKey]string{}
+	}
+	f.podLogs[podContainerKey{key: key, container: container}] = logs
+	return f
+}
+
+// WithPodExecFunc configures the function that handles commands executed in
+// pods with PodExec. Without it, executing commands fails.
+func (f *ClientBuilder) WithPodExecFunc(fn PodExecFunc) *ClientBuilder {
+	f.podExecFunc = fn
+	return f
+}
+
+var _ client.StreamClient = &fakeClient{}
+
+// PodLogs implements client.StreamClient. It supports the TailLines and
+// LimitBytes options, all other options are ignored.
+func (c *fakeClient) PodLogs(ctx context.Context, key client.ObjectKey, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+	if opts == nil {
+		opts = &corev1.PodLogOptions{}
+	}
+	// Validate the options like the API server does.
+	if opts.TailLines != nil && *opts.TailLines < 0 {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("tailLines: Invalid value: %d: must be greater than or equal to 0", *opts.TailLines))
+	}
+	if opts.LimitBytes != nil && *opts.LimitBytes < 1 {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("limitBytes: Invalid value: %d: must be greater than 0", *opts.LimitBytes))
+	}
+	pod := &corev1.Pod{}
+	if err := c.Get(ctx, key, pod); err != nil {
+		return nil, err
+	}
+	container, err := podContainer(pod, opts.Container)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := c.podLogs[podContainerKey{key: key, container: container}]
+	if opts.TailLines != nil {
+		lines := strings.SplitAfter(logs, "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if tail := int(*opts.TailLines); tail < len(lines) {
+			lines = lines[len(lines)-tail:]
+		}
+		logs = strings.Join(lines, "")
+	}
+	if opts.LimitBytes != nil && int(*opts.LimitBytes) < len(logs) {
+		logs = logs[:*opts.LimitBytes]
+	}
+	return io.NopCloser(strings.NewReader(logs)), nil
+}
+
+// PodExec implements client.StreamClient. The command is handled by the
+// function configured with WithPodExecFunc once the executor is started.
+func (c *fakeClient) PodExec(key client.ObjectKey, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+	if opts == nil {
+		opts = &corev1.PodExecOptions{}
+	}
+	return &fakeExecutor{client: c, key: key, opts: opts.DeepCopy()}, nil
+}
+
+// PodAttach implements client.StreamClient. It isn't supported by the fake client.
+func (c *fakeClient) PodAttach(client.ObjectKey, *corev1.PodAttachOptions) (remotecommand.Executor, error) {
+	return nil, errors.New("attaching to pods is not supported by the fake client")
+}
+
+// PodPortForward implements client.StreamClient. It isn't supported by the fake client.
+func (c *fakeClient) PodPortForward(client.ObjectKey) (httpstream.Dialer, error) {
+	return nil, errors.New("port-forwarding is not supported by the fake client")
+}
+
+// podContainer returns the name of the container of the pod, defaulting to
+// its only container like the API server.
+func podContainer(pod *corev1.Pod, container string) (string, error) {
+	if container != "" {
+		for _, c := range pod.Spec.Containers {
+			if c.Name == container {
+				return container, nil
+			}
+		}
+		return "", apierrors.NewBadRequest(fmt.Sprintf("container %s is not valid for pod %s", container, pod.Name))
+	}
+	if len(pod.Spec.Containers) != 1 {
+		names := make([]string, 0, len(pod.Spec.Containers))
+		for _, c := range pod.Spec.Containers {
+			names = append(names, c.Name)
+		}
+		return "", apierrors.NewBadRequest(fmt.Sprintf("a container name must be specified for pod %s, choose one of: %v", pod.Name, names))
+	}
+	return pod.Spec.Containers[0].Name, nil
+}
+
+type fakeExecutor struct {
+	client *fakeClient
+	key    client.ObjectKey
+	opts   *corev1.PodExecOptions
+}
+
+// Stream implements remotecommand.Executor.
+func (e *fakeExecutor) Stream(options remotecommand.StreamOptions) error {
+	return e.StreamWithContext(context.Background(), options)
+}
+
+// StreamWithContext implements remotecommand.Executor.
+func (e *fakeExecutor) StreamWithContext(ctx context.Context, options remotecommand.StreamOptions) error {
+	pod := &corev1.Pod{}
+	if err := e.client.Get(ctx, e.key, pod); err != nil {
+		return err
+	}
+	opts := e.opts.DeepCopy()
+	container, err := podContainer(pod, opts.Container)
+	if err != nil {
+		return err
+	}
+	opts.Container = container
+	if e.client.podExecFunc == nil {
+		return errors.New("executing commands in pods requires a PodExecFunc, see ClientBuilder.WithPodExecFunc")
+	}
+	return e.client.podExecFunc(ctx, e.key, opts, options)
+}
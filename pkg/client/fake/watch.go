@@ -0,0 +1,384 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const defaultWatchHistorySize = 100
+
+// WithWatchHistory makes the fake client keep the last size events, so that
+// watches can start from the resourceVersion returned by List or of any
+// object, like they do against a real API server. Watches starting from a
+// resourceVersion that is no longer in the history fail with 410 Gone.
+//
+// With a watch history, resourceVersions are taken from a single counter
+// shared by all objects instead of being incremented per object.
+func (f *ClientBuilder) WithWatchHistory(size int) *ClientBuilder {
+	f.watchHistorySize = size
+	return f
+}
+
+// WithWatchBookmarks makes watches that allow bookmarks receive a bookmark
+// event with the current resourceVersion at the given interval. As bookmarks
+// require a watch history, it is enabled with its default size if
+// WithWatchHistory wasn't called.
+func (f *ClientBuilder) WithWatchBookmarks(interval time.Duration) *ClientBuilder {
+	f.watchBookmarkInterval = interval
+	return f
+}
+
+// watchHistory assigns resourceVersions to objects and records the events
+// of the tracker, so that they can be replayed to watches.
+type watchHistory struct {
+	// writes is held for reading by writes from assigning a resourceVersion
+	// until the object is stored, and for writing by lists, so that the
+	// resourceVersion of a list includes all of the objects up to it.
+	writes sync.RWMutex
+
+	mu               sync.Mutex
+	size             int
+	bookmarkInterval time.Duration
+	revision         uint64
+	// compacted is the resourceVersion of the newest event that was dropped
+	// from events.
+	compacted uint64
+	events    []historyEvent
+	watchers  map[*historyWatcher]struct{}
+}
+
+type historyEvent struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+	eventType watch.EventType
+	rv        uint64
+	obj       runtime.Object
+	// old is the previous state of the object for modifications.
+	old runtime.Object
+}
+
+func newWatchHistory(size int, bookmarkInterval time.Duration) *watchHistory {
+	if size <= 0 {
+		size = defaultWatchHistorySize
+	}
+	return &watchHistory{
+		size:             size,
+		bookmarkInterval: bookmarkInterval,
+		watchers:         map[*historyWatcher]struct{}{},
+	}
+}
+
+func (h *watchHistory) nextResourceVersion() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.revision++
+	return strconv.FormatUint(h.revision, 10)
+}
+
+func (h *watchHistory) resourceVersion() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return strconv.FormatUint(h.revision, 10)
+}
+
+// list calls f with no write in progress and returns its result together
+// with the resourceVersion it corresponds to.
+func (h *watchHistory) list(f func() (runtime.Object, error)) (runtime.Object, string, error) {
+	h.writes.Lock()
+	defer h.writes.Unlock()
+	obj, err := f()
+	if err != nil {
+		return nil, "", err
+	}
+	return obj, h.resourceVersion(), nil
+}
+
+func (h *watchHistory) record(gvr schema.GroupVersionResource, eventType watch.EventType, obj, old runtime.Object) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	rv, err := strconv.ParseUint(accessor.GetResourceVersion(), 10, 64)
+	if err != nil {
+		return
+	}
+	ev := historyEvent{gvr: gvr, namespace: accessor.GetNamespace(), eventType: eventType, rv: rv, obj: obj, old: old}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// Concurrent writes may be recorded in a different order than their
+	// resourceVersions were assigned in, so keep the events sorted.
+	i := len(h.events)
+	for i > 0 && h.events[i-1].rv > rv {
+		i--
+	}
+	h.events = slices.Insert(h.events, i, ev)
+	if len(h.events) > h.size {
+		h.compacted = h.events[0].rv
+		h.events = slices.Delete(h.events, 0, 1)
+	}
+	for w := range h.watchers {
+		w.send(ev)
+	}
+}
+
+// watch starts a watch for the given resource that replays all events after
+// resourceVersion. If resourceVersion is empty or "0", only new events are sent.
+// match filters the objects of the events if set, bookmark creates the object
+// for bookmark events if set.
+func (h *watchHistory) watch(gvr schema.GroupVersionResource, namespace, resourceVersion string, match func(runtime.Object) bool, bookmark func(resourceVersion string) runtime.Object) (watch.Interface, error) {
+	var start uint64
+	replay := resourceVersion != "" && resourceVersion != "0"
+	if replay {
+		var err error
+		start, err = strconv.ParseUint(resourceVersion, 10, 64)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid resource version %q", resourceVersion))
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if replay && start < h.compacted {
+		return nil, apierrors.NewResourceExpired(fmt.Sprintf("too old resource version: %d (%d)", start, h.compacted+1))
+	}
+
+	w := &historyWatcher{
+		history:   h,
+		gvr:       gvr,
+		namespace: namespace,
+		start:     start,
+		match:     match,
+		bookmark:  bookmark,
+		notify:    make(chan struct{}, 1),
+		result:    make(chan watch.Event),
+		done:      make(chan struct{}),
+	}
+	if replay {
+		for _, ev := range h.events {
+			w.send(ev)
+		}
+	}
+	h.watchers[w] = struct{}{}
+	go w.run(h.bookmarkInterval)
+	return w, nil
+}
+
+// historyWatcher is a watch on a watchHistory. Events are queued, so that
+// recording them never blocks on slow consumers.
+type historyWatcher struct {
+	history   *watchHistory
+	gvr       schema.GroupVersionResource
+	namespace string
+	start     uint64
+	match     func(runtime.Object) bool
+	bookmark  func(resourceVersion string) runtime.Object
+
+	mu       sync.Mutex
+	queue    []watch.Event
+	notify   chan struct{}
+	result   chan watch.Event
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+var _ watch.Interface = &historyWatcher{}
+
+// send queues the event if it concerns the watched resource. Modifications
+// are translated like the API server does for watches with selectors: they
+// are sent as additions or deletions if the object starts or stops matching.
+func (w *historyWatcher) send(ev historyEvent) {
+	if ev.gvr != w.gvr || (w.namespace != "" && ev.namespace != w.namespace) || ev.rv <= w.start {
+		return
+	}
+
+	eventType := ev.eventType
+	if w.match != nil {
+		matches := w.match(ev.obj)
+		if ev.eventType == watch.Modified && ev.old != nil {
+			matched := w.match(ev.old)
+			switch {
+			case matches && !matched:
+				eventType = watch.Added
+			case !matches && matched:
+				eventType = watch.Deleted
+				matches = true
+			}
+		}
+		if !matches {
+			return
+		}
+	}
+	w.enqueue(watch.Event{Type: eventType, Object: ev.obj.DeepCopyObject()})
+}
+
+func (w *historyWatcher) enqueue(ev watch.Event) {
+	w.mu.Lock()
+	w.queue = append(w.queue, ev)
+	w.mu.Unlock()
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (w *historyWatcher) run(bookmarkInterval time.Duration) {
+	defer close(w.result)
+
+	var bookmarks <-chan time.Time
+	if bookmarkInterval > 0 && w.bookmark != nil {
+		ticker := time.NewTicker(bookmarkInterval)
+		defer ticker.Stop()
+		bookmarks = ticker.C
+	}
+
+	for {
+		w.mu.Lock()
+		queued := len(w.queue) > 0
+		var ev watch.Event
+		if queued {
+			ev = w.queue[0]
+			w.queue = w.queue[1:]
+		}
+		w.mu.Unlock()
+
+		if queued {
+			select {
+			case w.result <- ev:
+				continue
+			case <-w.done:
+				return
+			}
+		}
+
+		select {
+		case <-w.notify:
+		case <-bookmarks:
+			w.enqueue(watch.Event{Type: watch.Bookmark, Object: w.bookmark(w.history.resourceVersion())})
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Stop implements watch.Interface.
+func (w *historyWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		w.history.mu.Lock()
+		delete(w.history.watchers, w)
+		w.history.mu.Unlock()
+		close(w.done)
+	})
+}
+
+// ResultChan implements watch.Interface.
+func (w *historyWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// historyTracker records the changes made to the wrapped tracker in a watchHistory.
+type historyTracker struct {
+	testing.ObjectTracker
+	scheme  *runtime.Scheme
+	history *watchHistory
+}
+
+func (t historyTracker) Add(obj runtime.Object) error {
+	if err := t.ObjectTracker.Add(obj); err != nil {
+		return err
+	}
+	gvk, err := apiutil.GVKForObject(obj, t.scheme)
+	if err != nil {
+		return err
+	}
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+	t.history.record(gvr, watch.Added, obj.DeepCopyObject(), nil)
+	return nil
+}
+
+func (t historyTracker) Create(gvr schema.GroupVersionResource, obj runtime.Object, ns string, opts ...metav1.CreateOptions) error {
+	if err := t.ObjectTracker.Create(gvr, obj, ns, opts...); err != nil {
+		return err
+	}
+	t.history.record(gvr, watch.Added, obj.DeepCopyObject(), nil)
+	return nil
+}
+
+func (t historyTracker) Update(gvr schema.GroupVersionResource, obj runtime.Object, ns string, opts ...metav1.UpdateOptions) error {
+	old, err := t.ObjectTracker.Get(gvr, ns, objectName(obj))
+	if err != nil {
+		old = nil
+	}
+	if err := t.ObjectTracker.Update(gvr, obj, ns, opts...); err != nil {
+		return err
+	}
+	t.history.record(gvr, watch.Modified, obj.DeepCopyObject(), old)
+	return nil
+}
+
+func (t historyTracker) Patch(gvr schema.GroupVersionResource, obj runtime.Object, ns string, opts ...metav1.PatchOptions) error {
+	old, err := t.ObjectTracker.Get(gvr, ns, objectName(obj))
+	if err != nil {
+		old = nil
+	}
+	if err := t.ObjectTracker.Patch(gvr, obj, ns, opts...); err != nil {
+		return err
+	}
+	t.history.record(gvr, watch.Modified, obj.DeepCopyObject(), old)
+	return nil
+}
+
+func (t historyTracker) Delete(gvr schema.GroupVersionResource, ns, name string, opts ...metav1.DeleteOptions) error {
+	obj, err := t.ObjectTracker.Get(gvr, ns, name)
+	if err != nil {
+		return err
+	}
+	if err := t.ObjectTracker.Delete(gvr, ns, name, opts...); err != nil {
+		return err
+	}
+	// Like the API server, send the deleted object with the resourceVersion of its deletion.
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	accessor.SetResourceVersion(t.history.nextResourceVersion())
+	t.history.record(gvr, watch.Deleted, obj, nil)
+	return nil
+}
+
+func objectName(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetName()
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// TypedClient is a facade over a Client for objects of type T, whose list type
+// is L. It returns T from Get and []T from List instead of filling in
+// pre-allocated objects. All calls go through the wrapped Client, so reads are
+// served by its cache and interceptors apply as usual.
+//
+// T and L must be pointers to structs that are registered in the scheme of the
+// wrapped Client, e.g. TypedClient[*corev1.Pod, *corev1.PodList]. Unstructured
+// objects are not supported, as they don't carry their kind in their type.
+type TypedClient[T Object, L ObjectList] struct {
+	client Client
+}
+
+// NewTypedClient returns a TypedClient for objects of type T that uses the
+// given Client.
+func NewTypedClient[T Object, L ObjectList](c Client) *TypedClient[T, L] {
+	return &TypedClient[T, L]{client: c}
+}
+
+// TypedWatchEvent is an event of a watch started by TypedClient.Watch.
+type TypedWatchEvent[T Object] struct {
+	// Type is the type of the event.
+	Type watch.EventType
+
+	// Object is the object of the event. It is unset for watch.Error events.
+	Object T
+
+	// Err is the error reported by watch.Error events.
+	Err error
+}
+
+// Client returns the wrapped Client.
+func (c *TypedClient[T, L]) Client() Client {
+	return c.client
+}
+
+// Get retrieves the object with the given key.
+func (c *TypedClient[T, L]) Get(ctx context.Context, key ObjectKey, opts ...GetOption) (T, error) {
+	obj := newOf[T]()
+	if err := c.client.Get(ctx, key, obj, opts...); err != nil {
+		var zero T
+		return zero, err
+	}
+	return obj, nil
+}
+
+// List retrieves all objects matching the given options. If Limit is set, only
+// the first page is returned, use All to iterate over all pages.
+func (c *TypedClient[T, L]) List(ctx context.Context, opts ...ListOption) ([]T, error) {
+	items, _, err := c.list(ctx, opts...)
+	return items, err
+}
+
+// All returns an iterator over all objects matching the given options. If
+// Limit is set, the objects are retrieved in pages of that size, following
+// the continue token of each page. Iteration stops after the first error.
+func (c *TypedClient[T, L]) All(ctx context.Context, opts ...ListOption) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var continueToken string
+		for {
+			items, next, err := c.list(ctx, append(opts, Continue(continueToken))...)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			continueToken = next
+		}
+	}
+}
+
+func (c *TypedClient[T, L]) list(ctx context.Context, opts ...ListOption) ([]T, string, error) {
+	list := newOf[L]()
+	if err := c.client.List(ctx, list, opts...); err != nil {
+		return nil, "", err
+	}
+	objs, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, "", err
+	}
+	items := make([]T, 0, len(objs))
+	for _, obj := range objs {
+		item, ok := obj.(T)
+		if !ok {
+			return nil, "", fmt.Errorf("list %T contains %T, expected %T", list, obj, *new(T))
+		}
+		items = append(items, item)
+	}
+	return items, list.GetContinue(), nil
+}
+
+// Create saves the object in the Kubernetes cluster.
+func (c *TypedClient[T, L]) Create(ctx context.Context, obj T, opts ...CreateOption) error {
+	return c.client.Create(ctx, obj, opts...)
+}
+
+// Update updates the given object in the Kubernetes cluster.
+func (c *TypedClient[T, L]) Update(ctx context.Context, obj T, opts ...UpdateOption) error {
+	return c.client.Update(ctx, obj, opts...)
+}
+
+// Patch patches the given object in the Kubernetes cluster.
+func (c *TypedClient[T, L]) Patch(ctx context.Context, obj T, patch Patch, opts ...PatchOption) error {
+	return c.client.Patch(ctx, obj, patch, opts...)
+}
+
+// Delete deletes the given object from the Kubernetes cluster.
+func (c *TypedClient[T, L]) Delete(ctx context.Context, obj T, opts ...DeleteOption) error {
+	return c.client.Delete(ctx, obj, opts...)
+}
+
+// DeleteAllOf deletes all objects of type T matching the given options.
+func (c *TypedClient[T, L]) DeleteAllOf(ctx context.Context, opts ...DeleteAllOfOption) error {
+	return c.client.DeleteAllOf(ctx, newOf[T](), opts...)
+}
+
+// Status returns a client for the status subresource of objects of type T.
+func (c *TypedClient[T, L]) Status() SubResourceWriter {
+	return c.client.Status()
+}
+
+// Watch watches objects matching the given options. The wrapped Client must
+// implement WithWatch. The returned channel is closed when the watch ends or
+// the context is cancelled.
+func (c *TypedClient[T, L]) Watch(ctx context.Context, opts ...ListOption) (<-chan TypedWatchEvent[T], error) {
+	wc, ok := c.client.(WithWatch)
+	if !ok {
+		return nil, fmt.Errorf("client %T does not support watches", c.client)
+	}
+	w, err := wc.Watch(ctx, newOf[L](), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan TypedWatchEvent[T])
+	go func() {
+		defer close(events)
+		defer w.Stop()
+		for {
+			var event TypedWatchEvent[T]
+			select {
+			case <-ctx.Done():
+				return
+			case in, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+				event.Type = in.Type
+				if in.Type == watch.Error {
+					event.Err = apierrors.FromObject(in.Object)
+				} else if obj, ok := in.Object.(T); ok {
+					event.Object = obj
+				} else {
+					event.Type = watch.Error
+					event.Err = fmt.Errorf("unexpected %T in %s watch event, expected %T", in.Object, in.Type, *new(T))
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case events <- event:
+			}
+		}
+	}()
+	return events, nil
+}
+
+// newOf returns a new object of type O, which must be a pointer to a struct.
+func newOf[O any]() O {
+	return reflect.New(reflect.TypeFor[O]().Elem()).Interface().(O)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestTypedClientGetAndList(t *testing.T) {
+	gets := 0
+	c := fake.NewClientBuilder().
+		WithObjects(
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bar"}},
+		).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				gets++
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+	pods := client.NewTypedClient[*corev1.Pod, *corev1.PodList](c)
+	ctx := context.Background()
+
+	pod, err := pods.Get(ctx, client.ObjectKey{Namespace: "default", Name: "foo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pod.Name != "foo" {
+		t.Errorf("expected pod foo, got %q", pod.Name)
+	}
+	if gets != 1 {
+		t.Errorf("expected Get to go through the interceptor")
+	}
+
+	if pod, err := pods.Get(ctx, client.ObjectKey{Namespace: "default", Name: "missing"}); err == nil || pod != nil {
+		t.Errorf("expected an error and no pod, got %v and %v", err, pod)
+	}
+
+	items, err := pods.List(ctx, client.InNamespace("default"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("expected 2 pods, got %d", len(items))
+	}
+
+	if err := pods.Create(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "baz"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pods.DeleteAllOf(ctx, client.InNamespace("default")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if items, _ := pods.List(ctx); len(items) != 0 {
+		t.Errorf("expected all pods to be deleted, got %d", len(items))
+	}
+}
+
+func TestTypedClientAll(t *testing.T) {
+	pages := map[string]*corev1.PodList{
+		"": {
+			ListMeta: metav1.ListMeta{Continue: "second"},
+			Items:    []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "a"}}, {ObjectMeta: metav1.ObjectMeta{Name: "b"}}},
+		},
+		"second": {
+			Items: []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "c"}}},
+		},
+	}
+	var limits []int64
+	c := fake.NewClientBuilder().
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(_ context.Context, _ client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+				limits = append(limits, listOpts.Limit)
+				pages[listOpts.Continue].DeepCopyInto(list.(*corev1.PodList))
+				return nil
+			},
+		}).
+		Build()
+	pods := client.NewTypedClient[*corev1.Pod, *corev1.PodList](c)
+
+	var names []string
+	for pod, err := range pods.All(context.Background(), client.Limit(2)) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		names = append(names, pod.Name)
+	}
+	if len(names) != 3 || names[0] != "a" || names[2] != "c" {
+		t.Errorf("expected pods a, b and c, got %v", names)
+	}
+	if len(limits) != 2 || limits[0] != 2 || limits[1] != 2 {
+		t.Errorf("expected two pages of size 2, got %v", limits)
+	}
+
+	names = nil
+	for pod := range pods.All(context.Background(), client.Limit(2)) {
+		names = append(names, pod.Name)
+		break
+	}
+	if len(names) != 1 || len(limits) != 3 {
+		t.Errorf("expected iteration to stop after the first page, got %v after %d lists", names, len(limits))
+	}
+}
+
+func TestTypedClientWatch(t *testing.T) {
+	c := fake.NewClientBuilder().Build()
+	pods := client.NewTypedClient[*corev1.Pod, *corev1.PodList](c)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := pods.Watch(ctx, client.InNamespace("default"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pods.Create(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case event := <-events:
+		if event.Type != watch.Added || event.Object.Name != "foo" || event.Err != nil {
+			t.Errorf("expected pod foo to be added, got %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for watch event")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected the channel to be closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the channel to be closed")
+	}
+
+	var notWatching struct{ client.Client }
+	if _, err := client.NewTypedClient[*corev1.Pod, *corev1.PodList](notWatching).Watch(ctx); err == nil {
+		t.Error("expected an error for clients without watch support")
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+// Impersonate returns an option that makes a single request impersonate the
+// given user, groups, UID and extra attributes. It can be passed to all
+// methods of Client, Status() and SubResource().
+//
+// Reads that impersonate a user are always sent to the API server, so that
+// the API server authorizes them, and are never coalesced.
+//
+// The option is only honored by clients created by New or wrapping one.
+// Clients whose rest.Config impersonates a user itself reject it.
+func Impersonate(config rest.ImpersonationConfig) ImpersonateOption {
+	return ImpersonateOption(config)
+}
+
+// ImpersonateOption is the option returned by Impersonate.
+type ImpersonateOption rest.ImpersonationConfig
+
+var (
+	_ GetOption               = ImpersonateOption{}
+	_ ListOption              = ImpersonateOption{}
+	_ CreateOption            = ImpersonateOption{}
+	_ UpdateOption            = ImpersonateOption{}
+	_ PatchOption             = ImpersonateOption{}
+	_ DeleteOption            = ImpersonateOption{}
+	_ DeleteAllOfOption       = ImpersonateOption{}
+	_ SubResourceGetOption    = ImpersonateOption{}
+	_ SubResourceCreateOption = ImpersonateOption{}
+	_ SubResourceUpdateOption = ImpersonateOption{}
+	_ SubResourcePatchOption  = ImpersonateOption{}
+)
+
+// The option isn't part of the request options, the client reads it from the
+// list of options directly, so applying it is a no-op.
+
+// ApplyToGet implements GetOption.
+func (ImpersonateOption) ApplyToGet(*GetOptions) {}
+
+// ApplyToList implements ListOption.
+func (ImpersonateOption) ApplyToList(*ListOptions) {}
+
+// ApplyToCreate implements CreateOption.
+func (ImpersonateOption) ApplyToCreate(*CreateOptions) {}
+
+// ApplyToUpdate implements UpdateOption.
+func (ImpersonateOption) ApplyToUpdate(*UpdateOptions) {}
+
+// ApplyToPatch implements PatchOption.
+func (ImpersonateOption) ApplyToPatch(*PatchOptions) {}
+
+// ApplyToDelete implements DeleteOption.
+func (ImpersonateOption) ApplyToDelete(*DeleteOptions) {}
+
+// ApplyToDeleteAllOf implements DeleteAllOfOption.
+func (ImpersonateOption) ApplyToDeleteAllOf(*DeleteAllOfOptions) {}
+
+// ApplyToSubResourceGet implements SubResourceGetOption.
+func (ImpersonateOption) ApplyToSubResourceGet(*SubResourceGetOptions) {}
+
+// ApplyToSubResourceCreate implements SubResourceCreateOption.
+func (ImpersonateOption) ApplyToSubResourceCreate(*SubResourceCreateOptions) {}
+
+// ApplyToSubResourceUpdate implements SubResourceUpdateOption.
+func (ImpersonateOption) ApplyToSubResourceUpdate(*SubResourceUpdateOptions) {}
+
+// ApplyToSubResourcePatch implements SubResourcePatchOption.
+func (ImpersonateOption) ApplyToSubResourcePatch(*SubResourcePatchOptions) {}
+
+type impersonationContextKey struct{}
+
+// withImpersonation returns ctx carrying the last ImpersonateOption in opts,
+// if any, and whether it found one. It returns an error if the rest.Config of
+// c impersonates a user itself, as client-go would send the impersonated user
+// of the config together with the groups of both, mixing their privileges.
+func withImpersonation[O any](ctx context.Context, c *client, opts []O) (context.Context, bool, error) {
+	for i := len(opts) - 1; i >= 0; i-- {
+		if o, ok := any(opts[i]).(ImpersonateOption); ok {
+			if c.configImpersonates {
+				return ctx, false, errConfigImpersonates
+			}
+			return context.WithValue(ctx, impersonationContextKey{}, o), true, nil
+		}
+	}
+	return ctx, false, nil
+}
+
+var errConfigImpersonates = errors.New("the Impersonate option can't be used with a client whose rest.Config impersonates a user")
+
+// impersonatingRoundTripper sets the impersonation headers of requests whose
+// context carries an ImpersonateOption.
+type impersonatingRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+var _ http.RoundTripper = &impersonatingRoundTripper{}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *impersonatingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	o, ok := req.Context().Value(impersonationContextKey{}).(ImpersonateOption)
+	if !ok {
+		return rt.delegate.RoundTrip(req)
+	}
+	return transport.NewImpersonatingRoundTripper(transport.ImpersonationConfig{
+		UserName: o.UserName,
+		UID:      o.UID,
+		Groups:   o.Groups,
+		Extra:    o.Extra,
+	}, rt.delegate).RoundTrip(req)
+}
+
+// WrappedRoundTripper implements k8s.io/apimachinery/pkg/util/net.RoundTripperWrapper.
+func (rt *impersonatingRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.delegate
+}
+
+// WithImpersonation wraps a Client and makes all of its requests impersonate
+// the given user, groups, UID and extra attributes, see Impersonate. An
+// Impersonate option passed to a single call takes precedence. Like the option,
+// it can't be used if the rest.Config of the wrapped client impersonates a user.
+//
+// The wrapped client must be created by New. As reads that impersonate a user
+// are never served from the cache, it's usually created without a cache, e.g.
+// the API reader of a cluster wrapped by cluster.NewImpersonatingClient.
+func WithImpersonation(c Client, config rest.ImpersonationConfig) Client {
+	return &impersonatingClient{
+		option: Impersonate(config),
+		client: c,
+	}
+}
+
+type impersonatingClient struct {
+	option ImpersonateOption
+	client Client
+}
+
+var _ Client = &impersonatingClient{}
+
+func (c *impersonatingClient) Get(ctx context.Context, key ObjectKey, obj Object, opts ...GetOption) error {
+	return c.client.Get(ctx, key, obj, append([]GetOption{c.option}, opts...)...)
+}
+
+func (c *impersonatingClient) List(ctx context.Context, obj ObjectList, opts ...ListOption) error {
+	return c.client.List(ctx, obj, append([]ListOption{c.option}, opts...)...)
+}
+
+func (c *impersonatingClient) Create(ctx context.Context, obj Object, opts ...CreateOption) error {
+	return c.client.Create(ctx, obj, append([]CreateOption{c.option}, opts...)...)
+}
+
+func (c *impersonatingClient) Update(ctx context.Context, obj Object, opts ...UpdateOption) error {
+	return c.client.Update(ctx, obj, append([]UpdateOption{c.option}, opts...)...)
+}
+
+func (c *impersonatingClient) Patch(ctx context.Context, obj Object, patch Patch, opts ...PatchOption) error {
+	return c.client.Patch(ctx, obj, patch, append([]PatchOption{c.option}, opts...)...)
+}
+
+func (c *impersonatingClient) Delete(ctx context.Context, obj Object, opts ...DeleteOption) error {
+	return c.client.Delete(ctx, obj, append([]DeleteOption{c.option}, opts...)...)
+}
+
+func (c *impersonatingClient) DeleteAllOf(ctx context.Context, obj Object, opts ...DeleteAllOfOption) error {
+	return c.client.DeleteAllOf(ctx, obj, append([]DeleteAllOfOption{c.option}, opts...)...)
+}
+
+func (c *impersonatingClient) Status() SubResourceWriter {
+	return c.SubResource("status")
+}
+
+func (c *impersonatingClient) SubResource(subResource string) SubResourceClient {
+	return &impersonatingSubResourceClient{
+		option: c.option,
+		client: c.client.SubResource(subResource),
+	}
+}
+
+func (c *impersonatingClient) Scheme() *runtime.Scheme     { return c.client.Scheme() }
+func (c *impersonatingClient) RESTMapper() meta.RESTMapper { return c.client.RESTMapper() }
+func (c *impersonatingClient) GroupVersionKindFor(obj runtime.Object) (schema.GroupVersionKind, error) {
+	return c.client.GroupVersionKindFor(obj)
+}
+func (c *impersonatingClient) IsObjectNamespaced(obj runtime.Object) (bool, error) {
+	return c.client.IsObjectNamespaced(obj)
+}
+
+type impersonatingSubResourceClient struct {
+	option ImpersonateOption
+	client SubResourceClient
+}
+
+var _ SubResourceClient = &impersonatingSubResourceClient{}
+
+func (c *impersonatingSubResourceClient) Get(ctx context.Context, obj Object, subResource Object, opts ...SubResourceGetOption) error {
+	return c.client.Get(ctx, obj, subResource, append([]SubResourceGetOption{c.option}, opts...)...)
+}
+
+func (c *impersonatingSubResourceClient) Create(ctx context.Context, obj Object, subResource Object, opts ...SubResourceCreateOption) error {
+	return c.client.Create(ctx, obj, subResource, append([]SubResourceCreateOption{c.option}, opts...)...)
+}
+
+func (c *impersonatingSubResourceClient) Update(ctx context.Context, obj Object, opts ...SubResourceUpdateOption) error {
+	return c.client.Update(ctx, obj, append([]SubResourceUpdateOption{c.option}, opts...)...)
+}
+
+func (c *impersonatingSubResourceClient) Patch(ctx context.Context, obj Object, patch Patch, opts ...SubResourcePatchOption) error {
+	return c.client.Patch(ctx, obj, patch, append([]SubResourcePatchOption{c.option}, opts...)...)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestImpersonation(t *testing.T) {
+	var mu sync.Mutex
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = r.Header.Clone()
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		})
+	}))
+	defer server.Close()
+	lastHeaders := func() http.Header {
+		mu.Lock()
+		defer mu.Unlock()
+		return headers
+	}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	c, err := client.New(&rest.Config{Host: server.URL}, client.Options{Scheme: scheme.Scheme, Mapper: mapper})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+	key := client.ObjectKey{Namespace: "default", Name: "foo"}
+
+	if err := c.Get(ctx, key, &corev1.ConfigMap{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user := lastHeaders().Get("Impersonate-User"); user != "" {
+		t.Errorf("expected no impersonation without the option, got user %q", user)
+	}
+
+	alice := rest.ImpersonationConfig{UserName: "alice", Groups: []string{"tenant-a"}, UID: "1234"}
+	if err := c.Get(ctx, key, &corev1.ConfigMap{}, client.Impersonate(alice)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := lastHeaders()
+	if user := h.Get("Impersonate-User"); user != "alice" {
+		t.Errorf("expected Get to impersonate alice, got %q", user)
+	}
+	if group := h.Get("Impersonate-Group"); group != "tenant-a" {
+		t.Errorf("expected Get to impersonate group tenant-a, got %q", group)
+	}
+	if uid := h.Get("Impersonate-Uid"); uid != "1234" {
+		t.Errorf("expected Get to impersonate UID 1234, got %q", uid)
+	}
+
+	tenant := client.WithImpersonation(c, rest.ImpersonationConfig{UserName: "bob"})
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	if err := tenant.Create(ctx, cm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user := lastHeaders().Get("Impersonate-User"); user != "bob" {
+		t.Errorf("expected Create to impersonate bob, got %q", user)
+	}
+	if err := tenant.Update(ctx, cm, client.Impersonate(alice)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user := lastHeaders().Get("Impersonate-User"); user != "alice" {
+		t.Errorf("expected the option of a call to take precedence, got %q", user)
+	}
+	if err := tenant.List(ctx, &corev1.ConfigMapList{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user := lastHeaders().Get("Impersonate-User"); user != "bob" {
+		t.Errorf("expected List to impersonate bob, got %q", user)
+	}
+
+	if err := c.Get(ctx, key, &corev1.ConfigMap{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user := lastHeaders().Get("Impersonate-User"); user != "" {
+		t.Errorf("expected impersonation to be limited to single calls, got user %q", user)
+	}
+}
+
+func TestImpersonationWithImpersonatingConfig(t *testing.T) {
+	var mu sync.Mutex
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		})
+	}))
+	defer server.Close()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	config := &rest.Config{Host: server.URL, Impersonate: rest.ImpersonationConfig{UserName: "admin", Groups: []string{"system:masters"}}}
+	c, err := client.New(config, client.Options{Scheme: scheme.Scheme, Mapper: mapper})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+	key := client.ObjectKey{Namespace: "default", Name: "foo"}
+
+	alice := rest.ImpersonationConfig{UserName: "alice"}
+	if err := c.Get(ctx, key, &corev1.ConfigMap{}, client.Impersonate(alice)); err == nil {
+		t.Error("expected Get to reject the Impersonate option of a client whose config impersonates a user")
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	if err := client.WithImpersonation(c, alice).Create(ctx, cm); err == nil {
+		t.Error("expected WithImpersonation to be rejected for a client whose config impersonates a user")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 0 {
+		t.Errorf("expected rejected calls not to send requests, got %d", requests)
+	}
+}
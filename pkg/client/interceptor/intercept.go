@@ -2,11 +2,15 @@ package interceptor
 
 import (
 	"context"
+	"io"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/httpstream"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/remotecommand"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -135,6 +139,42 @@ type subResourceInterceptor struct {
 	funcs           Funcs
 }
 
+// PodLogs, temel istemci destekliyorsa client.StreamClient'i uygular.
+func (c interceptor) PodLogs(ctx context.Context, key client.ObjectKey, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+	sc, err := client.StreamClientFor(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return sc.PodLogs(ctx, key, opts)
+}
+
+// PodExec, temel istemci destekliyorsa client.StreamClient'i uygular.
+func (c interceptor) PodExec(key client.ObjectKey, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+	sc, err := client.StreamClientFor(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return sc.PodExec(key, opts)
+}
+
+// PodAttach, temel istemci destekliyorsa client.StreamClient'i uygular.
+func (c interceptor) PodAttach(key client.ObjectKey, opts *corev1.PodAttachOptions) (remotecommand.Executor, error) {
+	sc, err := client.StreamClientFor(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return sc.PodAttach(key, opts)
+}
+
+// PodPortForward, temel istemci destekliyorsa client.StreamClient'i uygular.
+func (c interceptor) PodPortForward(key client.ObjectKey) (httpstream.Dialer, error) {
+	sc, err := client.StreamClientFor(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return sc.PodPortForward(key)
+}
+
 var _ client.SubResourceClient = &subResourceInterceptor{}
 
 func (s subResourceInterceptor) Get(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
@@ -0,0 +1,57 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// QuorumReadTotal, önbellekten NotFound döndükten sonra API sunucusuna yapılan
+	// toplam canlı (quorum) okuma sayısını tutan bir prometheus sayaç metrikidir.
+	// result etiketi okumanın sonucunu ifade eder: found, not_found, too_old veya error.
+	QuorumReadTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_client_quorum_reads_total",
+		Help: "Her GVK için önbellekte bulunamayan nesneler için yapılan toplam canlı okuma sayısı",
+	}, []string{"group", "version", "kind", "result"})
+
+	// CoalescedReadTotal, aynı anda devam eden özdeş bir canlı okumayla birleştirildiği
+	// için API sunucusuna gönderilmeyen toplam Get ve List isteği sayısını tutan bir
+	// prometheus sayaç metrikidir. verb etiketi isteğin türünü ifade eder: get veya list.
+	CoalescedReadTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_client_coalesced_reads_total",
+		Help: "Her GVK için özdeş bir canlı okumayla birleştirilen toplam okuma sayısı",
+	}, []string{"group", "version", "kind", "verb"})
+
+	// RateLimiterWaitSeconds, denetleyici başına istemci oran sınırlayıcısının
+	// API sunucusuna gönderilen istekleri ne kadar süre beklettiğini tutan bir
+	// prometheus histogram metrikidir.
+	RateLimiterWaitSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "controller_runtime_client_rate_limiter_wait_seconds",
+		Help:    "Her denetleyici için isteklerin istemci oran sınırlayıcısında bekleme süresi (saniye)",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"controller"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		QuorumReadTotal,
+		CoalescedReadTotal,
+		RateLimiterWaitSeconds,
+	)
+}
@@ -431,6 +431,11 @@ type GetOptions struct {
 	// Raw represents raw GetOptions, as passed to the API server.  Note
 	// that these may not be respected by all implementations of interface.
 	Raw *metav1.GetOptions
+
+	// QuorumReadOnNotFound indicates whether a NotFound result from the
+	// cache should be retried with a live quorum read against the API server.
+	// If unset, the client's CacheOptions.QuorumReadOnNotFoundMaxAge applies.
+	QuorumReadOnNotFound *bool
 }
 
 var _ GetOption = &GetOptions{}
@@ -440,6 +445,9 @@ func (o *GetOptions) ApplyToGet(lo *GetOptions) {
 	if o.Raw != nil {
 		lo.Raw = o.Raw
 	}
+	if o.QuorumReadOnNotFound != nil {
+		lo.QuorumReadOnNotFound = o.QuorumReadOnNotFound
+	}
 }
 
 // AsGetOptions returns these options as a flattened metav1.GetOptions.
@@ -460,6 +468,21 @@ func (o *GetOptions) ApplyOptions(opts []GetOption) *GetOptions {
 	return o
 }
 
+// QuorumReadOnNotFoundOption indicates whether a NotFound result from the
+// cache should be retried with a live quorum read against the API server,
+// regardless of the age of the object. It has no effect on clients without a
+// cache or on objects that are not read from the cache.
+type QuorumReadOnNotFoundOption bool
+
+// ApplyToGet applies this configuration to the given get options.
+func (q QuorumReadOnNotFoundOption) ApplyToGet(opts *GetOptions) {
+	enabled := bool(q)
+	opts.QuorumReadOnNotFound = &enabled
+}
+
+// QuorumReadOnNotFound retries NotFound results from the cache with a live quorum read.
+const QuorumReadOnNotFound = QuorumReadOnNotFoundOption(true)
+
 // }}}
 
 // {{{ List Options
@@ -81,6 +81,15 @@ var _ = Describe("GetOptions", func() {
 		o.ApplyToGet(newGetOpts)
 		Expect(newGetOpts).To(Equal(o))
 	})
+	It("Should set QuorumReadOnNotFound", func() {
+		o := &client.GetOptions{QuorumReadOnNotFound: ptr.To(true)}
+		newGetOpts := &client.GetOptions{}
+		o.ApplyToGet(newGetOpts)
+		Expect(newGetOpts).To(Equal(o))
+		newGetOpts = &client.GetOptions{}
+		client.QuorumReadOnNotFound.ApplyToGet(newGetOpts)
+		Expect(newGetOpts).To(Equal(o))
+	})
 })
 
 var _ = Describe("CreateOptions", func() {
@@ -0,0 +1,52 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/internal/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/internal/controller/identity"
+)
+
+// controllerRateLimitingRoundTripper waits for the client rate limiter of the
+// controller whose reconcile issued a request, if it has one, before sending
+// the request. The rate limit of the rest.Config still applies on top of it.
+type controllerRateLimitingRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+var _ http.RoundTripper = &controllerRateLimitingRoundTripper{}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *controllerRateLimitingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	id, ok := identity.FromContext(req.Context())
+	if ok && id.ClientRateLimiter != nil {
+		start := time.Now()
+		if err := id.ClientRateLimiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+		metrics.RateLimiterWaitSeconds.WithLabelValues(id.Name).Observe(time.Since(start).Seconds())
+	}
+	return rt.delegate.RoundTrip(req)
+}
+
+// WrappedRoundTripper implements k8s.io/apimachinery/pkg/util/net.RoundTripperWrapper.
+func (rt *controllerRateLimitingRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return rt.delegate
+}
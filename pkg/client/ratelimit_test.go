@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/internal/controller/identity"
+)
+
+type countingRateLimiter struct {
+	flowcontrol.RateLimiter
+	waits atomic.Int32
+}
+
+func (l *countingRateLimiter) Wait(ctx context.Context) error {
+	l.waits.Add(1)
+	return l.RateLimiter.Wait(ctx)
+}
+
+func TestControllerClientRateLimiter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		})
+	}))
+	defer server.Close()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	c, err := client.New(&rest.Config{Host: server.URL}, client.Options{Scheme: scheme.Scheme, Mapper: mapper})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key := client.ObjectKey{Namespace: "default", Name: "foo"}
+
+	limiter := &countingRateLimiter{RateLimiter: flowcontrol.NewFakeAlwaysRateLimiter()}
+	ctx := identity.IntoContext(context.Background(), identity.Identity{Name: "noisy", ClientRateLimiter: limiter})
+	for range 3 {
+		if err := c.Get(ctx, key, &corev1.ConfigMap{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := c.Update(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if waits := limiter.waits.Load(); waits != 4 {
+		t.Errorf("expected 4 requests to wait for the controller's rate limiter, got %d", waits)
+	}
+
+	if err := c.Get(context.Background(), key, &corev1.ConfigMap{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if waits := limiter.waits.Load(); waits != 4 {
+		t.Errorf("expected requests outside of the controller not to wait, got %d waits", waits)
+	}
+
+}
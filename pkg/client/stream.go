@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// StreamClient streams the subresources of pods that aren't shaped like
+// objects and thus can't be used with SubResource, i.e. logs, exec, attach
+// and port-forward. Evictions are objects and are created with
+//
+//	c.SubResource("eviction").Create(ctx, pod, &policyv1.Eviction{})
+//
+// Clients created by New, unless they are dry run clients, and the fake
+// client implement StreamClient. Use StreamClientFor to get it.
+type StreamClient interface {
+	// PodLogs streams the logs of a container of the pod with the given key.
+	// The caller must close the returned stream.
+	PodLogs(ctx context.Context, key ObjectKey, opts *corev1.PodLogOptions) (io.ReadCloser, error)
+
+	// PodExec returns an executor that runs a command in a container of the
+	// pod with the given key once it's started with StreamWithContext.
+	PodExec(key ObjectKey, opts *corev1.PodExecOptions) (remotecommand.Executor, error)
+
+	// PodAttach returns an executor that attaches to a running container of
+	// the pod with the given key once it's started with StreamWithContext.
+	PodAttach(key ObjectKey, opts *corev1.PodAttachOptions) (remotecommand.Executor, error)
+
+	// PodPortForward returns a dialer to forward ports of the pod with the
+	// given key, for use with k8s.io/client-go/tools/portforward.New.
+	PodPortForward(key ObjectKey) (httpstream.Dialer, error)
+}
+
+// StreamClientFor returns the StreamClient of the given client, or an error
+// if it doesn't support streaming subresources.
+func StreamClientFor(c Client) (StreamClient, error) {
+	sc, ok := c.(StreamClient)
+	if !ok {
+		return nil, fmt.Errorf("client %T does not support streaming subresources", c)
+	}
+	return sc, nil
+}
+
+var _ StreamClient = &client{}
+
+// PodLogs implements StreamClient.
+func (c *client) PodLogs(ctx context.Context, key ObjectKey, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+	if opts == nil {
+		opts = &corev1.PodLogOptions{}
+	}
+	req, err := c.podRequest(http.MethodGet, key, "log")
+	if err != nil {
+		return nil, err
+	}
+	return req.VersionedParams(opts, scheme.ParameterCodec).Stream(ctx)
+}
+
+// PodExec implements StreamClient.
+func (c *client) PodExec(key ObjectKey, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+	req, err := c.podRequest(http.MethodPost, key, "exec")
+	if err != nil {
+		return nil, err
+	}
+	return c.podExecutor(req.VersionedParams(opts, scheme.ParameterCodec).URL())
+}
+
+// PodAttach implements StreamClient.
+func (c *client) PodAttach(key ObjectKey, opts *corev1.PodAttachOptions) (remotecommand.Executor, error) {
+	req, err := c.podRequest(http.MethodPost, key, "attach")
+	if err != nil {
+		return nil, err
+	}
+	return c.podExecutor(req.VersionedParams(opts, scheme.ParameterCodec).URL())
+}
+
+// PodPortForward implements StreamClient.
+func (c *client) PodPortForward(key ObjectKey) (httpstream.Dialer, error) {
+	req, err := c.podRequest(http.MethodPost, key, "portforward")
+	if err != nil {
+		return nil, err
+	}
+	u := req.URL()
+
+	transport, upgrader, err := spdy.RoundTripperFor(c.typedClient.resources.config)
+	if err != nil {
+		return nil, err
+	}
+	spdyDialer := spdy.NewDialer(upgrader, c.streamHTTPClient(transport), http.MethodPost, u)
+	// Prefer tunneling SPDY over WebSockets, which works through proxies
+	// that don't support SPDY, and fall back to SPDY for older API servers.
+	websocketDialer, err := portforward.NewSPDYOverWebsocketDialer(u, c.websocketConfig())
+	if err != nil {
+		return nil, err
+	}
+	return portforward.NewFallbackDialer(websocketDialer, spdyDialer, shouldFallBackToSPDY), nil
+}
+
+// podRequest returns a request for the subresource of the pod with the given
+// key, using the client's HTTP client and RESTMapper.
+func (c *client) podRequest(verb string, key ObjectKey, subResource string) (*rest.Request, error) {
+	r, err := c.typedClient.resources.getResource(&corev1.Pod{})
+	if err != nil {
+		return nil, err
+	}
+	return r.Verb(verb).
+		Namespace(key.Namespace).
+		Resource(r.resource()).
+		Name(key.Name).
+		SubResource(subResource), nil
+}
+
+// podExecutor returns an executor for the exec or attach subresource at u,
+// which uses WebSockets and falls back to SPDY for older API servers.
+func (c *client) podExecutor(u *url.URL) (remotecommand.Executor, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(c.typedClient.resources.config)
+	if err != nil {
+		return nil, err
+	}
+	spdyExecutor, err := remotecommand.NewSPDYExecutorForTransports(c.streamHTTPClient(transport).Transport, upgrader, http.MethodPost, u)
+	if err != nil {
+		return nil, err
+	}
+	websocketExecutor, err := remotecommand.NewWebSocketExecutor(c.websocketConfig(), http.MethodGet, u.String())
+	if err != nil {
+		return nil, err
+	}
+	return remotecommand.NewFallbackExecutor(websocketExecutor, spdyExecutor, shouldFallBackToSPDY)
+}
+
+// Upgraded connections are hijacked from the transport that dialed them, so
+// they can't be sent through the pooled transport of the client's HTTP client.
+// Instead, the upgrading transports are built from the client's rest.Config
+// and wrapped with the same round-trippers that New adds to the HTTP client,
+// which honor Impersonate options and the client rate limits of controllers.
+
+// streamHTTPClient returns a copy of the client's HTTP client that sends its
+// requests through the given upgrading transport.
+func (c *client) streamHTTPClient(transport http.RoundTripper) *http.Client {
+	httpClient := &http.Client{}
+	if c.typedClient.resources.httpClient != nil {
+		*httpClient = *c.typedClient.resources.httpClient
+		// Streams are long-lived, a timeout would cut them off.
+		httpClient.Timeout = 0
+	}
+	httpClient.Transport = wrapClientTransport(transport)
+	return httpClient
+}
+
+// websocketConfig returns the client's rest.Config with the round-trippers of
+// its HTTP client, as the WebSocket executor and dialer can only be built from
+// a rest.Config.
+func (c *client) websocketConfig() *rest.Config {
+	config := rest.CopyConfig(c.typedClient.resources.config)
+	config.Wrap(wrapClientTransport)
+	return config
+}
+
+func shouldFallBackToSPDY(err error) bool {
+	return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/internal/controller/identity"
+)
+
+func TestStreamClientPodLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/default/pods/foo/log" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		_, _ = io.WriteString(w, "logs of "+q.Get("container")+" tail "+q.Get("tailLines"))
+	}))
+	defer server.Close()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	c, err := client.New(&rest.Config{Host: server.URL}, client.Options{Scheme: scheme.Scheme, Mapper: mapper})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sc, err := client.StreamClientFor(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logs, err := sc.PodLogs(context.Background(), client.ObjectKey{Namespace: "default", Name: "foo"}, &corev1.PodLogOptions{
+		Container: "app",
+		TailLines: ptr.To[int64](10),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer logs.Close()
+	b, err := io.ReadAll(logs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := string(b), "logs of app tail 10"; got != want {
+		t.Errorf("expected logs %q, got %q", want, got)
+	}
+
+	if _, err := sc.PodExec(client.ObjectKey{Namespace: "default", Name: "foo"}, &corev1.PodExecOptions{Command: []string{"true"}}); err != nil {
+		t.Errorf("unexpected error creating executor: %v", err)
+	}
+	if _, err := sc.PodPortForward(client.ObjectKey{Namespace: "default", Name: "foo"}); err != nil {
+		t.Errorf("unexpected error creating dialer: %v", err)
+	}
+
+	if _, err := client.StreamClientFor(client.NewDryRunClient(c)); err == nil {
+		t.Error("expected dry run clients not to support streaming subresources")
+	}
+}
+
+func TestStreamClientRoundTrippers(t *testing.T) {
+	var execRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/namespaces/default/pods/foo/exec" && r.Header.Get("Impersonate-User") == "admin" {
+			execRequests.Add(1)
+		}
+		http.Error(w, "upgrades are not supported", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	config := &rest.Config{Host: server.URL, Impersonate: rest.ImpersonationConfig{UserName: "admin"}}
+	c, err := client.New(config, client.Options{Scheme: scheme.Scheme, Mapper: mapper})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sc, err := client.StreamClientFor(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exec, err := sc.PodExec(client.ObjectKey{Namespace: "default", Name: "foo"}, &corev1.PodExecOptions{Command: []string{"true"}, Stdout: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	limiter := &countingRateLimiter{RateLimiter: flowcontrol.NewFakeAlwaysRateLimiter()}
+	ctx := identity.IntoContext(context.Background(), identity.Identity{Name: "exec", ClientRateLimiter: limiter})
+	if err := exec.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: io.Discard}); err == nil {
+		t.Fatal("expected the upgrade to fail")
+	}
+	if execRequests.Load() == 0 {
+		t.Error("expected the exec request to reach the server with the impersonation headers of the config")
+	}
+	if waits := limiter.waits.Load(); waits != execRequests.Load() {
+		t.Errorf("expected all %d exec requests to wait for the controller's rate limiter, got %d waits", execRequests.Load(), waits)
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"sigs.k8s.io/controller-runtime/pkg/internal/tracing"
+)
+
+// newTracingClient wraps c so that every call made within a span, e.g. a
+// reconcile, creates a child span. Calls outside a span are not traced.
+func newTracingClient(c Client) Client {
+	return &tracingClient{c: c}
+}
+
+type tracingClient struct {
+	c Client
+}
+
+// newTracingWithWatchClient is like newTracingClient, but also traces the
+// start of watches.
+func newTracingWithWatchClient(c WithWatch) WithWatch {
+	return &tracingWithWatchClient{tracingClient: &tracingClient{c: c}, w: c}
+}
+
+type tracingWithWatchClient struct {
+	*tracingClient
+	w WithWatch
+}
+
+var (
+	_ Client       = &tracingClient{}
+	_ StreamClient = &tracingClient{}
+	_ WithWatch    = &tracingWithWatchClient{}
+)
+
+// start starts a span for a call with the given verb on obj, if ctx contains a span.
+func start(ctx context.Context, c Client, verb string, obj runtime.Object, namespace, name, subResource string) (context.Context, trace.Span) {
+	tracer := tracing.TracerFromContext(ctx)
+	ctx, span := tracer.Start(ctx, "client."+verb, trace.WithSpanKind(trace.SpanKindClient))
+	if !span.IsRecording() {
+		return ctx, span
+	}
+	attrs := []attribute.KeyValue{attribute.String("k8s.verb", verb)}
+	if gvk, err := c.GroupVersionKindFor(obj); err == nil {
+		if meta.IsListType(obj) {
+			gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+		}
+		attrs = append(attrs, tracing.GVKAttributes(gvk)...)
+	}
+	if namespace != "" {
+		attrs = append(attrs, tracing.NamespaceKey.String(namespace))
+	}
+	if name != "" {
+		attrs = append(attrs, tracing.NameKey.String(name))
+	}
+	if subResource != "" {
+		attrs = append(attrs, attribute.String("k8s.subresource", subResource))
+	}
+	span.SetAttributes(attrs...)
+	return ctx, span
+}
+
+func (t *tracingClient) Get(ctx context.Context, key ObjectKey, obj Object, opts ...GetOption) error {
+	spanCtx, span := start(ctx, t.c, "get", obj, key.Namespace, key.Name, "")
+	err := t.c.Get(spanCtx, key, obj, opts...)
+	if err == nil && span.IsRecording() {
+		tracing.LinkObject(obj, span, trace.SpanFromContext(ctx))
+	}
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracingClient) List(ctx context.Context, list ObjectList, opts ...ListOption) error {
+	listOpts := (&ListOptions{}).ApplyOptions(opts)
+	ctx, span := start(ctx, t.c, "list", list, listOpts.Namespace, "", "")
+	err := t.c.List(ctx, list, opts...)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracingWithWatchClient) Watch(ctx context.Context, list ObjectList, opts ...ListOption) (watch.Interface, error) {
+	listOpts := (&ListOptions{}).ApplyOptions(opts)
+	ctx, span := start(ctx, t.c, "watch", list, listOpts.Namespace, "", "")
+	w, err := t.w.Watch(ctx, list, opts...)
+	tracing.End(span, err)
+	return w, err
+}
+
+func (t *tracingClient) Create(ctx context.Context, obj Object, opts ...CreateOption) error {
+	ctx, span := start(ctx, t.c, "create", obj, obj.GetNamespace(), obj.GetName(), "")
+	err := t.c.Create(ctx, obj, opts...)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracingClient) Update(ctx context.Context, obj Object, opts ...UpdateOption) error {
+	ctx, span := start(ctx, t.c, "update", obj, obj.GetNamespace(), obj.GetName(), "")
+	err := t.c.Update(ctx, obj, opts...)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracingClient) Patch(ctx context.Context, obj Object, patch Patch, opts ...PatchOption) error {
+	ctx, span := start(ctx, t.c, "patch", obj, obj.GetNamespace(), obj.GetName(), "")
+	err := t.c.Patch(ctx, obj, patch, opts...)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracingClient) Delete(ctx context.Context, obj Object, opts ...DeleteOption) error {
+	ctx, span := start(ctx, t.c, "delete", obj, obj.GetNamespace(), obj.GetName(), "")
+	err := t.c.Delete(ctx, obj, opts...)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracingClient) DeleteAllOf(ctx context.Context, obj Object, opts ...DeleteAllOfOption) error {
+	deleteAllOfOpts := (&DeleteAllOfOptions{}).ApplyOptions(opts)
+	ctx, span := start(ctx, t.c, "deletecollection", obj, deleteAllOfOpts.Namespace, "", "")
+	err := t.c.DeleteAllOf(ctx, obj, opts...)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracingClient) Status() SubResourceWriter {
+	return t.SubResource("status")
+}
+
+func (t *tracingClient) SubResource(subResource string) SubResourceClient {
+	return &tracingSubResourceClient{c: t.c, subResourceClient: t.c.SubResource(subResource), subResource: subResource}
+}
+
+// PodLogs implements StreamClient if the wrapped client does.
+func (t *tracingClient) PodLogs(ctx context.Context, key ObjectKey, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+	sc, err := StreamClientFor(t.c)
+	if err != nil {
+		return nil, err
+	}
+	ctx, span := start(ctx, t.c, "get", &corev1.Pod{}, key.Namespace, key.Name, "log")
+	logs, err := sc.PodLogs(ctx, key, opts)
+	tracing.End(span, err)
+	return logs, err
+}
+
+// PodExec implements StreamClient if the wrapped client does.
+func (t *tracingClient) PodExec(key ObjectKey, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+	sc, err := StreamClientFor(t.c)
+	if err != nil {
+		return nil, err
+	}
+	return sc.PodExec(key, opts)
+}
+
+// PodAttach implements StreamClient if the wrapped client does.
+func (t *tracingClient) PodAttach(key ObjectKey, opts *corev1.PodAttachOptions) (remotecommand.Executor, error) {
+	sc, err := StreamClientFor(t.c)
+	if err != nil {
+		return nil, err
+	}
+	return sc.PodAttach(key, opts)
+}
+
+// PodPortForward implements StreamClient if the wrapped client does.
+func (t *tracingClient) PodPortForward(key ObjectKey) (httpstream.Dialer, error) {
+	sc, err := StreamClientFor(t.c)
+	if err != nil {
+		return nil, err
+	}
+	return sc.PodPortForward(key)
+}
+
+func (t *tracingClient) Scheme() *runtime.Scheme     { return t.c.Scheme() }
+func (t *tracingClient) RESTMapper() meta.RESTMapper { return t.c.RESTMapper() }
+func (t *tracingClient) GroupVersionKindFor(obj runtime.Object) (schema.GroupVersionKind, error) {
+	return t.c.GroupVersionKindFor(obj)
+}
+func (t *tracingClient) IsObjectNamespaced(obj runtime.Object) (bool, error) {
+	return t.c.IsObjectNamespaced(obj)
+}
+
+type tracingSubResourceClient struct {
+	c                 Client
+	subResourceClient SubResourceClient
+	subResource       string
+}
+
+func (t *tracingSubResourceClient) Get(ctx context.Context, obj Object, subResource Object, opts ...SubResourceGetOption) error {
+	ctx, span := start(ctx, t.c, "get", obj, obj.GetNamespace(), obj.GetName(), t.subResource)
+	err := t.subResourceClient.Get(ctx, obj, subResource, opts...)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracingSubResourceClient) Create(ctx context.Context, obj Object, subResource Object, opts ...SubResourceCreateOption) error {
+	ctx, span := start(ctx, t.c, "create", obj, obj.GetNamespace(), obj.GetName(), t.subResource)
+	err := t.subResourceClient.Create(ctx, obj, subResource, opts...)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracingSubResourceClient) Update(ctx context.Context, obj Object, opts ...SubResourceUpdateOption) error {
+	ctx, span := start(ctx, t.c, "update", obj, obj.GetNamespace(), obj.GetName(), t.subResource)
+	err := t.subResourceClient.Update(ctx, obj, opts...)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracingSubResourceClient) Patch(ctx context.Context, obj Object, patch Patch, opts ...SubResourcePatchOption) error {
+	ctx, span := start(ctx, t.c, "patch", obj, obj.GetNamespace(), obj.GetName(), t.subResource)
+	err := t.subResourceClient.Patch(ctx, obj, patch, opts...)
+	tracing.End(span, err)
+	return err
+}
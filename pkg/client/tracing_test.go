@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/tracing"
+)
+
+type stubClient struct {
+	Client
+	get func(obj Object) error
+}
+
+func (s *stubClient) Get(_ context.Context, _ ObjectKey, obj Object, _ ...GetOption) error {
+	return s.get(obj)
+}
+
+func (s *stubClient) GroupVersionKindFor(obj runtime.Object) (schema.GroupVersionKind, error) {
+	return apiutil.GVKForObject(obj, scheme.Scheme)
+}
+
+func TestTracingClient(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	origin, originSpan := tp.Tracer("test").Start(context.Background(), "origin")
+	originSpan.End()
+	stub := &stubClient{get: func(obj Object) error {
+		if !tracing.InjectIntoObject(origin, obj) {
+			t.Fatal("expected trace context to be injected")
+		}
+		return nil
+	}}
+	c := newTracingClient(stub)
+	key := ObjectKey{Namespace: "default", Name: "foo"}
+
+	if err := c.Get(context.Background(), key, &corev1.Pod{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(recorder.Ended()); n != 1 {
+		t.Fatalf("expected no span outside of a span, got %d spans", n-1)
+	}
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "reconcile")
+	if err := c.Get(ctx, key, &corev1.Pod{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stub.get = func(Object) error { return errors.New("boom") }
+	if err := c.Get(ctx, key, &corev1.Pod{}); err == nil {
+		t.Fatal("expected an error")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+	get := spans[1]
+	if get.Name() != "client.get" || get.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("expected client.get child span of the reconcile, got %q with parent %s", get.Name(), get.Parent().SpanID())
+	}
+	attrs := map[string]string{}
+	for _, attr := range get.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	for k, v := range map[string]string{"k8s.verb": "get", "k8s.kind": "Pod", "k8s.version": "v1", "k8s.namespace": "default", "k8s.name": "foo"} {
+		if attrs[k] != v {
+			t.Errorf("expected attribute %s=%s, got %q", k, v, attrs[k])
+		}
+	}
+	if len(get.Links()) != 1 || get.Links()[0].SpanContext.SpanID() != originSpan.SpanContext().SpanID() {
+		t.Errorf("expected get span to be linked to the object's trace context, got %v", get.Links())
+	}
+	if len(spans[3].Links()) != 1 {
+		t.Errorf("expected parent span to be linked to the object's trace context, got %v", spans[3].Links())
+	}
+	if failed := spans[2]; failed.Status().Description != "boom" {
+		t.Errorf("expected failed get to record the error, got status %v", failed.Status())
+	}
+}
+
+type stubWatchClient struct {
+	stubClient
+}
+
+func (s *stubWatchClient) Watch(context.Context, ObjectList, ...ListOption) (watch.Interface, error) {
+	return watch.NewEmptyWatch(), nil
+}
+
+func TestTracingWithWatchClient(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	c := newTracingWithWatchClient(&stubWatchClient{})
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "reconcile")
+	w, err := c.Watch(ctx, &corev1.PodList{}, InNamespace("default"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.Stop()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	attrs := map[string]string{}
+	for _, attr := range spans[0].Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if spans[0].Name() != "client.watch" || attrs["k8s.kind"] != "Pod" || attrs["k8s.namespace"] != "default" {
+		t.Errorf("expected client.watch span for pods in default, got %q with attributes %v", spans[0].Name(), attrs)
+	}
+}
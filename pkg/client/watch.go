@@ -31,7 +31,7 @@ func NewWithWatch(config *rest.Config, options Options) (WithWatch, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &izlemeClient{client: client}, nil
+	return newTracingWithWatchClient(&izlemeClient{client: client}), nil
 }
 
 type izlemeClient struct {
@@ -19,6 +19,7 @@ package cluster
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/go-logr/logr"
@@ -247,6 +248,25 @@ func New(config *rest.Config, opts ...Option) (Cluster, error) {
 	}, nil
 }
 
+// NewImpersonatingClient returns a client for the cluster that impersonates
+// the given user, groups, UID and extra attributes in all of its requests,
+// e.g. to act on behalf of a tenant. It reads from the API server instead of
+// the cache, so that all requests are authorized as the impersonated user.
+// The client wraps the API reader of the cluster and shares its HTTP client,
+// and with it the connections, as well as its RESTMapper and scheme, so it is
+// cheap to create.
+//
+// It returns an error if the API reader of the cluster isn't a client.Client,
+// which is always the case for clusters and managers created by this module.
+// Its requests fail if the Config of the cluster impersonates a user itself.
+func NewImpersonatingClient(c Cluster, config rest.ImpersonationConfig) (client.Client, error) {
+	apiClient, ok := c.GetAPIReader().(client.Client)
+	if !ok {
+		return nil, fmt.Errorf("the API reader of the cluster is a %T, not a client.Client", c.GetAPIReader())
+	}
+	return client.WithImpersonation(apiClient, config), nil
+}
+
 // setOptionsDefaults set default values for Options fields.
 func setOptionsDefaults(options Options, config *rest.Config) (Options, error) {
 	if options.HTTPClient == nil {
@@ -167,4 +167,11 @@ var _ = Describe("cluster.Cluster", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(c.GetAPIReader()).NotTo(BeNil())
 	})
+	It("should provide a function to get an impersonating Client", func() {
+		c, err := New(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		ic, err := NewImpersonatingClient(c, rest.ImpersonationConfig{UserName: "tenant"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ic).NotTo(BeNil())
+	})
 })
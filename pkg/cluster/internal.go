@@ -38,7 +38,7 @@ type cluster struct {
 	scheme           *runtime.Scheme     // Şema
 	cache            cache.Cache         // Önbellek
 	client           client.Client       // İstemci
-	apiReader        client.Reader       // API sunucusuna istek yapacak okuyucu, önbelleğe değil.
+	apiReader        client.Client       // API sunucusuna istek yapacak okuyucu, önbelleğe değil.
 	fieldIndexes     client.FieldIndexer // Alan dizinleyici
 	recorderProvider *intrec.Provider    // Olay kaydedici sağlayıcı
 	mapper           meta.RESTMapper     // Kaynakları tür ve sürüme göre eşleyen haritalayıcı
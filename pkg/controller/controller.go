@@ -22,6 +22,8 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
@@ -78,6 +80,21 @@ type TypedOptions[request comparable] struct {
 
 	// LogConstructor, bu denetleyici için kullanılan bir logger oluşturmak ve her reconcile işlemine context alanı aracılığıyla geçirmek için kullanılır.
 	LogConstructor func(request *request) logr.Logger
+
+	// TracerProvider, her reconcile işlemi için bir span oluşturmak için kullanılır.
+	// Reconcile context'i ile yapılan istemci çağrıları bu span'ın alt span'larını oluşturur.
+	// Ayarlanmamışsa, Yöneticinin TracerProvider'ına varsayılan olarak ayarlanır.
+	TracerProvider trace.TracerProvider
+
+	// ClientRateLimiter, bu denetleyicinin reconcile işlemleri sırasında istemcilerin
+	// API sunucusuna gönderdiği istekleri sınırlar, örneğin flowcontrol.NewTokenBucketRateLimiter ile.
+	// Böylece gürültülü bir denetleyici, diğer denetleyicilerin istek bütçesini tüketemez.
+	// Yalnızca reconcile context'i ile client.New ile oluşturulmuş istemcilerden yapılan
+	// istekler sınırlanır ve rest.Config'in QPS ve Burst sınırları da geçerli olmaya devam eder.
+	// Sınırlayıcıda bekleme süresi controller_runtime_client_rate_limiter_wait_seconds
+	// metriğinde denetleyici başına raporlanır.
+	// Ayarlanmamışsa, istekler denetleyici başına sınırlanmaz.
+	ClientRateLimiter flowcontrol.RateLimiter
 }
 
 // Controller bir Kubernetes API'sini uygular. Bir Controller, source.Sources'dan gelen reconcile.Request'leri besleyen bir iş kuyruğunu yönetir.
@@ -203,6 +220,10 @@ func NewTypedUnmanaged[request comparable](name string, mgr manager.Manager, opt
 		options.NeedLeaderElection = mgr.GetControllerOptions().NeedLeaderElection
 	}
 
+	if options.TracerProvider == nil {
+		options.TracerProvider = mgr.GetTracerProvider()
+	}
+
 	// Bağımlılıkları ayarlanmış denetleyici oluştur
 	return &controller.Controller[request]{
 		Do:                      options.Reconciler,
@@ -214,8 +235,13 @@ func NewTypedUnmanaged[request comparable](name string, mgr manager.Manager, opt
 		LogConstructor:          options.LogConstructor,
 		RecoverPanic:            options.RecoverPanic,
 		LeaderElected:           options.NeedLeaderElection,
+		TracerProvider:          options.TracerProvider,
+		ClientRateLimiter:       options.ClientRateLimiter,
 	}, nil
 }
 
 // ReconcileIDFromContext, geçerli context'ten reconcileID'yi alır.
 var ReconcileIDFromContext = controller.ReconcileIDFromContext
+
+// NameFromContext, geçerli context'in ait olduğu reconcile işlemini yürüten denetleyicinin adını alır.
+var NameFromContext = controller.NameFromContext
@@ -0,0 +1,769 @@
+/*
+2024 Kubernetes Yazarları.
+
+Apache Lisansı, Sürüm 2.0 ("Lisans") uyarınca lisanslanmıştır;
+bu dosyayı yalnızca Lisans uyarınca kullanabilirsiniz.
+Lisansın bir kopyasını aşağıdaki adreste bulabilirsiniz:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Yürürlükteki yasa veya yazılı izin gereği aksi belirtilmedikçe,
+Lisans kapsamında dağıtılan yazılım "OLDUĞU GİBİ" dağıtılır,
+HERHANGİ BİR GARANTİ VEYA KOŞUL OLMAKSIZIN, açık veya zımni.
+Lisans kapsamında izin verilen belirli dil kapsamındaki
+haklar ve sınırlamalar için Lisansa bakınız.
+*/
+
+package controllertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Driver, reconcile.Request kullanan bir uzlaştırıcıyı adım adım çalıştıran bir TypedDriver'dır.
+type Driver = TypedDriver[reconcile.Request]
+
+// TypedDriver, bir reconcile.TypedReconciler'ı sahte bir istemciye karşı deterministik olarak adım adım çalıştırır.
+//
+// Olaylar, builder'ın yapılandıracağı aynı işleyici ve koşul zincirinden geçirilir ve sonuçta oluşan
+// istekler, sahte bir saat kullanan bir kuyruğa eklenir. Hiçbir şey arka planda çalışmaz: uzlaştırmalar
+// yalnızca Step veya RunUntilIdle çağrıldığında yapılır ve RequeueAfter ile geciktirilen istekler
+// yalnızca saat Advance ile ilerletildiğinde hazır olur.
+//
+// Olaylar, Client tarafından döndürülen istemci üzerinden yapılan yazmalardan senkronize olarak üretilir.
+// Uzlaştırıcının yazmalarının da olay üretmesi için bu istemciyi kullanması gerekir. Çöp toplama gibi
+// istemcinin yan etkileri olay üretmez.
+type TypedDriver[request comparable] struct {
+	client  client.WithWatch
+	wrapped client.WithWatch
+	queue   *driverQueue[request]
+
+	forObject        client.Object
+	forPredicates    []predicate.Predicate
+	owns             []driverInput
+	watches          []driverWatch[request]
+	globalPredicates []predicate.Predicate
+
+	reconciler reconcile.TypedReconciler[request]
+	handlers   []driverHandler[request]
+
+	reconciles []TypedReconcileRecord[request]
+	writes     []Write
+	// current, şu anda çalışan uzlaştırmanın kaydıdır.
+	current *TypedReconcileRecord[request]
+}
+
+type driverInput struct {
+	object     client.Object
+	predicates []predicate.Predicate
+}
+
+type driverWatch[request comparable] struct {
+	object     client.Object
+	handler    handler.TypedEventHandler[client.Object, request]
+	predicates []predicate.Predicate
+}
+
+type driverHandler[request comparable] struct {
+	object     client.Object
+	gvk        schema.GroupVersionKind
+	handler    handler.TypedEventHandler[client.Object, request]
+	predicates []predicate.Predicate
+}
+
+// Aşağıdaki yöntemler, bilgilendiricilerin olay işleyicileri gibi önce koşulları değerlendirir
+// ve yalnızca hepsi sağlanırsa işleyiciyi çağırır.
+
+func (h driverHandler[request]) create(ctx context.Context, q workqueue.TypedRateLimitingInterface[request], obj client.Object) {
+	evt := event.CreateEvent{Object: obj}
+	if !slices.ContainsFunc(h.predicates, func(p predicate.Predicate) bool { return !p.Create(evt) }) {
+		h.handler.Create(ctx, evt, q)
+	}
+}
+
+func (h driverHandler[request]) update(ctx context.Context, q workqueue.TypedRateLimitingInterface[request], old, cur client.Object) {
+	evt := event.UpdateEvent{ObjectOld: old, ObjectNew: cur}
+	if !slices.ContainsFunc(h.predicates, func(p predicate.Predicate) bool { return !p.Update(evt) }) {
+		h.handler.Update(ctx, evt, q)
+	}
+}
+
+func (h driverHandler[request]) delete(ctx context.Context, q workqueue.TypedRateLimitingInterface[request], obj client.Object) {
+	evt := event.DeleteEvent{Object: obj}
+	if !slices.ContainsFunc(h.predicates, func(p predicate.Predicate) bool { return !p.Delete(evt) }) {
+		h.handler.Delete(ctx, evt, q)
+	}
+}
+
+func (h driverHandler[request]) generic(ctx context.Context, q workqueue.TypedRateLimitingInterface[request], obj client.Object) {
+	evt := event.GenericEvent{Object: obj}
+	if !slices.ContainsFunc(h.predicates, func(p predicate.Predicate) bool { return !p.Generic(evt) }) {
+		h.handler.Generic(ctx, evt, q)
+	}
+}
+
+// TypedReconcileRecord, tek bir uzlaştırmanın kaydıdır.
+type TypedReconcileRecord[request comparable] struct {
+	// Request, uzlaştırılan istektir.
+	Request request
+	// Result, uzlaştırıcının döndürdüğü sonuçtur.
+	Result reconcile.Result
+	// Err, uzlaştırıcının döndürdüğü hatadır.
+	Err error
+	// Writes, uzlaştırma sırasında yapılan API yazmalarıdır.
+	Writes []Write
+}
+
+// ReconcileRecord, reconcile.Request için bir TypedReconcileRecord'dur.
+type ReconcileRecord = TypedReconcileRecord[reconcile.Request]
+
+// Write, TypedDriver'ın istemcisi üzerinden yapılan bir API yazmasıdır.
+type Write struct {
+	// Verb, yazmanın türüdür: create, update, patch, delete veya deletecollection.
+	Verb string
+	// SubResource, yazma bir alt kaynağa yapıldıysa alt kaynağın adıdır, örneğin status.
+	SubResource string
+	// GroupVersionKind, yazılan nesnenin türüdür.
+	GroupVersionKind schema.GroupVersionKind
+	// Key, yazılan nesnenin anahtarıdır. deletecollection için boştur.
+	Key client.ObjectKey
+	// Object, yazmadan sonraki nesnenin bir kopyasıdır.
+	Object client.Object
+	// Err, yazmanın döndürdüğü hatadır.
+	Err error
+}
+
+// DelayedRequest, kuyrukta hazır olmasını bekleyen bir istektir.
+type DelayedRequest[request comparable] struct {
+	// Request, geciktirilen istektir.
+	Request request
+	// ReadyAt, isteğin hazır olacağı zamandır.
+	ReadyAt time.Time
+}
+
+// NewDriver, verilen sahte istemciyi kullanan yeni bir Driver döndürür.
+func NewDriver(c client.WithWatch) *Driver {
+	return NewTypedDriver[reconcile.Request](c)
+}
+
+// NewTypedDriver, verilen sahte istemciyi kullanan yeni bir TypedDriver döndürür.
+func NewTypedDriver[request comparable](c client.WithWatch) *TypedDriver[request] {
+	d := &TypedDriver[request]{
+		client: c,
+		queue: &driverQueue[request]{
+			clock:       clocktesting.NewFakeClock(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)),
+			rateLimiter: workqueue.NewTypedItemExponentialFailureRateLimiter[request](5*time.Millisecond, 1000*time.Second),
+			waiting:     map[request]time.Time{},
+		},
+	}
+	d.wrapped = interceptor.NewClient(c, interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			return d.write(ctx, "create", "", obj, func() error { return c.Create(ctx, obj, opts...) })
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			return d.write(ctx, "update", "", obj, func() error { return c.Update(ctx, obj, opts...) })
+		},
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			return d.write(ctx, "patch", "", obj, func() error { return c.Patch(ctx, obj, patch, opts...) })
+		},
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			return d.write(ctx, "delete", "", obj, func() error { return c.Delete(ctx, obj, opts...) })
+		},
+		DeleteAllOf: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteAllOfOption) error {
+			return d.deleteAllOf(ctx, obj, func() error { return c.DeleteAllOf(ctx, obj, opts...) })
+		},
+		SubResourceCreate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+			return d.write(ctx, "create", subResourceName, obj, func() error {
+				return c.SubResource(subResourceName).Create(ctx, obj, subResource, opts...)
+			})
+		},
+		SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			return d.write(ctx, "update", subResourceName, obj, func() error {
+				return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+			})
+		},
+		SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+			return d.write(ctx, "patch", subResourceName, obj, func() error {
+				return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+			})
+		},
+	})
+	return d
+}
+
+// For, builder.For gibi, verilen türdeki nesnelerin olaylarının nesnenin kendisi için bir istek eklemesini sağlar.
+// Yalnızca reconcile.Request ile kullanılabilir.
+func (d *TypedDriver[request]) For(obj client.Object, predicates ...predicate.Predicate) *TypedDriver[request] {
+	d.forObject = obj
+	d.forPredicates = predicates
+	return d
+}
+
+// Owns, builder.Owns gibi, verilen türdeki nesnelerin olaylarının For ile verilen türdeki denetleyici
+// sahipleri için bir istek eklemesini sağlar.
+func (d *TypedDriver[request]) Owns(obj client.Object, predicates ...predicate.Predicate) *TypedDriver[request] {
+	d.owns = append(d.owns, driverInput{object: obj, predicates: predicates})
+	return d
+}
+
+// Watches, builder.Watches gibi, verilen türdeki nesnelerin olaylarını verilen işleyiciye iletir.
+func (d *TypedDriver[request]) Watches(obj client.Object, h handler.TypedEventHandler[client.Object, request], predicates ...predicate.Predicate) *TypedDriver[request] {
+	d.watches = append(d.watches, driverWatch[request]{object: obj, handler: h, predicates: predicates})
+	return d
+}
+
+// WithEventFilter, builder.WithEventFilter gibi, tüm izlemelerin olaylarını filtreleyen bir koşul ekler.
+func (d *TypedDriver[request]) WithEventFilter(p predicate.Predicate) *TypedDriver[request] {
+	d.globalPredicates = append(d.globalPredicates, p)
+	return d
+}
+
+// Complete, izlemeleri builder'ın yapacağı gibi kurar ve uzlaştırıcıyı ayarlar.
+func (d *TypedDriver[request]) Complete(r reconcile.TypedReconciler[request]) error {
+	if r == nil {
+		return errors.New("must provide a non-nil Reconciler")
+	}
+	if d.forObject != nil {
+		hdler, ok := any(&handler.EnqueueRequestForObject{}).(handler.TypedEventHandler[client.Object, request])
+		if !ok {
+			return fmt.Errorf("For() can only be used with reconcile.Request, got %T", *new(request))
+		}
+		if err := d.addHandler(d.forObject, hdler, d.forPredicates); err != nil {
+			return err
+		}
+	}
+
+	if len(d.owns) > 0 && d.forObject == nil {
+		return errors.New("Owns() can only be used together with For()")
+	}
+	// Sahte istemcinin RESTMapper'ı yalnızca kayıtlı türleri bilir, bu nedenle sahiplerin kapsamı
+	// gerektiğinde şemadan tahmin edilir.
+	mapper := meta.MultiRESTMapper{d.client.RESTMapper(), testrestmapper.TestOnlyStaticRESTMapper(d.client.Scheme())}
+	for _, own := range d.owns {
+		hdler, ok := handler.EnqueueRequestForOwner(
+			d.client.Scheme(), mapper, d.forObject, handler.OnlyControllerOwner(),
+		).(handler.TypedEventHandler[client.Object, request])
+		if !ok {
+			return fmt.Errorf("Owns() can only be used with reconcile.Request, got %T", *new(request))
+		}
+		if err := d.addHandler(own.object, hdler, own.predicates); err != nil {
+			return err
+		}
+	}
+
+	if len(d.watches) == 0 && d.forObject == nil {
+		return errors.New("there are no watches configured, controller will never get triggered. Use For(), Owns() or Watches() to set them up")
+	}
+	for _, w := range d.watches {
+		if err := d.addHandler(w.object, w.handler, w.predicates); err != nil {
+			return err
+		}
+	}
+
+	d.reconciler = r
+	return nil
+}
+
+func (d *TypedDriver[request]) addHandler(obj client.Object, h handler.TypedEventHandler[client.Object, request], predicates []predicate.Predicate) error {
+	gvk, err := apiutil.GVKForObject(obj, d.client.Scheme())
+	if err != nil {
+		return err
+	}
+	allPredicates := append([]predicate.Predicate(nil), d.globalPredicates...)
+	allPredicates = append(allPredicates, predicates...)
+	d.handlers = append(d.handlers, driverHandler[request]{
+		object:     obj,
+		gvk:        gvk,
+		handler:    h,
+		predicates: allPredicates,
+	})
+	return nil
+}
+
+// Client, yazmaları kaydeden ve izlenen türler için olay üreten istemciyi döndürür.
+func (d *TypedDriver[request]) Client() client.WithWatch {
+	return d.wrapped
+}
+
+// Clock, kuyruğun kullandığı sahte saati döndürür.
+func (d *TypedDriver[request]) Clock() *clocktesting.FakeClock {
+	return d.queue.clock
+}
+
+// Advance, sahte saati verilen süre kadar ilerletir. Zamanı gelen geciktirilmiş istekler hazır olur.
+func (d *TypedDriver[request]) Advance(duration time.Duration) {
+	d.queue.clock.Step(duration)
+}
+
+// Sync, bir bilgilendiricinin ilk listelemesi gibi, izlenen türlerin mevcut tüm nesneleri için
+// oluşturma olayları üretir. Sahte istemci oluşturulurken eklenen nesneler için kullanışlıdır.
+func (d *TypedDriver[request]) Sync(ctx context.Context) error {
+	for _, h := range d.handlers {
+		list, err := d.newList(h)
+		if err != nil {
+			return err
+		}
+		if err := d.client.List(ctx, list); err != nil {
+			return err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			obj, ok := item.(client.Object)
+			if !ok {
+				return fmt.Errorf("list item %T is not a client.Object", item)
+			}
+			if _, isUnstructured := obj.(*unstructured.Unstructured); isUnstructured {
+				obj.GetObjectKind().SetGroupVersionKind(h.gvk)
+			}
+			h.create(ctx, d.queue, obj)
+		}
+	}
+	return nil
+}
+
+// Generic, verilen nesne için, türünü izleyen işleyicilere genel bir olay gönderir.
+func (d *TypedDriver[request]) Generic(ctx context.Context, obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, d.client.Scheme())
+	if err != nil {
+		return err
+	}
+	for _, h := range d.handlers {
+		if h.gvk != gvk {
+			continue
+		}
+		h.generic(ctx, d.queue, obj)
+	}
+	return nil
+}
+
+// Enqueue, verilen isteği doğrudan kuyruğa ekler.
+func (d *TypedDriver[request]) Enqueue(req request) {
+	d.queue.Add(req)
+}
+
+// Queue, hazır olan istekleri uzlaştırılacakları sırayla döndürür.
+func (d *TypedDriver[request]) Queue() []request {
+	d.queue.promote()
+	return slices.Clone(d.queue.ready)
+}
+
+// Delayed, henüz hazır olmayan istekleri hazır olacakları sırayla döndürür.
+func (d *TypedDriver[request]) Delayed() []DelayedRequest[request] {
+	d.queue.promote()
+	delayed := make([]DelayedRequest[request], 0, len(d.queue.waiting))
+	for _, req := range d.queue.waitingOrder() {
+		delayed = append(delayed, DelayedRequest[request]{Request: req, ReadyAt: d.queue.waiting[req]})
+	}
+	return delayed
+}
+
+// Step, kuyruktaki ilk hazır isteği uzlaştırır ve sonucu bir denetleyicinin yapacağı gibi işler.
+// Hazır istek yoksa false döndürür.
+func (d *TypedDriver[request]) Step(ctx context.Context) (TypedReconcileRecord[request], bool) {
+	if d.reconciler == nil {
+		panic("Complete must be called before Step")
+	}
+	d.queue.promote()
+	if len(d.queue.ready) == 0 {
+		return TypedReconcileRecord[request]{}, false
+	}
+	req := d.queue.ready[0]
+	d.queue.ready = d.queue.ready[1:]
+
+	record := TypedReconcileRecord[request]{Request: req}
+	d.current = &record
+	record.Result, record.Err = d.reconciler.Reconcile(ctx, req)
+	d.current = nil
+
+	switch {
+	case record.Err != nil:
+		if !errors.Is(record.Err, reconcile.TerminalError(nil)) {
+			d.queue.AddRateLimited(req)
+		}
+	case record.Result.RequeueAfter > 0:
+		d.queue.Forget(req)
+		d.queue.AddAfter(req, record.Result.RequeueAfter)
+	case record.Result.Requeue:
+		d.queue.AddRateLimited(req)
+	default:
+		d.queue.Forget(req)
+	}
+
+	d.reconciles = append(d.reconciles, record)
+	return record, true
+}
+
+// RunUntilIdle, hazır istek kalmayana kadar Step'i çağırır ve yapılan uzlaştırmaları döndürür.
+// maxSteps uzlaştırmadan sonra hala hazır istek varsa, sonsuz döngüleri yakalamak için bir hata döndürür.
+func (d *TypedDriver[request]) RunUntilIdle(ctx context.Context, maxSteps int) ([]TypedReconcileRecord[request], error) {
+	var records []TypedReconcileRecord[request]
+	for range maxSteps {
+		record, ok := d.Step(ctx)
+		if !ok {
+			return records, nil
+		}
+		records = append(records, record)
+	}
+	if len(d.Queue()) > 0 {
+		return records, fmt.Errorf("queue is not idle after %d reconciles", maxSteps)
+	}
+	return records, nil
+}
+
+// Reconciles, şimdiye kadar yapılan tüm uzlaştırmaları döndürür.
+func (d *TypedDriver[request]) Reconciles() []TypedReconcileRecord[request] {
+	return slices.Clone(d.reconciles)
+}
+
+// Writes, istemci üzerinden şimdiye kadar yapılan tüm yazmaları döndürür.
+func (d *TypedDriver[request]) Writes() []Write {
+	return slices.Clone(d.writes)
+}
+
+// write, fn ile yapılan yazmayı kaydeder ve yazılan nesnenin türünü izleyen işleyicilere
+// yazmadan önceki ve sonraki durumuna göre olaylar gönderir.
+func (d *TypedDriver[request]) write(ctx context.Context, verb, subResource string, obj client.Object, fn func() error) error {
+	gvk, err := apiutil.GVKForObject(obj, d.client.Scheme())
+	if err != nil {
+		return err
+	}
+	before, err := d.snapshot(ctx, gvk, client.ObjectKeyFromObject(obj))
+	if err != nil {
+		return err
+	}
+
+	writeErr := fn()
+	d.record(Write{
+		Verb:             verb,
+		SubResource:      subResource,
+		GroupVersionKind: gvk,
+		Key:              client.ObjectKeyFromObject(obj),
+		Object:           obj.DeepCopyObject().(client.Object),
+		Err:              writeErr,
+	})
+	if writeErr != nil {
+		return writeErr
+	}
+
+	after, err := d.snapshot(ctx, gvk, client.ObjectKeyFromObject(obj))
+	if err != nil {
+		return err
+	}
+	d.dispatch(ctx, gvk, before, after)
+	return nil
+}
+
+func (d *TypedDriver[request]) deleteAllOf(ctx context.Context, obj client.Object, fn func() error) error {
+	gvk, err := apiutil.GVKForObject(obj, d.client.Scheme())
+	if err != nil {
+		return err
+	}
+	// Silinen nesneleri bulmak için, izlenen türün tüm nesneleri yazmadan önce ve sonra listelenir.
+	before, err := d.snapshotAll(ctx, gvk)
+	if err != nil {
+		return err
+	}
+
+	writeErr := fn()
+	d.record(Write{
+		Verb:             "deletecollection",
+		GroupVersionKind: gvk,
+		Object:           obj.DeepCopyObject().(client.Object),
+		Err:              writeErr,
+	})
+	if writeErr != nil {
+		return writeErr
+	}
+
+	after, err := d.snapshotAll(ctx, gvk)
+	if err != nil {
+		return err
+	}
+	for key, objs := range before {
+		d.dispatch(ctx, gvk, objs, after[key])
+	}
+	return nil
+}
+
+func (d *TypedDriver[request]) record(w Write) {
+	d.writes = append(d.writes, w)
+	if d.current != nil {
+		d.current.Writes = append(d.current.Writes, w)
+	}
+}
+
+// snapshot, verilen türü izleyen her işleyici için nesneyi işleyicinin nesne tipinde okur.
+// Nesne yoksa işleyicinin girdisi nil'dir.
+func (d *TypedDriver[request]) snapshot(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) ([]client.Object, error) {
+	objs := make([]client.Object, len(d.handlers))
+	if key.Name == "" {
+		return objs, nil
+	}
+	for i, h := range d.handlers {
+		if h.gvk != gvk {
+			continue
+		}
+		obj := d.newObject(h)
+		if err := d.client.Get(ctx, key, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		objs[i] = obj
+	}
+	return objs, nil
+}
+
+// snapshotAll, verilen türün tüm nesneleri için snapshot'ı anahtarlarına göre döndürür.
+func (d *TypedDriver[request]) snapshotAll(ctx context.Context, gvk schema.GroupVersionKind) (map[client.ObjectKey][]client.Object, error) {
+	res := map[client.ObjectKey][]client.Object{}
+	for i, h := range d.handlers {
+		if h.gvk != gvk {
+			continue
+		}
+		list, err := d.newList(h)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.client.List(ctx, list); err != nil {
+			return nil, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			obj, ok := item.(client.Object)
+			if !ok {
+				return nil, fmt.Errorf("list item %T is not a client.Object", item)
+			}
+			if _, isUnstructured := obj.(*unstructured.Unstructured); isUnstructured {
+				obj.GetObjectKind().SetGroupVersionKind(gvk)
+			}
+			key := client.ObjectKeyFromObject(obj)
+			if res[key] == nil {
+				res[key] = make([]client.Object, len(d.handlers))
+			}
+			res[key][i] = obj
+		}
+	}
+	return res, nil
+}
+
+// dispatch, her işleyiciye nesnenin önceki ve sonraki durumuna karşılık gelen olayı gönderir.
+func (d *TypedDriver[request]) dispatch(ctx context.Context, gvk schema.GroupVersionKind, before, after []client.Object) {
+	for i, h := range d.handlers {
+		if h.gvk != gvk {
+			continue
+		}
+		var old, cur client.Object
+		if i < len(before) {
+			old = before[i]
+		}
+		if i < len(after) {
+			cur = after[i]
+		}
+		switch {
+		case old == nil && cur != nil:
+			h.create(ctx, d.queue, cur)
+		case old != nil && cur != nil:
+			// API sunucusu, hiçbir şeyi değiştirmeyen yazmalar için olay göndermez.
+			if old.GetResourceVersion() != cur.GetResourceVersion() {
+				h.update(ctx, d.queue, old, cur)
+			}
+		case old != nil && cur == nil:
+			h.delete(ctx, d.queue, old)
+		}
+	}
+}
+
+// newObject, işleyicinin izlediği nesneyle aynı tipte boş bir nesne döndürür.
+func (d *TypedDriver[request]) newObject(h driverHandler[request]) client.Object {
+	switch h.object.(type) {
+	case *unstructured.Unstructured:
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(h.gvk)
+		return u
+	case *metav1.PartialObjectMetadata:
+		p := &metav1.PartialObjectMetadata{}
+		p.SetGroupVersionKind(h.gvk)
+		return p
+	default:
+		return reflect.New(reflect.TypeOf(h.object).Elem()).Interface().(client.Object)
+	}
+}
+
+// newList, işleyicinin izlediği nesnelerin listesi için boş bir liste döndürür.
+func (d *TypedDriver[request]) newList(h driverHandler[request]) (client.ObjectList, error) {
+	listGVK := h.gvk.GroupVersion().WithKind(h.gvk.Kind + "List")
+	switch h.object.(type) {
+	case *unstructured.Unstructured:
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(listGVK)
+		return list, nil
+	case *metav1.PartialObjectMetadata:
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(listGVK)
+		return list, nil
+	default:
+		obj, err := d.client.Scheme().New(listGVK)
+		if err != nil {
+			return nil, err
+		}
+		list, ok := obj.(client.ObjectList)
+		if !ok {
+			return nil, fmt.Errorf("%T is not a client.ObjectList", obj)
+		}
+		return list, nil
+	}
+}
+
+var _ workqueue.TypedRateLimitingInterface[reconcile.Request] = &driverQueue[reconcile.Request]{}
+
+// driverQueue, sahte bir saat kullanan ve hiçbir zaman engellemeyen bir oran sınırlama kuyruğudur.
+type driverQueue[request comparable] struct {
+	clock       *clocktesting.FakeClock
+	rateLimiter workqueue.TypedRateLimiter[request]
+	ready       []request
+	waiting     map[request]time.Time
+	// added, geciktirilen isteklerin eklenme sırasıdır, aynı zamanda hazır olan istekleri
+	// eklenme sıralarına göre kuyruğa almak için kullanılır.
+	added        map[request]int
+	addedCounter int
+	shutdown     bool
+}
+
+// Add, TypedRateLimitingInterface'i uygular.
+func (q *driverQueue[request]) Add(item request) {
+	if q.shutdown || slices.Contains(q.ready, item) {
+		return
+	}
+	q.ready = append(q.ready, item)
+}
+
+// AddAfter, TypedRateLimitingInterface'i uygular.
+func (q *driverQueue[request]) AddAfter(item request, duration time.Duration) {
+	if q.shutdown {
+		return
+	}
+	if duration <= 0 {
+		q.Add(item)
+		return
+	}
+	readyAt := q.clock.Now().Add(duration)
+	if existing, ok := q.waiting[item]; ok && !readyAt.Before(existing) {
+		return
+	}
+	q.waiting[item] = readyAt
+	if q.added == nil {
+		q.added = map[request]int{}
+	}
+	q.addedCounter++
+	q.added[item] = q.addedCounter
+}
+
+// AddRateLimited, TypedRateLimitingInterface'i uygular.
+func (q *driverQueue[request]) AddRateLimited(item request) {
+	q.AddAfter(item, q.rateLimiter.When(item))
+}
+
+// Forget, TypedRateLimitingInterface'i uygular.
+func (q *driverQueue[request]) Forget(item request) {
+	q.rateLimiter.Forget(item)
+}
+
+// NumRequeues, TypedRateLimitingInterface'i uygular.
+func (q *driverQueue[request]) NumRequeues(item request) int {
+	return q.rateLimiter.NumRequeues(item)
+}
+
+// Len, TypedRateLimitingInterface'i uygular.
+func (q *driverQueue[request]) Len() int {
+	q.promote()
+	return len(q.ready)
+}
+
+// Get, TypedRateLimitingInterface'i uygular. Engellemez: hazır istek yoksa kapanmış gibi davranır.
+func (q *driverQueue[request]) Get() (request, bool) {
+	q.promote()
+	if len(q.ready) == 0 {
+		return *new(request), true
+	}
+	item := q.ready[0]
+	q.ready = q.ready[1:]
+	return item, false
+}
+
+// Done, TypedRateLimitingInterface'i uygular.
+func (q *driverQueue[request]) Done(item request) {}
+
+// ShutDown, TypedRateLimitingInterface'i uygular.
+func (q *driverQueue[request]) ShutDown() {
+	q.shutdown = true
+}
+
+// ShutDownWithDrain, TypedRateLimitingInterface'i uygular.
+func (q *driverQueue[request]) ShutDownWithDrain() {
+	q.shutdown = true
+}
+
+// ShuttingDown, TypedRateLimitingInterface'i uygular.
+func (q *driverQueue[request]) ShuttingDown() bool {
+	return q.shutdown
+}
+
+// promote, zamanı gelen geciktirilmiş istekleri hazır olacakları sırayla kuyruğa alır.
+func (q *driverQueue[request]) promote() {
+	now := q.clock.Now()
+	for _, item := range q.waitingOrder() {
+		if q.waiting[item].After(now) {
+			break
+		}
+		delete(q.waiting, item)
+		delete(q.added, item)
+		if !slices.Contains(q.ready, item) {
+			q.ready = append(q.ready, item)
+		}
+	}
+}
+
+// waitingOrder, geciktirilmiş istekleri hazır olacakları zamana, sonra eklenme sıralarına göre döndürür.
+func (q *driverQueue[request]) waitingOrder() []request {
+	items := make([]request, 0, len(q.waiting))
+	for item := range q.waiting {
+		items = append(items, item)
+	}
+	slices.SortFunc(items, func(a, b request) int {
+		if c := q.waiting[a].Compare(q.waiting[b]); c != 0 {
+			return c
+		}
+		return q.added[a] - q.added[b]
+	})
+	return items
+}
@@ -0,0 +1,161 @@
+/*
+2024 Kubernetes Yazarları.
+
+Apache Lisansı, Sürüm 2.0 ("Lisans") uyarınca lisanslanmıştır;
+bu dosyayı yalnızca Lisans uyarınca kullanabilirsiniz.
+Lisansın bir kopyasını aşağıdaki adreste bulabilirsiniz:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Yürürlükteki yasa veya yazılı izin gereği aksi belirtilmedikçe,
+Lisans kapsamında dağıtılan yazılım "OLDUĞU GİBİ" dağıtılır,
+HERHANGİ BİR GARANTİ VEYA KOŞUL OLMAKSIZIN, açık veya zımni.
+Lisans kapsamında izin verilen belirli dil kapsamındaki
+haklar ve sınırlamalar için Lisansa bakınız.
+*/
+
+package controllertest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllertest"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// podCreator, her ReplicaSet için sahip olunan tek bir Pod oluşturur ve bir dakika sonra tekrar uzlaştırır.
+type podCreator struct {
+	client client.Client
+	err    error
+}
+
+func (r *podCreator) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	if r.err != nil {
+		return reconcile.Result{}, r.err
+	}
+	rs := &appsv1.ReplicaSet{}
+	if err := r.client.Get(ctx, req.NamespacedName, rs); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: rs.Namespace, Name: rs.Name}}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.client, pod, func() error {
+		return controllerutil.SetControllerReference(rs, pod, r.client.Scheme())
+	})
+	return reconcile.Result{RequeueAfter: time.Minute}, err
+}
+
+func TestDriver(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "rs"}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "rs"}}
+	d := controllertest.NewDriver(fake.NewClientBuilder().WithObjects(rs).Build())
+	r := &podCreator{client: d.Client()}
+	g.Expect(d.For(&appsv1.ReplicaSet{}).Owns(&corev1.Pod{}).Complete(r)).To(Succeed())
+
+	// Sahte istemci oluşturulurken eklenen nesneler yalnızca Sync ile olay üretir.
+	g.Expect(d.Queue()).To(BeEmpty())
+	g.Expect(d.Sync(ctx)).To(Succeed())
+	g.Expect(d.Queue()).To(Equal([]reconcile.Request{req}))
+
+	// İlk uzlaştırma Pod'u oluşturur ve Pod'un oluşturma olayı ReplicaSet'i tekrar kuyruğa ekler.
+	record, ok := d.Step(ctx)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(record.Request).To(Equal(req))
+	g.Expect(record.Err).NotTo(HaveOccurred())
+	g.Expect(record.Writes).To(HaveLen(1))
+	g.Expect(record.Writes[0].Verb).To(Equal("create"))
+	g.Expect(record.Writes[0].GroupVersionKind.Kind).To(Equal("Pod"))
+	g.Expect(record.Writes[0].Key).To(Equal(req.NamespacedName))
+	g.Expect(d.Queue()).To(Equal([]reconcile.Request{req}))
+
+	// İkinci uzlaştırma hiçbir şey yazmaz, bu nedenle kuyrukta yalnızca RequeueAfter kalır.
+	records, err := d.RunUntilIdle(ctx, 10)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(records).To(HaveLen(1))
+	g.Expect(records[0].Writes).To(BeEmpty())
+	g.Expect(d.Queue()).To(BeEmpty())
+	g.Expect(d.Delayed()).To(Equal([]controllertest.DelayedRequest[reconcile.Request]{
+		{Request: req, ReadyAt: d.Clock().Now().Add(time.Minute)},
+	}))
+
+	d.Advance(59 * time.Second)
+	g.Expect(d.Queue()).To(BeEmpty())
+	d.Advance(time.Second)
+	g.Expect(d.Queue()).To(Equal([]reconcile.Request{req}))
+	_, ok = d.Step(ctx)
+	g.Expect(ok).To(BeTrue())
+	_, ok = d.Step(ctx)
+	g.Expect(ok).To(BeFalse())
+
+	// Hatalar oran sınırlamasıyla tekrar kuyruğa eklenir, terminal hatalar eklenmez.
+	r.err = errors.New("boom")
+	d.Enqueue(req)
+	_, ok = d.Step(ctx)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(d.Delayed()).To(ContainElement(controllertest.DelayedRequest[reconcile.Request]{
+		Request: req, ReadyAt: d.Clock().Now().Add(5 * time.Millisecond),
+	}))
+	d.Advance(5 * time.Millisecond)
+	r.err = reconcile.TerminalError(errors.New("boom"))
+	record, _ = d.Step(ctx)
+	g.Expect(record.Err).To(MatchError(ContainSubstring("boom")))
+	g.Expect(d.Queue()).To(BeEmpty())
+
+	g.Expect(d.Reconciles()).To(HaveLen(5))
+	g.Expect(d.Writes()).To(HaveLen(1))
+}
+
+func TestDriverPredicates(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	d := controllertest.NewDriver(fake.NewClientBuilder().Build())
+	g.Expect(d.
+		For(&appsv1.ReplicaSet{}, predicate.GenerationChangedPredicate{}).
+		Complete(reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+			return reconcile.Result{}, nil
+		}))).To(Succeed())
+
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "rs"}}
+	g.Expect(d.Client().Create(ctx, rs)).To(Succeed())
+	g.Expect(d.Queue()).To(HaveLen(1))
+	_, err := d.RunUntilIdle(ctx, 10)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Etiket değişiklikleri nesilleri değiştirmez ve koşul tarafından filtrelenir.
+	rs.Labels = map[string]string{"a": "b"}
+	g.Expect(d.Client().Update(ctx, rs)).To(Succeed())
+	g.Expect(d.Queue()).To(BeEmpty())
+
+	g.Expect(d.Client().Delete(ctx, rs)).To(Succeed())
+	g.Expect(d.Queue()).To(HaveLen(1))
+
+	g.Expect(d.Generic(ctx, rs)).To(Succeed())
+	g.Expect(d.Queue()).To(HaveLen(1))
+}
+
+func TestTypedDriverForRequiresRequest(t *testing.T) {
+	g := NewWithT(t)
+
+	// For ve Owns, istekleri reconcile.Request olarak oluşturan işleyiciler kullanır.
+	d := controllertest.NewTypedDriver[string](fake.NewClientBuilder().Build())
+	err := d.For(&appsv1.ReplicaSet{}).Complete(reconcile.TypedFunc[string](func(context.Context, string) (reconcile.Result, error) {
+		return reconcile.Result{}, nil
+	}))
+	g.Expect(err).To(MatchError(ContainSubstring("For() can only be used with reconcile.Request")))
+}
@@ -27,6 +27,8 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/ptr"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -466,6 +468,91 @@ func mutate(f MutateFn, key client.ObjectKey, obj client.Object) error {
 // MutateFn is a function which mutates the existing object into its desired state.
 type MutateFn func() error
 
+// RetryOption allows to modify how UpdateWithRetry and UpdateStatusWithRetry retry on conflicts.
+type RetryOption func(*wait.Backoff)
+
+// WithRetryBackoff sets the backoff between attempts. Its Steps field is the
+// maximum number of attempts. Defaults to retry.DefaultRetry.
+func WithRetryBackoff(backoff wait.Backoff) RetryOption {
+	return func(b *wait.Backoff) {
+		*b = backoff
+	}
+}
+
+// UpdateWithRetry applies the MutateFn to the given object and updates it in
+// the Kubernetes cluster, if the MutateFn changed it. If the update fails with
+// a conflict, the object is re-read from apiReader, the MutateFn is applied
+// again and the update is retried with backoff. apiReader should read from the
+// API server directly, e.g. manager.GetAPIReader(), as a cache-backed reader
+// might return the same stale object again.
+//
+// The first attempt uses the object as passed in. The MutateFn must thus be
+// idempotent and derive its changes from the object's current state.
+//
+// It returns the executed operation, the number of attempts and an error.
+//
+// Note: changes made by MutateFn to any sub-resource (status...), will be
+// discarded. Use UpdateStatusWithRetry to update the status.
+func UpdateWithRetry(ctx context.Context, c client.Client, apiReader client.Reader, obj client.Object, f MutateFn, opts ...RetryOption) (OperationResult, int, error) {
+	return updateWithRetry(ctx, apiReader, obj, f, func(ctx context.Context, obj client.Object) error {
+		return c.Update(ctx, obj)
+	}, OperationResultUpdated, opts)
+}
+
+// UpdateStatusWithRetry is like UpdateWithRetry, but updates the status
+// subresource of the object.
+//
+// It returns the executed operation, the number of attempts and an error.
+func UpdateStatusWithRetry(ctx context.Context, c client.Client, apiReader client.Reader, obj client.Object, f MutateFn, opts ...RetryOption) (OperationResult, int, error) {
+	return updateWithRetry(ctx, apiReader, obj, f, func(ctx context.Context, obj client.Object) error {
+		return c.Status().Update(ctx, obj)
+	}, OperationResultUpdatedStatusOnly, opts)
+}
+
+func updateWithRetry(
+	ctx context.Context,
+	apiReader client.Reader,
+	obj client.Object,
+	f MutateFn,
+	update func(context.Context, client.Object) error,
+	updated OperationResult,
+	opts []RetryOption,
+) (OperationResult, int, error) {
+	backoff := retry.DefaultRetry
+	for _, opt := range opts {
+		opt(&backoff)
+	}
+
+	key := client.ObjectKeyFromObject(obj)
+	result := OperationResultNone
+	attempts := 0
+	err := retry.RetryOnConflict(backoff, func() error {
+		attempts++
+		if attempts > 1 {
+			if err := apiReader.Get(ctx, key, obj); err != nil {
+				return err
+			}
+		}
+		existing := obj.DeepCopyObject()
+		if err := mutate(f, key, obj); err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(existing, obj) {
+			result = OperationResultNone
+			return nil
+		}
+		if err := update(ctx, obj); err != nil {
+			return err
+		}
+		result = updated
+		return nil
+	})
+	if err != nil {
+		return OperationResultNone, attempts, err
+	}
+	return result, attempts, nil
+}
+
 // AddFinalizer accepts an Object and adds the provided finalizer if not present.
 // It returns an indication of whether it updated the object's list of finalizers.
 func AddFinalizer(o client.Object, finalizer string) (finalizersUpdated bool) {
@@ -26,9 +26,11 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -856,6 +858,91 @@ var _ = Describe("Controllerutil", func() {
 		})
 	})
 
+	Describe("UpdateWithRetry", func() {
+		var deploy *appsv1.Deployment
+		var bumpAnnotation controllerutil.MutateFn
+
+		BeforeEach(func() {
+			deploy = &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("deploy-%d", rand.Int31()), //nolint:gosec
+					Namespace: "default",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"foo": "bar"}},
+						Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "busybox", Image: "busybox"}}},
+					},
+				},
+			}
+			Expect(c.Create(context.TODO(), deploy)).To(Succeed())
+			bumpAnnotation = func() error {
+				if deploy.Annotations == nil {
+					deploy.Annotations = map[string]string{}
+				}
+				deploy.Annotations["bump"] = "true"
+				return nil
+			}
+		})
+
+		It("updates the object in a single attempt", func() {
+			op, attempts, err := controllerutil.UpdateWithRetry(context.TODO(), c, c, deploy, bumpAnnotation)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(op).To(BeEquivalentTo(controllerutil.OperationResultUpdated))
+			Expect(attempts).To(Equal(1))
+		})
+
+		It("re-reads the object and retries on conflict", func() {
+			stale := deploy.DeepCopy()
+			deploy.Labels = map[string]string{"changed": "elsewhere"}
+			Expect(c.Update(context.TODO(), deploy)).To(Succeed())
+
+			op, attempts, err := controllerutil.UpdateWithRetry(context.TODO(), c, c, stale, func() error {
+				deploy = stale
+				return bumpAnnotation()
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(op).To(BeEquivalentTo(controllerutil.OperationResultUpdated))
+			Expect(attempts).To(Equal(2))
+
+			fetched := &appsv1.Deployment{}
+			Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(stale), fetched)).To(Succeed())
+			Expect(fetched.Labels).To(HaveKeyWithValue("changed", "elsewhere"))
+			Expect(fetched.Annotations).To(HaveKeyWithValue("bump", "true"))
+		})
+
+		It("gives up after the configured number of attempts", func() {
+			stale := deploy.DeepCopy()
+			deploy.Labels = map[string]string{"changed": "elsewhere"}
+			Expect(c.Update(context.TODO(), deploy)).To(Succeed())
+
+			_, attempts, err := controllerutil.UpdateWithRetry(context.TODO(), c, staleReader{stale.DeepCopy()}, stale, func() error {
+				deploy = stale
+				return bumpAnnotation()
+			}, controllerutil.WithRetryBackoff(wait.Backoff{Steps: 3}))
+			Expect(apierrors.IsConflict(err)).To(BeTrue())
+			Expect(attempts).To(Equal(3))
+		})
+
+		It("doesn't update unchanged objects", func() {
+			op, attempts, err := controllerutil.UpdateWithRetry(context.TODO(), c, c, deploy, func() error { return nil })
+			Expect(err).NotTo(HaveOccurred())
+			Expect(op).To(BeEquivalentTo(controllerutil.OperationResultNone))
+			Expect(attempts).To(Equal(1))
+		})
+
+		It("updates the status subresource", func() {
+			op, attempts, err := controllerutil.UpdateStatusWithRetry(context.TODO(), c, c, deploy, func() error {
+				deploy.Status.ObservedGeneration = 42
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(op).To(BeEquivalentTo(controllerutil.OperationResultUpdatedStatusOnly))
+			Expect(attempts).To(Equal(1))
+		})
+	})
+
 	Describe("Finalizers", func() {
 		var deploy *appsv1.Deployment
 
@@ -1049,6 +1136,19 @@ func deploymentScaler(deploy *appsv1.Deployment, replicas int32) controllerutil.
 	return fn
 }
 
+type staleReader struct {
+	deploy *appsv1.Deployment
+}
+
+func (s staleReader) Get(ctx context.Context, key client.ObjectKey, into client.Object, opts ...client.GetOption) error {
+	s.deploy.DeepCopyInto(into.(*appsv1.Deployment))
+	return nil
+}
+
+func (s staleReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return fmt.Errorf("unexpected list")
+}
+
 type errorReader struct {
 	client.Client
 }
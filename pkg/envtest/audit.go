@@ -0,0 +1,225 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envtest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AuditLogOptions are the options for the audit logging of the API server.
+type AuditLogOptions struct {
+	// Level is the level of the events logged with the generated policy,
+	// which logs all requests once they completed.  Defaults to Metadata,
+	// use RequestResponse to also log the request and response bodies.
+	Level auditv1.Level
+
+	// Policy, if set, is used instead of the generated policy.
+	Policy *auditv1.Policy
+}
+
+// AuditLog reads the audit events the API server of an Environment logs.
+// The API server logs an event once it has sent its response, so tests
+// usually need to poll for the events of the requests they made, e.g. with
+// the helpers of the komega package.
+type AuditLog struct {
+	// path is the path of the log file.
+	path string
+	// dir contains the policy and the log file, if they were created by an
+	// Environment.
+	dir string
+
+	mu sync.Mutex
+	// offset is the position in the log file after which events are returned.
+	offset int64
+}
+
+// AuditEventFilter selects audit events.
+type AuditEventFilter func(*auditv1.Event) bool
+
+// AuditByUser selects the events of requests made by or on behalf of the
+// user with the given name.
+func AuditByUser(name string) AuditEventFilter {
+	return func(e *auditv1.Event) bool {
+		return e.User.Username == name || (e.ImpersonatedUser != nil && e.ImpersonatedUser.Username == name)
+	}
+}
+
+// AuditByVerb selects the events of requests with one of the given verbs,
+// e.g. "create", "update", "patch", "delete", "get", "list" or "watch".
+func AuditByVerb(verbs ...string) AuditEventFilter {
+	return func(e *auditv1.Event) bool {
+		return slices.Contains(verbs, e.Verb)
+	}
+}
+
+// AuditByResource selects the events of requests to the given resource or its
+// subresources.
+func AuditByResource(gr schema.GroupResource) AuditEventFilter {
+	return func(e *auditv1.Event) bool {
+		return e.ObjectRef != nil && e.ObjectRef.APIGroup == gr.Group && e.ObjectRef.Resource == gr.Resource
+	}
+}
+
+// AuditByObject selects the events of requests to objects with the namespace
+// and name of obj.  Combine it with AuditByResource to only select the events
+// of a single object.
+func AuditByObject(obj client.Object) AuditEventFilter {
+	return func(e *auditv1.Event) bool {
+		return e.ObjectRef != nil && e.ObjectRef.Namespace == obj.GetNamespace() && e.ObjectRef.Name == obj.GetName()
+	}
+}
+
+// NewAuditLog returns an AuditLog reading the audit log file at the given
+// path, which must use the JSON format, e.g. one written by the API server of
+// an existing cluster.
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// newAuditLog creates the directory of the audit log, writes the policy to it,
+// and configures the API server to use them.
+func newAuditLog(opts *AuditLogOptions, apiServer *APIServer) (*AuditLog, error) {
+	policy := opts.Policy
+	if policy == nil {
+		level := opts.Level
+		if level == "" {
+			level = auditv1.LevelMetadata
+		}
+		policy = &auditv1.Policy{
+			OmitStages: []auditv1.Stage{auditv1.StageRequestReceived},
+			Rules:      []auditv1.PolicyRule{{Level: level}},
+		}
+	}
+	policy = policy.DeepCopy()
+	policy.TypeMeta = metav1.TypeMeta{APIVersion: auditv1.SchemeGroupVersion.String(), Kind: "Policy"}
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode audit policy: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "envtest-audit-")
+	if err != nil {
+		return nil, err
+	}
+	l := &AuditLog{path: filepath.Join(dir, "audit.log"), dir: dir}
+	if err := os.WriteFile(l.policyPath(), data, 0600); err != nil {
+		return nil, fmt.Errorf("unable to write audit policy: %w", err)
+	}
+
+	apiServer.Configure().
+		Set("audit-policy-file", l.policyPath()).
+		Set("audit-log-path", l.Path()).
+		Set("audit-log-format", "json").
+		Set("audit-log-mode", "blocking").
+		Set("audit-log-maxsize", "0")
+	return l, nil
+}
+
+func (l *AuditLog) policyPath() string {
+	return filepath.Join(l.dir, "policy.json")
+}
+
+// Path returns the path of the audit log file.
+func (l *AuditLog) Path() string {
+	return l.path
+}
+
+// Events returns the events logged so far that are selected by all filters,
+// ignoring the events logged before the last call to Reset.
+func (l *AuditLog) Events(filters ...AuditEventFilter) ([]auditv1.Event, error) {
+	l.mu.Lock()
+	offset := l.offset
+	l.mu.Unlock()
+
+	f, err := os.Open(l.Path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var events []auditv1.Event
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// ignore a partially written event, it'll be read by the next call.
+			return events, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		event := auditv1.Event{}
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, fmt.Errorf("unable to decode audit event: %w", err)
+		}
+		if matchesAll(&event, filters) {
+			events = append(events, event)
+		}
+	}
+}
+
+// Reset makes Events ignore the events logged so far, e.g. between tests.
+func (l *AuditLog) Reset() error {
+	info, err := os.Stat(l.Path())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.offset = info.Size()
+	return nil
+}
+
+// cleanup removes the audit log and its policy, if they were created by an
+// Environment.
+func (l *AuditLog) cleanup() error {
+	if l.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(l.dir)
+}
+
+func matchesAll(e *auditv1.Event, filters []AuditEventFilter) bool {
+	for _, filter := range filters {
+		if !filter(e) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+/*
+2024 Kubernetes Yazarları.
+
+Apache Lisansı, Sürüm 2.0 ("Lisans") uyarınca lisanslanmıştır;
+bu dosyayı yalnızca Lisans uyarınca kullanabilirsiniz.
+Lisansın bir kopyasını aşağıdaki adreste bulabilirsiniz:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Yürürlükteki yasa veya yazılı izin gereği aksi belirtilmedikçe,
+Lisans kapsamında dağıtılan yazılım "OLDUĞU GİBİ" dağıtılır,
+HERHANGİ BİR GARANTİ VEYA KOŞUL OLMAKSIZIN, açık veya zımni.
+Lisans kapsamında izin verilen belirli dil kapsamındaki
+haklar ve sınırlamalar için Lisansa bakınız.
+*/
+
+package envtest
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	authnv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestAuditLog(t *testing.T) {
+	g := NewWithT(t)
+
+	apiServer := &APIServer{}
+	l, err := newAuditLog(&AuditLogOptions{Level: auditv1.LevelRequestResponse}, apiServer)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer func() {
+		g.Expect(l.cleanup()).To(Succeed())
+		g.Expect(l.Path()).NotTo(BeAnExistingFile())
+	}()
+
+	// Oluşturulan ilke yalnızca tamamlanan istekleri verilen düzeyde günlüğe yazar.
+	g.Expect(apiServer.Configure().Get("audit-log-path").Get(nil)).To(Equal([]string{l.Path()}))
+	data, err := os.ReadFile(apiServer.Configure().Get("audit-policy-file").Get(nil)[0])
+	g.Expect(err).NotTo(HaveOccurred())
+	policy := &auditv1.Policy{}
+	g.Expect(json.Unmarshal(data, policy)).To(Succeed())
+	g.Expect(policy.Kind).To(Equal("Policy"))
+	g.Expect(policy.OmitStages).To(ConsistOf(auditv1.StageRequestReceived))
+	g.Expect(policy.Rules).To(Equal([]auditv1.PolicyRule{{Level: auditv1.LevelRequestResponse}}))
+
+	// Günlük dosyası henüz yokken olay yoktur.
+	g.Expect(l.Events()).To(BeEmpty())
+
+	writeEvents := func(events ...auditv1.Event) {
+		f, err := os.OpenFile(l.Path(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		g.Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+		for _, e := range events {
+			g.Expect(json.NewEncoder(f).Encode(e)).To(Succeed())
+		}
+	}
+	writeEvents(
+		auditv1.Event{
+			Verb:      "create",
+			User:      authnv1.UserInfo{Username: "admin"},
+			ObjectRef: &auditv1.ObjectReference{Resource: "configmaps", Namespace: "default", Name: "a"},
+		},
+		auditv1.Event{
+			Verb:             "delete",
+			User:             authnv1.UserInfo{Username: "admin"},
+			ImpersonatedUser: &authnv1.UserInfo{Username: "controller"},
+			ObjectRef:        &auditv1.ObjectReference{APIGroup: "apps", Resource: "deployments", Namespace: "default", Name: "a"},
+		},
+	)
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a"}}
+
+	g.Expect(l.Events()).To(HaveLen(2))
+	g.Expect(l.Events(AuditByUser("controller"))).To(ConsistOf(HaveField("Verb", "delete")))
+	g.Expect(l.Events(AuditByVerb("create", "update"))).To(ConsistOf(HaveField("Verb", "create")))
+	g.Expect(l.Events(AuditByResource(schema.GroupResource{Resource: "configmaps"}), AuditByObject(cm))).To(HaveLen(1))
+	g.Expect(l.Events(AuditByResource(schema.GroupResource{Group: "apps", Resource: "deployments"}), AuditByVerb("create"))).To(BeEmpty())
+
+	// Reset, o ana kadar yazılan olayları yok sayar.
+	g.Expect(l.Reset()).To(Succeed())
+	g.Expect(l.Events()).To(BeEmpty())
+	writeEvents(auditv1.Event{Verb: "get", User: authnv1.UserInfo{Username: "admin"}})
+	g.Expect(l.Events()).To(ConsistOf(HaveField("Verb", "get")))
+}
@@ -115,6 +115,17 @@ func InstallCRDs(config *rest.Config, options CRDInstallOptions) ([]*apiextensio
 	return options.CRDs, nil
 }
 
+// ReadCRDs returns the CRDs in options.CRDs along with the ones read from
+// options.Paths, without installing them. This allows to use the same
+// manifests as the test environment elsewhere, e.g. with the fake client.
+func ReadCRDs(options CRDInstallOptions) ([]*apiextensionsv1.CustomResourceDefinition, error) {
+	options.CRDs = append([]*apiextensionsv1.CustomResourceDefinition{}, options.CRDs...)
+	if err := readCRDFiles(&options); err != nil {
+		return nil, fmt.Errorf("unable to read CRD files: %w", err)
+	}
+	return options.CRDs, nil
+}
+
 // readCRDFiles reads the directories of CRDs in options.Paths and adds the CRD structs to options.CRDs.
 func readCRDFiles(options *CRDInstallOptions) error {
 	if len(options.Paths) > 0 {
@@ -19,6 +19,8 @@ package envtest
 import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -48,4 +50,28 @@ var _ = Describe("Test", func() {
 			Expect(expectedCRDs).To(Equal(foundCRDs))
 		})
 	})
+
+	Describe("ReadCRDs", func() {
+		It("verilen CRD'leri ve dosyalardan okunanları döndürmeli", func() {
+			given := &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "given.example.com"}}
+			opt := CRDInstallOptions{
+				Paths: []string{"testdata/crds"},
+				CRDs:  []*apiextensionsv1.CustomResourceDefinition{given},
+			}
+			crds, err := ReadCRDs(opt)
+			Expect(err).NotTo(HaveOccurred())
+
+			foundCRDs := sets.NewString()
+			for _, crd := range crds {
+				foundCRDs.Insert(crd.Name)
+			}
+			Expect(foundCRDs).To(Equal(sets.NewString("given.example.com", "frigates.ship.example.com", "configs.foo.example.com")))
+			Expect(opt.CRDs).To(HaveLen(1))
+		})
+
+		It("ErrorIfPathMissing ayarlandığında eksik yollar için hata döndürmeli", func() {
+			_, err := ReadCRDs(CRDInstallOptions{Paths: []string{"testdata/missing"}, ErrorIfPathMissing: true})
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
@@ -0,0 +1,93 @@
+/*
+2024 Kubernetes Yazarları.
+
+Apache Lisansı, Sürüm 2.0 ("Lisans") uyarınca lisanslanmıştır;
+bu dosyayı yalnızca Lisans uyarınca kullanabilirsiniz.
+Lisansın bir kopyasını aşağıdaki adreste bulabilirsiniz:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Yürürlükteki yasa veya yazılı izin gereği aksi belirtilmedikçe,
+Lisans kapsamında dağıtılan yazılım "OLDUĞU GİBİ" dağıtılır,
+HERHANGİ BİR GARANTİ VEYA KOŞUL OLMAKSIZIN, açık veya zımni.
+Lisans kapsamında izin verilen belirli dil kapsamındaki
+haklar ve sınırlamalar için Lisansa bakınız.
+*/
+
+package komega
+
+import (
+	"fmt"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// AuditEvents, denetim günlüğünden tüm filtrelerle seçilen olayları getiren bir fonksiyon döner.
+// API sunucusu olayları yanıtı gönderdikten sonra yazdığı için gomega.Eventually() ile şu şekilde kullanılabilir:
+//
+//	gomega.Eventually(komega.AuditEvents(testEnv.AuditLog(),
+//	  envtest.AuditByVerb("delete"),
+//	  envtest.AuditByObject(&deployment),
+//	)).Should(gomega.HaveLen(1))
+//
+// Dönen fonksiyon doğrudan çağrılarak şu şekilde de kullanılabilir: gomega.Expect(komega.AuditEvents(...)()).To(...)
+func AuditEvents(log *envtest.AuditLog, filters ...envtest.AuditEventFilter) func() ([]auditv1.Event, error) {
+	return func() ([]auditv1.Event, error) {
+		return log.Events(filters...)
+	}
+}
+
+// HaveAuditEvent, bir *envtest.AuditLog içinde tüm filtrelerle seçilen en az bir olay bulunduğunda eşleşen bir eşleştirici döner.
+// gomega.Eventually() ile şu şekilde kullanılabilir:
+//
+//	gomega.Eventually(testEnv.AuditLog()).Should(komega.HaveAuditEvent(
+//	  envtest.AuditByUser("system:serviceaccount:default:controller"),
+//	  envtest.AuditByVerb("update", "patch"),
+//	))
+func HaveAuditEvent(filters ...envtest.AuditEventFilter) types.GomegaMatcher {
+	return &auditEventMatcher{filters: filters}
+}
+
+type auditEventMatcher struct {
+	filters []envtest.AuditEventFilter
+	// events, son eşleştirmede okunan tüm olaylardır ve hata mesajlarında gösterilir.
+	events []auditv1.Event
+}
+
+func (m *auditEventMatcher) Match(actual interface{}) (bool, error) {
+	log, ok := actual.(*envtest.AuditLog)
+	if !ok || log == nil {
+		return false, fmt.Errorf("HaveAuditEvent bir *envtest.AuditLog bekler, verilen: %s", format.Object(actual, 1))
+	}
+	matching, err := log.Events(m.filters...)
+	if err != nil {
+		return false, err
+	}
+	if len(matching) > 0 {
+		m.events = matching
+		return true, nil
+	}
+	m.events, err = log.Events()
+	return false, err
+}
+
+func (m *auditEventMatcher) FailureMessage(_ interface{}) string {
+	return fmt.Sprintf("denetim günlüğünde filtrelerle eşleşen bir olay bekleniyordu, günlükteki olaylar:\n%s", m.summary())
+}
+
+func (m *auditEventMatcher) NegatedFailureMessage(_ interface{}) string {
+	return fmt.Sprintf("denetim günlüğünde filtrelerle eşleşen bir olay beklenmiyordu, eşleşen olaylar:\n%s", m.summary())
+}
+
+// summary, olayları her satırda bir olay olacak şekilde kısaca özetler.
+func (m *auditEventMatcher) summary() string {
+	var res string
+	for _, e := range m.events {
+		res += fmt.Sprintf("  %s %s (%s)\n", e.Verb, e.RequestURI, e.User.Username)
+	}
+	return res
+}
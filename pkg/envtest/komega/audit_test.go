@@ -0,0 +1,41 @@
+package komega
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	authnv1 "k8s.io/api/authentication/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+func TestHaveAuditEvent(t *testing.T) {
+	g := NewWithT(t)
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log := envtest.NewAuditLog(path)
+
+	// Günlük dosyası henüz yokken hiçbir olay eşleşmez.
+	g.Expect(log).NotTo(HaveAuditEvent())
+
+	data, err := json.Marshal(auditv1.Event{
+		Verb:       "update",
+		RequestURI: "/apis/apps/v1/namespaces/default/deployments/test",
+		User:       authnv1.UserInfo{Username: "admin"},
+		ObjectRef:  &auditv1.ObjectReference{APIGroup: "apps", Resource: "deployments", Namespace: "default", Name: "test"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(os.WriteFile(path, append(data, '\n'), 0600)).To(Succeed())
+
+	g.Expect(log).To(HaveAuditEvent(envtest.AuditByUser("admin"), envtest.AuditByObject(exampleDeployment())))
+	g.Expect(log).NotTo(HaveAuditEvent(envtest.AuditByVerb("delete")))
+	g.Expect(AuditEvents(log, envtest.AuditByVerb("update"))()).To(HaveLen(1))
+
+	// Eşleştirici yalnızca *envtest.AuditLog kabul eder.
+	_, err = HaveAuditEvent().Match("audit.log")
+	g.Expect(err).To(HaveOccurred())
+}
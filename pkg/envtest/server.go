@@ -46,23 +46,58 @@ It's possible to override some defaults, by setting the following environment va
 * TEST_ASSET_KUBE_APISERVER (string): path to the api-server binary to use
 * TEST_ASSET_ETCD (string): path to the etcd binary to use
 * TEST_ASSET_KUBECTL (string): path to the kubectl binary to use
-* KUBEBUILDER_ASSETS (string): directory containing the binaries to use (api-server, etcd and kubectl). Defaults to /usr/local/kubebuilder/bin.
+* TEST_ASSET_KUBE_CONTROLLER_MANAGER (string): path to the kube-controller-manager binary to use
+* KUBEBUILDER_ASSETS (string): directory containing the binaries to use (api-server, etcd, kubectl and optionally kube-controller-manager). Defaults to /usr/local/kubebuilder/bin.
 * KUBEBUILDER_CONTROLPLANE_START_TIMEOUT (string supported by time.ParseDuration): timeout for test control plane to start. Defaults to 20s.
 * KUBEBUILDER_CONTROLPLANE_STOP_TIMEOUT (string supported by time.ParseDuration): timeout for test control plane to start. Defaults to 20s.
 * KUBEBUILDER_ATTACH_CONTROL_PLANE_OUTPUT (boolean): if set to true, the control plane's stdout and stderr are attached to os.Stdout and os.Stderr
+* KUBEBUILDER_SHARED_CONTROL_PLANE (boolean): if set to true, the control plane is shared with the environments of other test packages
+* KUBEBUILDER_SHARED_CONTROL_PLANE_DIR (string): directory in which shared control planes are coordinated. Defaults to a directory in os.TempDir().
 */
 const (
-	envUseExistingCluster = "USE_EXISTING_CLUSTER"
-	envStartTimeout       = "KUBEBUILDER_CONTROLPLANE_START_TIMEOUT"
-	envStopTimeout        = "KUBEBUILDER_CONTROLPLANE_STOP_TIMEOUT"
-	envAttachOutput       = "KUBEBUILDER_ATTACH_CONTROL_PLANE_OUTPUT"
-	StartTimeout          = 60
-	StopTimeout           = 60
+	envUseExistingCluster    = "USE_EXISTING_CLUSTER"
+	envStartTimeout          = "KUBEBUILDER_CONTROLPLANE_START_TIMEOUT"
+	envStopTimeout           = "KUBEBUILDER_CONTROLPLANE_STOP_TIMEOUT"
+	envAttachOutput          = "KUBEBUILDER_ATTACH_CONTROL_PLANE_OUTPUT"
+	envSharedControlPlane    = "KUBEBUILDER_SHARED_CONTROL_PLANE"
+	envSharedControlPlaneDir = "KUBEBUILDER_SHARED_CONTROL_PLANE_DIR"
+	StartTimeout             = 60
+	StopTimeout              = 60
 
 	defaultKubebuilderControlPlaneStartTimeout = 20 * time.Second
 	defaultKubebuilderControlPlaneStopTimeout  = 20 * time.Second
 )
 
+// Names of kube-controller-manager controllers that are useful to run in tests,
+// see Environment.KubeControllerManagerControllers.
+const (
+	// NamespaceController deletes the contents of namespaces being deleted, and
+	// then finalizes them.
+	NamespaceController = "namespace"
+
+	// GarbageCollectorController deletes the objects whose owners were deleted,
+	// and implements foreground and orphan deletion.
+	GarbageCollectorController = "garbagecollector"
+
+	// ServiceAccountController creates the default service account of each
+	// namespace.
+	ServiceAccountController = "serviceaccount"
+
+	// ServiceAccountTokenController populates the secrets of type
+	// kubernetes.io/service-account-token with tokens.
+	ServiceAccountTokenController = "serviceaccount-token"
+)
+
+// Authorization modes of the API server, see APIServerOptions.AuthorizationModes.
+const (
+	AuthorizationModeAlwaysAllow = controlplane.AuthorizationModeAlwaysAllow
+	AuthorizationModeAlwaysDeny  = controlplane.AuthorizationModeAlwaysDeny
+	AuthorizationModeABAC        = controlplane.AuthorizationModeABAC
+	AuthorizationModeWebhook     = controlplane.AuthorizationModeWebhook
+	AuthorizationModeRBAC        = controlplane.AuthorizationModeRBAC
+	AuthorizationModeNode        = controlplane.AuthorizationModeNode
+)
+
 // internal types we expose as part of our public API.
 type (
 	// ControlPlane is the re-exported ControlPlane type from the internal testing package.
@@ -71,9 +106,15 @@ type (
 	// APIServer is the re-exported APIServer from the internal testing package.
 	APIServer = controlplane.APIServer
 
+	// APIServerOptions is the re-exported APIServerOptions from the internal testing package.
+	APIServerOptions = controlplane.APIServerOptions
+
 	// Etcd is the re-exported Etcd from the internal testing package.
 	Etcd = controlplane.Etcd
 
+	// ControllerManager is the re-exported ControllerManager from the internal testing package.
+	ControllerManager = controlplane.ControllerManager
+
 	// User represents a Kubernetes user to provision for auth purposes.
 	User = controlplane.User
 
@@ -96,6 +137,10 @@ type (
 
 	// Arg is a single flag with one or more values.
 	Arg = process.Arg
+
+	// Snapshot is a copy of the objects stored by a control plane, see
+	// Environment.Snapshot.
+	Snapshot = controlplane.EtcdSnapshot
 )
 
 var (
@@ -170,13 +215,88 @@ type Environment struct {
 	// Enable this to get more visibility of the testing control plane.
 	// It respect KUBEBUILDER_ATTACH_CONTROL_PLANE_OUTPUT environment variable.
 	AttachControlPlaneOutput bool
+
+	// KubeControllerManagerControllers are the kube-controller-manager
+	// controllers to run against the control plane, e.g. NamespaceController or
+	// GarbageCollectorController.  kube-controller-manager is only started if
+	// this is set, or if ControlPlane.ControllerManager is set.
+	//
+	// The kube-controller-manager binary isn't part of the default envtest
+	// binaries, `setup-envtest use --controller-manager` downloads it next to them.
+	KubeControllerManagerControllers []string
+
+	// AuditLogOptions, if set, enables the audit logging of the API server to
+	// a temporary file, whose events can be read with AuditLog.
+	//
+	// Audit logging is not supported when using an existing cluster or a shared
+	// control plane.
+	AuditLogOptions *AuditLogOptions
+
+	// APIServerOptions, if set, replaces the Options of the API server, which
+	// configure its feature gates, runtime config, admission plugins and
+	// authorization modes, e.g.
+	//
+	//	testEnv := &envtest.Environment{
+	//		APIServerOptions: &envtest.APIServerOptions{
+	//			FeatureGates:       map[string]bool{"MutatingAdmissionPolicy": true},
+	//			RuntimeConfig:      map[string]bool{"admissionregistration.k8s.io/v1alpha1": true},
+	//			AuthorizationModes: []string{envtest.AuthorizationModeRBAC},
+	//		},
+	//	}
+	//
+	// The options are validated against the API server binary when starting.
+	// They are ignored when using an existing cluster.
+	APIServerOptions *APIServerOptions
+
+	// SharedControlPlane indicates that the control plane should be shared with
+	// the Environments of other test packages, which `go test` runs in separate
+	// processes, instead of starting one per package.  The first Environment to
+	// start launches the control plane, the following ones attach to it, and the
+	// last one to stop shuts it down.  Each Environment gets its own namespace,
+	// see Namespace.
+	//
+	// Only the configuration of the Environment that launches the control plane
+	// is used, so all Environments sharing it should configure it the same way.
+	// AddUser is not supported when attached to a control plane launched by
+	// another Environment, and the output of a shared control plane is never
+	// attached, since it outlives the process that launched it.
+	// Stop uninstalls the webhook configurations installed by the Environment
+	// and removes its conversion webhooks from the CRDs, which are kept for the
+	// other Environments.
+	// It respects the KUBEBUILDER_SHARED_CONTROL_PLANE environment variable.
+	SharedControlPlane bool
+
+	// SharedControlPlaneDir is the directory in which the Environments sharing
+	// a control plane keep its state.  It defaults to the
+	// KUBEBUILDER_SHARED_CONTROL_PLANE_DIR environment variable, or to a
+	// directory in os.TempDir() if unspecified.
+	SharedControlPlaneDir string
+
+	// SharedControlPlaneCleanup is called by Stop before detaching from a shared
+	// control plane, to clean up what the tests created outside of Namespace.
+	// Namespace itself is deleted afterwards.
+	SharedControlPlaneCleanup func(cfg *rest.Config, namespace string) error
+
+	// Namespace is the namespace created for this Environment when using a
+	// shared control plane.  Tests should create their namespaced objects in
+	// it to be isolated from the tests of other packages.
+	//
+	// Populated by Start.
+	Namespace string
+
+	// shared is set while this Environment uses a shared control plane.
+	shared *sharedControlPlane
+
+	// auditLog is set while audit logging is enabled.
+	auditLog *AuditLog
 }
 
 // Stop stops a running server.
 // Previously installed CRDs, as listed in CRDInstallOptions.CRDs, will be uninstalled
 // if CRDInstallOptions.CleanUpAfterUse are set to true.
 func (te *Environment) Stop() error {
-	if te.CRDInstallOptions.CleanUpAfterUse {
+	// CRDs of a shared control plane may still be used by other packages.
+	if te.CRDInstallOptions.CleanUpAfterUse && te.shared == nil {
 		if err := UninstallCRDs(te.Config, te.CRDInstallOptions); err != nil {
 			return err
 		}
@@ -190,7 +310,20 @@ func (te *Environment) Stop() error {
 		return nil
 	}
 
-	return te.ControlPlane.Stop()
+	if te.shared != nil {
+		return te.detachSharedControlPlane()
+	}
+
+	if err := te.ControlPlane.Stop(); err != nil {
+		return err
+	}
+	if te.auditLog != nil {
+		if err := te.auditLog.cleanup(); err != nil {
+			return err
+		}
+		te.auditLog = nil
+	}
+	return nil
 }
 
 // Start starts a local Kubernetes server and updates te.ApiserverPort with the port it is listening on.
@@ -209,60 +342,31 @@ func (te *Environment) Start() (*rest.Config, error) {
 			}
 		}
 	} else {
-		apiServer := te.ControlPlane.GetAPIServer()
-
-		if te.ControlPlane.Etcd == nil {
-			te.ControlPlane.Etcd = &controlplane.Etcd{}
+		if os.Getenv(envSharedControlPlane) == "true" {
+			te.SharedControlPlane = true
 		}
-
-		if os.Getenv(envAttachOutput) == "true" {
-			te.AttachControlPlaneOutput = true
+		if err := te.configureControlPlane(); err != nil {
+			return nil, err
 		}
-		if te.AttachControlPlaneOutput {
-			if apiServer.Out == nil {
-				apiServer.Out = os.Stdout
-			}
-			if apiServer.Err == nil {
-				apiServer.Err = os.Stderr
-			}
-			if te.ControlPlane.Etcd.Out == nil {
-				te.ControlPlane.Etcd.Out = os.Stdout
+		if te.AuditLogOptions != nil {
+			if te.SharedControlPlane {
+				return nil, fmt.Errorf("audit logging is not supported with a shared control plane")
 			}
-			if te.ControlPlane.Etcd.Err == nil {
-				te.ControlPlane.Etcd.Err = os.Stderr
+			auditLog, err := newAuditLog(te.AuditLogOptions, te.ControlPlane.GetAPIServer())
+			if err != nil {
+				return nil, fmt.Errorf("unable to configure audit logging: %w", err)
 			}
+			te.auditLog = auditLog
 		}
 
-		apiServer.Path = process.BinPathFinder("kube-apiserver", te.BinaryAssetsDirectory)
-		te.ControlPlane.Etcd.Path = process.BinPathFinder("etcd", te.BinaryAssetsDirectory)
-		te.ControlPlane.KubectlPath = process.BinPathFinder("kubectl", te.BinaryAssetsDirectory)
-
-		if err := te.defaultTimeouts(); err != nil {
-			return nil, fmt.Errorf("failed to default controlplane timeouts: %w", err)
-		}
-		te.ControlPlane.Etcd.StartTimeout = te.ControlPlaneStartTimeout
-		te.ControlPlane.Etcd.StopTimeout = te.ControlPlaneStopTimeout
-		apiServer.StartTimeout = te.ControlPlaneStartTimeout
-		apiServer.StopTimeout = te.ControlPlaneStopTimeout
-
-		log.V(1).Info("starting control plane")
-		if err := te.startControlPlane(); err != nil {
-			return nil, fmt.Errorf("unable to start control plane itself: %w", err)
-		}
-
-		// Create the *rest.Config for creating new clients
-		baseConfig := &rest.Config{
-			// gotta go fast during tests -- we don't really care about overwhelming our test API server
-			QPS:   1000.0,
-			Burst: 2000.0,
-		}
-
-		adminInfo := User{Name: "admin", Groups: []string{"system:masters"}}
-		adminUser, err := te.ControlPlane.AddUser(adminInfo, baseConfig)
-		if err != nil {
-			return te.Config, fmt.Errorf("unable to provision admin user: %w", err)
+		if te.SharedControlPlane {
+			log.V(1).Info("starting or attaching to shared control plane")
+			if err := te.startSharedControlPlane(); err != nil {
+				return nil, fmt.Errorf("unable to start shared control plane: %w", err)
+			}
+		} else if err := te.startLocalControlPlane(); err != nil {
+			return nil, err
 		}
-		te.Config = adminUser.Config()
 	}
 
 	// Set the default scheme if nil.
@@ -276,6 +380,12 @@ func (te *Environment) Start() (*rest.Config, error) {
 		return nil, fmt.Errorf("default namespace didn't register within deadline: %w", err)
 	}
 
+	if te.shared != nil {
+		if err := te.createSharedNamespace(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Call PrepWithoutInstalling to setup certificates first
 	// and have them available to patch CRD conversion webhook as well.
 	if err := te.WebhookInstallOptions.PrepWithoutInstalling(); err != nil {
@@ -310,12 +420,159 @@ func (te *Environment) Start() (*rest.Config, error) {
 // settings as well as any required by the authentication method.  You can use
 // this to easily specify options like QPS.
 //
+// Unless the user belongs to the system:masters group, its requests are
+// authorized by the AuthorizationModes of APIServerOptions, which default to
+// RBAC, so roles need to be bound to it or to its groups.
+//
 // This is effectively a convinience alias for ControlPlane.AddUser -- see that
 // for more low-level details.
 func (te *Environment) AddUser(user User, baseConfig *rest.Config) (*AuthenticatedUser, error) {
+	if te.shared != nil && !te.shared.owner {
+		return nil, fmt.Errorf("unable to add users to a shared control plane started by another environment")
+	}
 	return te.ControlPlane.AddUser(user, baseConfig)
 }
 
+// configureControlPlane locates the binaries and configures the timeouts and
+// output of the control plane.
+func (te *Environment) configureControlPlane() error {
+	apiServer := te.ControlPlane.GetAPIServer()
+
+	if te.ControlPlane.Etcd == nil {
+		te.ControlPlane.Etcd = &controlplane.Etcd{}
+	}
+
+	if os.Getenv(envAttachOutput) == "true" {
+		te.AttachControlPlaneOutput = true
+	}
+	if te.AttachControlPlaneOutput && !te.SharedControlPlane {
+		if apiServer.Out == nil {
+			apiServer.Out = os.Stdout
+		}
+		if apiServer.Err == nil {
+			apiServer.Err = os.Stderr
+		}
+		if te.ControlPlane.Etcd.Out == nil {
+			te.ControlPlane.Etcd.Out = os.Stdout
+		}
+		if te.ControlPlane.Etcd.Err == nil {
+			te.ControlPlane.Etcd.Err = os.Stderr
+		}
+	}
+
+	if len(te.KubeControllerManagerControllers) > 0 {
+		if te.ControlPlane.ControllerManager == nil {
+			te.ControlPlane.ControllerManager = &controlplane.ControllerManager{}
+		}
+		te.ControlPlane.ControllerManager.Controllers = te.KubeControllerManagerControllers
+	}
+	controllerManager := te.ControlPlane.ControllerManager
+	if controllerManager != nil && te.AttachControlPlaneOutput && !te.SharedControlPlane {
+		if controllerManager.Out == nil {
+			controllerManager.Out = os.Stdout
+		}
+		if controllerManager.Err == nil {
+			controllerManager.Err = os.Stderr
+		}
+	}
+
+	if te.APIServerOptions != nil {
+		apiServer.Options = *te.APIServerOptions
+	}
+
+	apiServer.Path = process.BinPathFinder("kube-apiserver", te.BinaryAssetsDirectory)
+	te.ControlPlane.Etcd.Path = process.BinPathFinder("etcd", te.BinaryAssetsDirectory)
+	te.ControlPlane.KubectlPath = process.BinPathFinder("kubectl", te.BinaryAssetsDirectory)
+
+	if err := te.defaultTimeouts(); err != nil {
+		return fmt.Errorf("failed to default controlplane timeouts: %w", err)
+	}
+	te.ControlPlane.Etcd.StartTimeout = te.ControlPlaneStartTimeout
+	te.ControlPlane.Etcd.StopTimeout = te.ControlPlaneStopTimeout
+	apiServer.StartTimeout = te.ControlPlaneStartTimeout
+	apiServer.StopTimeout = te.ControlPlaneStopTimeout
+	if controllerManager != nil {
+		controllerManager.Path = process.BinPathFinder("kube-controller-manager", te.BinaryAssetsDirectory)
+		controllerManager.StartTimeout = te.ControlPlaneStartTimeout
+		controllerManager.StopTimeout = te.ControlPlaneStopTimeout
+	}
+	return nil
+}
+
+// startLocalControlPlane starts the control plane and provisions the admin
+// user used for te.Config.
+func (te *Environment) startLocalControlPlane() error {
+	log.V(1).Info("starting control plane")
+	if err := te.startControlPlane(); err != nil {
+		return fmt.Errorf("unable to start control plane itself: %w", err)
+	}
+
+	// Create the *rest.Config for creating new clients
+	baseConfig := &rest.Config{
+		// gotta go fast during tests -- we don't really care about overwhelming our test API server
+		QPS:   1000.0,
+		Burst: 2000.0,
+	}
+
+	adminInfo := User{Name: "admin", Groups: []string{"system:masters"}}
+	adminUser, err := te.ControlPlane.AddUser(adminInfo, baseConfig)
+	if err != nil {
+		return fmt.Errorf("unable to provision admin user: %w", err)
+	}
+	te.Config = adminUser.Config()
+	return nil
+}
+
+// AuditLog returns the audit log of the API server, or nil if audit logging
+// is not enabled, see AuditLogOptions.
+func (te *Environment) AuditLog() *AuditLog {
+	return te.auditLog
+}
+
+// Snapshot copies the objects stored by the control plane, so that Restore can
+// return to them.  It is typically called right after Start, so that each test
+// starts with the CRDs and webhooks installed and nothing else, e.g.
+//
+//	snapshot, err := testEnv.Snapshot()
+//	...
+//	AfterEach(func() {
+//		Expect(testEnv.Restore(snapshot)).To(Succeed())
+//	})
+//
+// Snapshots are not supported when using an existing cluster or a shared
+// control plane.
+func (te *Environment) Snapshot() (*Snapshot, error) {
+	if err := te.checkSnapshotSupported(); err != nil {
+		return nil, err
+	}
+	return te.ControlPlane.Etcd.Snapshot()
+}
+
+// Restore reverts the objects stored by the control plane to the given
+// snapshot.  The changes are applied through etcd while the API server keeps
+// running, so clients and informers see them like any other change: restored
+// objects get new resource versions, and objects created since the snapshot
+// are deleted without going through finalizers or webhooks.
+func (te *Environment) Restore(snapshot *Snapshot) error {
+	if err := te.checkSnapshotSupported(); err != nil {
+		return err
+	}
+	return te.ControlPlane.Etcd.Restore(snapshot)
+}
+
+func (te *Environment) checkSnapshotSupported() error {
+	if te.useExistingCluster() {
+		return fmt.Errorf("snapshots are not supported when using an existing cluster")
+	}
+	if te.shared != nil {
+		return fmt.Errorf("snapshots are not supported when using a shared control plane")
+	}
+	if te.ControlPlane.Etcd == nil {
+		return fmt.Errorf("the control plane has not been started")
+	}
+	return nil
+}
+
 func (te *Environment) startControlPlane() error {
 	numTries, maxRetries := 0, 5
 	var err error
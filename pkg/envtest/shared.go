@@ -0,0 +1,426 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/internal/flock"
+	"sigs.k8s.io/controller-runtime/pkg/internal/testing/controlplane"
+)
+
+const (
+	sharedLockFile  = "lock"
+	sharedStateFile = "state.json"
+)
+
+// sharedControlPlane tracks the use of a shared control plane by an Environment.
+type sharedControlPlane struct {
+	// dir is the directory containing the lock and the state of the control plane.
+	dir string
+	// owner is true if the control plane was started by this Environment.
+	owner bool
+}
+
+// sharedControlPlaneState is stored in the directory of a shared control plane.
+// It must only be accessed while holding the lock of that directory.
+type sharedControlPlaneState struct {
+	// KubeConfig contains the credentials of the admin user.
+	KubeConfig []byte `json:"kubeConfig"`
+	// PIDs are the process IDs of the control plane components.
+	PIDs []int `json:"pids"`
+	// Dirs are the directories to remove once the control plane is stopped.
+	Dirs []string `json:"dirs,omitempty"`
+	// Users are the process IDs of the test processes using the control plane,
+	// once per Environment.
+	Users []int `json:"users,omitempty"`
+}
+
+// sharedControlPlaneDir returns the directory of the shared control plane,
+// which is specific to the binaries, controllers and API server options in
+// use, creating it if needed.
+func (te *Environment) sharedControlPlaneDir() (string, error) {
+	base := te.SharedControlPlaneDir
+	if base == "" {
+		base = os.Getenv(envSharedControlPlaneDir)
+	}
+	if base == "" {
+		base = filepath.Join(os.TempDir(), "envtest-shared-control-plane")
+	}
+
+	h := sha256.New()
+	apiServer := te.ControlPlane.GetAPIServer()
+	options, err := json.Marshal(apiServer.Options)
+	if err != nil {
+		return "", err
+	}
+	key := []string{apiServer.Path, te.ControlPlane.Etcd.Path, string(options)}
+	if cm := te.ControlPlane.ControllerManager; cm != nil {
+		key = append(key, cm.Path)
+		key = append(key, cm.Controllers...)
+	}
+	for _, part := range key {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	dir := filepath.Join(base, hex.EncodeToString(h.Sum(nil))[:16])
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("unable to create shared control plane directory: %w", err)
+	}
+	return dir, nil
+}
+
+// startSharedControlPlane attaches to the shared control plane, starting it if
+// no other Environment is running it.
+func (te *Environment) startSharedControlPlane() (retErr error) {
+	dir, err := te.sharedControlPlaneDir()
+	if err != nil {
+		return err
+	}
+	unlock, err := flock.Lock(filepath.Join(dir, sharedLockFile))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := unlock(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+
+	state, err := readSharedControlPlaneState(dir)
+	if err != nil {
+		return err
+	}
+	if state != nil {
+		state.pruneUsers()
+		// A control plane without users or with exited components was left
+		// behind by test processes that didn't call Stop.
+		if len(state.Users) == 0 || !state.running() {
+			log.V(1).Info("stopping stale shared control plane", "dir", dir)
+			if err := state.stop(te.ControlPlaneStopTimeout); err != nil {
+				return err
+			}
+			state = nil
+		}
+	}
+
+	shared := &sharedControlPlane{dir: dir}
+	if state == nil {
+		if err := te.startLocalControlPlane(); err != nil {
+			return err
+		}
+		defer func() {
+			if retErr != nil {
+				_ = te.ControlPlane.Stop()
+			}
+		}()
+		kubeConfig, err := controlplane.KubeConfigFromREST(te.Config)
+		if err != nil {
+			return err
+		}
+		pids, dirs := controlplane.Processes(&te.ControlPlane)
+		state = &sharedControlPlaneState{KubeConfig: kubeConfig, PIDs: pids, Dirs: dirs}
+		shared.owner = true
+	} else {
+		log.V(1).Info("attaching to shared control plane", "dir", dir)
+		cfg, err := clientcmd.RESTConfigFromKubeConfig(state.KubeConfig)
+		if err != nil {
+			return fmt.Errorf("unable to load shared control plane kubeconfig: %w", err)
+		}
+		cfg.QPS = 1000.0
+		cfg.Burst = 2000.0
+		te.Config = cfg
+	}
+
+	state.Users = append(state.Users, os.Getpid())
+	if err := state.write(dir); err != nil {
+		return err
+	}
+	te.shared = shared
+	return nil
+}
+
+// detachSharedControlPlane cleans up the namespace of this Environment, and
+// stops the shared control plane if no other Environment uses it anymore.
+func (te *Environment) detachSharedControlPlane() (retErr error) {
+	var errList []error
+	if err := te.uninstallSharedWebhooks(); err != nil {
+		errList = append(errList, err)
+	}
+	if err := te.cleanupSharedNamespace(); err != nil {
+		errList = append(errList, err)
+	}
+
+	shared := te.shared
+	te.shared = nil
+	unlock, err := flock.Lock(filepath.Join(shared.dir, sharedLockFile))
+	if err != nil {
+		return kerrors.NewAggregate(append(errList, err))
+	}
+	defer func() {
+		if err := unlock(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+
+	state, err := readSharedControlPlaneState(shared.dir)
+	if err != nil {
+		return kerrors.NewAggregate(append(errList, err))
+	}
+	if state == nil {
+		// Someone else already cleaned up after us.
+		return kerrors.NewAggregate(errList)
+	}
+	state.removeUser(os.Getpid())
+	state.pruneUsers()
+	if len(state.Users) > 0 {
+		return kerrors.NewAggregate(append(errList, state.write(shared.dir)))
+	}
+
+	log.V(1).Info("stopping shared control plane", "dir", shared.dir)
+	if shared.owner {
+		err = te.ControlPlane.Stop()
+	} else {
+		err = state.stop(te.ControlPlaneStopTimeout)
+	}
+	if err != nil {
+		errList = append(errList, err)
+	}
+	if err := os.Remove(filepath.Join(shared.dir, sharedStateFile)); err != nil && !os.IsNotExist(err) {
+		errList = append(errList, err)
+	}
+	return kerrors.NewAggregate(errList)
+}
+
+// uninstallSharedWebhooks removes the webhook configurations installed by this
+// Environment, and the conversion webhooks it set on CRDs, from the shared
+// control plane, as they point to a webhook server that is going away.
+func (te *Environment) uninstallSharedWebhooks() error {
+	options := &te.WebhookInstallOptions
+	if len(options.LocalServingCAData) == 0 {
+		return nil
+	}
+	c, err := client.New(te.Config, client.Options{})
+	if err != nil {
+		return fmt.Errorf("unable to create client: %w", err)
+	}
+
+	var errList []error
+	for _, hook := range options.MutatingWebhooks {
+		log.V(1).Info("uninstalling mutating webhook", "webhook", hook.GetName())
+		if err := c.Delete(context.TODO(), hook); client.IgnoreNotFound(err) != nil {
+			errList = append(errList, fmt.Errorf("unable to delete mutating webhook %q: %w", hook.GetName(), err))
+		}
+	}
+	for _, hook := range options.ValidatingWebhooks {
+		log.V(1).Info("uninstalling validating webhook", "webhook", hook.GetName())
+		if err := c.Delete(context.TODO(), hook); client.IgnoreNotFound(err) != nil {
+			errList = append(errList, fmt.Errorf("unable to delete validating webhook %q: %w", hook.GetName(), err))
+		}
+	}
+
+	// The CRDs may still be used by other Environments, so only their
+	// conversion webhook is removed, and only if it still points to ours.
+	hostPort, err := options.generateHostPort()
+	if err != nil {
+		return kerrors.NewAggregate(append(errList, err))
+	}
+	url := fmt.Sprintf("https://%s/convert", hostPort)
+	for _, crd := range te.CRDInstallOptions.CRDs {
+		existing := &apiextensionsv1.CustomResourceDefinition{}
+		if err := c.Get(context.TODO(), client.ObjectKey{Name: crd.GetName()}, existing); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				errList = append(errList, fmt.Errorf("unable to get CRD %q: %w", crd.GetName(), err))
+			}
+			continue
+		}
+		conv := existing.Spec.Conversion
+		if conv == nil || conv.Strategy != apiextensionsv1.WebhookConverter || conv.Webhook == nil ||
+			conv.Webhook.ClientConfig == nil || conv.Webhook.ClientConfig.URL == nil || *conv.Webhook.ClientConfig.URL != url {
+			continue
+		}
+		log.V(1).Info("uninstalling conversion webhook", "crd", crd.GetName())
+		existing.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{Strategy: apiextensionsv1.NoneConverter}
+		if err := c.Update(context.TODO(), existing); err != nil {
+			errList = append(errList, fmt.Errorf("unable to remove the conversion webhook of CRD %q: %w", crd.GetName(), err))
+		}
+	}
+	return kerrors.NewAggregate(errList)
+}
+
+// createSharedNamespace creates the namespace isolating this Environment
+// from the others using the shared control plane.
+func (te *Environment) createSharedNamespace() error {
+	c, err := client.New(te.Config, client.Options{})
+	if err != nil {
+		return fmt.Errorf("unable to create client: %w", err)
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "envtest-"}}
+	if err := c.Create(context.TODO(), ns); err != nil {
+		return fmt.Errorf("unable to create namespace: %w", err)
+	}
+	te.Namespace = ns.Name
+	return nil
+}
+
+// cleanupSharedNamespace calls SharedControlPlaneCleanup and deletes the
+// namespace of this Environment.
+func (te *Environment) cleanupSharedNamespace() error {
+	if te.Namespace == "" {
+		return nil
+	}
+	if te.SharedControlPlaneCleanup != nil {
+		if err := te.SharedControlPlaneCleanup(te.Config, te.Namespace); err != nil {
+			return fmt.Errorf("unable to clean up after namespace %s: %w", te.Namespace, err)
+		}
+	}
+	c, err := client.New(te.Config, client.Options{})
+	if err != nil {
+		return fmt.Errorf("unable to create client: %w", err)
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: te.Namespace}}
+	if err := c.Delete(context.TODO(), ns); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("unable to delete namespace %s: %w", te.Namespace, err)
+	}
+	te.Namespace = ""
+	return nil
+}
+
+// readSharedControlPlaneState reads the state from dir, returning nil if no
+// shared control plane is running.
+func readSharedControlPlaneState(dir string) (*sharedControlPlaneState, error) {
+	data, err := os.ReadFile(filepath.Join(dir, sharedStateFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read shared control plane state: %w", err)
+	}
+	state := &sharedControlPlaneState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("unable to decode shared control plane state: %w", err)
+	}
+	return state, nil
+}
+
+// write stores the state in dir.
+func (s *sharedControlPlaneState) write(dir string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so that the state is never truncated.
+	tmp := filepath.Join(dir, sharedStateFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("unable to write shared control plane state: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(dir, sharedStateFile))
+}
+
+// removeUser removes one use of the control plane by the process with the given ID.
+func (s *sharedControlPlaneState) removeUser(pid int) {
+	if i := slices.Index(s.Users, pid); i >= 0 {
+		s.Users = slices.Delete(s.Users, i, i+1)
+	}
+}
+
+// pruneUsers removes the users whose processes exited without detaching.
+func (s *sharedControlPlaneState) pruneUsers() {
+	s.Users = slices.DeleteFunc(s.Users, func(pid int) bool {
+		return !processAlive(pid)
+	})
+}
+
+// running returns whether all components of the control plane are still running.
+func (s *sharedControlPlaneState) running() bool {
+	for _, pid := range s.PIDs {
+		if !processAlive(pid) {
+			return false
+		}
+	}
+	return len(s.PIDs) > 0
+}
+
+// stop stops the control plane components, which are not necessarily children
+// of this process, and removes their directories.
+func (s *sharedControlPlaneState) stop(timeout time.Duration) error {
+	var errList []error
+	for _, pid := range s.PIDs {
+		if err := signalProcess(pid, syscall.SIGTERM); err != nil {
+			errList = append(errList, err)
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for _, pid := range s.PIDs {
+		for processAlive(pid) && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if processAlive(pid) {
+			if err := signalProcess(pid, syscall.SIGKILL); err != nil {
+				errList = append(errList, err)
+			}
+			errList = append(errList, fmt.Errorf("timeout waiting for process %d to stop", pid))
+		}
+	}
+
+	for _, dir := range s.Dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			errList = append(errList, err)
+		}
+	}
+	return kerrors.NewAggregate(errList)
+}
+
+// processAlive returns whether a process with the given ID exists.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
+
+// signalProcess sends sig to the process with the given ID, ignoring
+// processes that already exited.
+func signalProcess(pid int, sig os.Signal) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return nil //nolint:nilerr
+	}
+	if err := p.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("unable to signal process %d: %w", pid, err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+2024 Kubernetes Yazarları.
+
+Apache Lisansı, Sürüm 2.0 ("Lisans") uyarınca lisanslanmıştır;
+bu dosyayı yalnızca Lisans uyarınca kullanabilirsiniz.
+Lisansın bir kopyasını aşağıdaki adreste bulabilirsiniz:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Yürürlükteki yasa veya yazılı izin gereği aksi belirtilmedikçe,
+Lisans kapsamında dağıtılan yazılım "OLDUĞU GİBİ" dağıtılır,
+HERHANGİ BİR GARANTİ VEYA KOŞUL OLMAKSIZIN, açık veya zımni.
+Lisans kapsamında izin verilen belirli dil kapsamındaki
+haklar ve sınırlamalar için Lisansa bakınız.
+*/
+
+package envtest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSharedControlPlaneState(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	// Sonlanmış bir alt işlemin kimliği, kapanmadan çıkan bir test işlemini temsil eder.
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	g.Expect(cmd.Run()).To(Succeed())
+	exited := cmd.Process.Pid
+
+	state, err := readSharedControlPlaneState(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(state).To(BeNil())
+
+	componentDir := filepath.Join(dir, "etcd")
+	g.Expect(os.Mkdir(componentDir, 0700)).To(Succeed())
+	state = &sharedControlPlaneState{
+		KubeConfig: []byte("kubeconfig"),
+		PIDs:       []int{exited},
+		Dirs:       []string{componentDir},
+		Users:      []int{os.Getpid(), exited, os.Getpid()},
+	}
+	g.Expect(state.write(dir)).To(Succeed())
+
+	read, err := readSharedControlPlaneState(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(read).To(Equal(state))
+
+	// Çıkmış kullanıcılar ayıklanır, aynı işlemin her ortamı ayrı sayılır.
+	read.pruneUsers()
+	g.Expect(read.Users).To(Equal([]int{os.Getpid(), os.Getpid()}))
+	read.removeUser(os.Getpid())
+	g.Expect(read.Users).To(Equal([]int{os.Getpid()}))
+
+	// Bileşenleri çıkmış bir kontrol düzlemi çalışmıyor sayılır ve durdurulması dizinlerini siler.
+	g.Expect(read.running()).To(BeFalse())
+	g.Expect(read.stop(time.Second)).To(Succeed())
+	g.Expect(componentDir).NotTo(BeADirectory())
+}
+
+func TestSharedControlPlaneDir(t *testing.T) {
+	g := NewWithT(t)
+	base := t.TempDir()
+
+	env := &Environment{SharedControlPlaneDir: base}
+	env.ControlPlane.GetAPIServer().Path = "/bin/1.30/kube-apiserver"
+	env.ControlPlane.Etcd = &Etcd{Path: "/bin/1.30/etcd"}
+	dir, err := env.sharedControlPlaneDir()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dir).To(BeADirectory())
+	g.Expect(filepath.Dir(dir)).To(Equal(base))
+
+	// Farklı ikili dosyalar farklı kontrol düzlemleri kullanır.
+	env.ControlPlane.GetAPIServer().Path = "/bin/1.31/kube-apiserver"
+	other, err := env.sharedControlPlaneDir()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(other).NotTo(Equal(dir))
+
+	// Farklı API sunucusu seçenekleri de farklı kontrol düzlemleri kullanır.
+	env.ControlPlane.GetAPIServer().Options.FeatureGates = map[string]bool{"AllAlpha": true}
+	withOptions, err := env.sharedControlPlaneDir()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(withOptions).NotTo(Equal(other))
+}
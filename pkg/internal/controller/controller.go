@@ -24,12 +24,18 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/workqueue"
 
+	"sigs.k8s.io/controller-runtime/pkg/internal/controller/identity"
 	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/internal/controller/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/internal/tracing"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -92,6 +98,15 @@ type Controller[request comparable] struct {
 
 	// LeaderElected indicates whether the controller is leader elected or always running.
 	LeaderElected *bool
+
+	// TracerProvider is used to create a span for every reconcile. Client
+	// calls made with the reconcile's context create child spans of it.
+	// Defaults to a no-op provider.
+	TracerProvider trace.TracerProvider
+
+	// ClientRateLimiter limits the requests that clients send to the API
+	// server within reconciles of this controller. Nil means no limit.
+	ClientRateLimiter flowcontrol.RateLimiter
 }
 
 // Reconcile implements reconcile.Reconciler.
@@ -296,11 +311,20 @@ func (c *Controller[request]) reconcileHandler(ctx context.Context, req request)
 	log = log.WithValues("reconcileID", reconcileID)
 	ctx = logf.IntoContext(ctx, log)
 	ctx = addReconcileID(ctx, reconcileID)
+	ctx = identity.IntoContext(ctx, identity.Identity{Name: c.Name, ClientRateLimiter: c.ClientRateLimiter})
+	ctx, span := c.startSpan(ctx, req, reconcileID)
 
 	// RunInformersAndControllers the syncHandler, passing it the Namespace/Name string of the
 	// resource to be synced.
 	log.V(5).Info("Reconciling")
 	result, err := c.Reconcile(ctx, req)
+	if !result.IsZero() {
+		span.SetAttributes(
+			attribute.Bool("reconcile.requeue", result.Requeue),
+			attribute.String("reconcile.requeue_after", result.RequeueAfter.String()),
+		)
+	}
+	tracing.End(span, err)
 	switch {
 	case err != nil:
 		if errors.Is(err, reconcile.TerminalError(nil)) {
@@ -336,6 +360,26 @@ func (c *Controller[request]) reconcileHandler(ctx context.Context, req request)
 	}
 }
 
+// startSpan starts the span of a reconcile.
+func (c *Controller[request]) startSpan(ctx context.Context, req request, reconcileID types.UID) (context.Context, trace.Span) {
+	if c.TracerProvider == nil {
+		return ctx, noop.Span{}
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("controller", c.Name),
+		attribute.String("reconcile.id", string(reconcileID)),
+	}
+	if r, ok := any(req).(reconcile.Request); ok {
+		attrs = append(attrs, tracing.NamespaceKey.String(r.Namespace), tracing.NameKey.String(r.Name))
+	} else {
+		attrs = append(attrs, attribute.String("reconcile.request", fmt.Sprintf("%v", req)))
+	}
+	return tracing.Tracer(c.TracerProvider).Start(ctx, "Reconcile "+c.Name,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
 // GetLogger returns this controller's logger.
 func (c *Controller[request]) GetLogger() logr.Logger {
 	return c.LogConstructor(nil)
@@ -356,6 +400,13 @@ func ReconcileIDFromContext(ctx context.Context) types.UID {
 	return r
 }
 
+// NameFromContext gets the name of the controller whose reconcile the
+// current context belongs to.
+func NameFromContext(ctx context.Context) string {
+	id, _ := identity.FromContext(ctx)
+	return id.Name
+}
+
 // reconcileIDKey is a context.Context Value key. Its associated value should
 // be a types.UID.
 type reconcileIDKey struct{}
@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package identity carries the identity of the controller whose reconcile
+// issued a request through the context, so that lower layers like the client
+// can attribute and rate limit requests per controller.
+package identity
+
+import (
+	"context"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// Identity identifies a controller.
+type Identity struct {
+	// Name is the name of the controller.
+	Name string
+
+	// ClientRateLimiter limits the requests the controller sends to the API
+	// server. It is nil if the requests aren't limited per controller.
+	ClientRateLimiter flowcontrol.RateLimiter
+}
+
+type contextKey struct{}
+
+// IntoContext returns a copy of ctx that carries id.
+func IntoContext(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the identity carried by ctx, if any.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+	return id, ok
+}
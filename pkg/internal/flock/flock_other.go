@@ -23,3 +23,8 @@ package flock
 func Acquire(path string) error {
 	return nil
 }
+
+// Lock, Unix olmayan sistemlerde uygulanmamıştır.
+func Lock(path string) (func() error, error) {
+	return func() error { return nil }, nil
+}
@@ -46,3 +46,29 @@ func Acquire(path string) error {
 	}
 	return err
 }
+
+// Lock, bir dosya üzerinde özel bir kilit alır ve kilit başka bir işlem
+// tarafından tutuluyorsa serbest bırakılana kadar bekler. Acquire'ın aksine
+// kilit işlem süresince tutulmaz; döndürülen işlev kilidi serbest bırakır.
+func Lock(path string) (func() error, error) {
+	fd, err := unix.Open(path, unix.O_CREAT|unix.O_RDWR|unix.O_CLOEXEC, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		err = unix.Flock(fd, unix.LOCK_EX)
+		if !errors.Is(err, unix.EINTR) {
+			break
+		}
+	}
+	if err != nil {
+		_ = unix.Close(fd)
+		return nil, fmt.Errorf("dosya %q kilitlenemiyor: %w", path, err)
+	}
+
+	return func() error {
+		// Dosya tanıtıcısını kapatmak kilidi de serbest bırakır.
+		return unix.Close(fd)
+	}, nil
+}
@@ -139,8 +139,15 @@ type APIServer struct {
 	Out io.Writer
 	Err io.Writer
 
+	// Options are typed options for flags whose valid values depend on the
+	// version of the API server, see APIServerOptions.
+	Options APIServerOptions
+
 	processState *process.State
 
+	// version is the version of the binary, discovered if Options are set.
+	version string
+
 	// args contains the structured arguments to use for running the API server
 	// Lazily initialized by .Configure(), Defaulted eventually with .defaultArgs()
 	args *process.Arguments
@@ -311,7 +318,8 @@ func (s *APIServer) setProcessState() error {
 }
 
 // discoverFlags checks for certain flags that *must* be set in certain
-// versions, and *must not* be set in others.
+// versions, and *must not* be set in others, and validates the Options
+// against the binary.
 func (s *APIServer) discoverFlags() error {
 	// Present: <1.24, Absent: >= 1.24
 	present, err := s.processState.CheckFlag("insecure-port")
@@ -323,6 +331,13 @@ func (s *APIServer) discoverFlags() error {
 		s.Configure().Disable("insecure-port")
 	}
 
+	if s.Options.isEmpty() {
+		return nil
+	}
+	s.version = s.discoverVersion()
+	if err := s.validateOptions(); err != nil {
+		return fmt.Errorf("invalid API server options: %w", err)
+	}
 	return nil
 }
 
@@ -360,6 +375,9 @@ func (s *APIServer) defaultArgs() map[string][]string {
 		// in Start
 		args["insecure-port"] = []string{"0"}
 	}
+	for key, vals := range s.optionArgs() {
+		args[key] = vals
+	}
 	return args
 }
 
@@ -0,0 +1,239 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Authorization modes of the API server, see APIServerOptions.AuthorizationModes.
+const (
+	AuthorizationModeAlwaysAllow = "AlwaysAllow"
+	AuthorizationModeAlwaysDeny  = "AlwaysDeny"
+	AuthorizationModeABAC        = "ABAC"
+	AuthorizationModeWebhook     = "Webhook"
+	AuthorizationModeRBAC        = "RBAC"
+	AuthorizationModeNode        = "Node"
+)
+
+// APIServerOptions are typed options for the API server flags whose valid
+// values depend on the version of the API server.  Before the API server is
+// started, they are validated against the feature gates, admission plugins
+// and authorization modes listed by the help output of its binary, so that
+// e.g. a feature gate that was removed fails with a clear error instead of an
+// API server that doesn't come up.
+//
+// Flags set with Configure are passed in addition to the ones of these
+// options, or replace them when using Set.
+type APIServerOptions struct {
+	// FeatureGates enables or disables the given feature gates.
+	FeatureGates map[string]bool
+
+	// RuntimeConfig enables or disables built-in API group versions.  Keys are
+	// either <group>/<version> (e.g. "resource.k8s.io/v1alpha3"), or one of
+	// "api/all", "api/ga", "api/beta" and "api/alpha".
+	RuntimeConfig map[string]bool
+
+	// EnableAdmissionPlugins are enabled in addition to the default ones.
+	EnableAdmissionPlugins []string
+
+	// DisableAdmissionPlugins are disabled in addition to ServiceAccount, which
+	// is disabled by default because no controller creates the default service
+	// account of namespaces.  Enabling ServiceAccount with
+	// EnableAdmissionPlugins removes it from the disabled plugins.
+	DisableAdmissionPlugins []string
+
+	// AuthorizationModes are the ordered authorization modes of the API server.
+	//
+	// If unset, RBAC is used, which enforces the roles bound to the users
+	// provisioned with AddUser unless they belong to the system:masters group.
+	// Use AlwaysAllow to allow every request of every user.
+	AuthorizationModes []string
+}
+
+// isEmpty returns true if none of the options are set.
+func (o *APIServerOptions) isEmpty() bool {
+	return len(o.FeatureGates) == 0 && len(o.RuntimeConfig) == 0 &&
+		len(o.EnableAdmissionPlugins) == 0 && len(o.DisableAdmissionPlugins) == 0 &&
+		len(o.AuthorizationModes) == 0
+}
+
+var (
+	// versionRegexp matches the output of `kube-apiserver --version`.
+	versionRegexp = regexp.MustCompile(`Kubernetes (v\d+\.\d+\.\d+\S*)`)
+	// featureGateRegexp matches the feature gates listed in the help of
+	// --feature-gates, which newer versions prefix with their component.
+	featureGateRegexp = regexp.MustCompile(`(?m)^\s*(?:[a-z]+:)?([A-Za-z0-9]+)=true\|false \(`)
+	// admissionPluginsRegexp matches the admission plugins listed in the help of
+	// --enable-admission-plugins.
+	admissionPluginsRegexp = regexp.MustCompile(`Comma-delimited list of admission plugins: ([A-Za-z, ]+)\.`)
+	// authorizationModesRegexp matches the authorization modes listed in the
+	// help of --authorization-mode.
+	authorizationModesRegexp = regexp.MustCompile(`Comma-delimited list of: ([A-Za-z,]+)`)
+	// runtimeConfigRegexp matches the keys of --runtime-config.
+	runtimeConfigRegexp = regexp.MustCompile(`^(api/(all|ga|beta|alpha)|([a-z0-9.-]+/)?v\d+((alpha|beta)\d+)?)$`)
+)
+
+// discoverVersion returns the version printed by the binary, or an empty
+// string if it can't be determined.
+func (s *APIServer) discoverVersion() string {
+	out, err := exec.Command(s.Path, "--version").CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	if m := versionRegexp.FindSubmatch(out); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// validateOptions checks the options against the help output of the binary.
+// Options whose valid values can't be found in the help output, e.g. because
+// its format changed, are not validated.
+func (s *APIServer) validateOptions() error {
+	help, err := exec.Command(s.Path, "--help").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("unable to run command %q to validate the API server options: %w", s.Path, err)
+	}
+	binary := "kube-apiserver"
+	if s.version != "" {
+		binary += " " + s.version
+	}
+
+	var errList []error
+	if len(s.Options.FeatureGates) > 0 {
+		known := sets.New[string]()
+		for _, m := range featureGateRegexp.FindAllSubmatch(help, -1) {
+			known.Insert(string(m[1]))
+		}
+		for _, name := range sortedKeys(s.Options.FeatureGates) {
+			if known.Len() > 0 && !known.Has(name) {
+				errList = append(errList, fmt.Errorf("feature gate %q is not supported by %s", name, binary))
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(s.Options.RuntimeConfig) {
+		if !runtimeConfigRegexp.MatchString(key) {
+			errList = append(errList, fmt.Errorf("runtime config %q is neither a group version nor one of api/all, api/ga, api/beta and api/alpha", key))
+		}
+	}
+
+	if len(s.Options.EnableAdmissionPlugins) > 0 || len(s.Options.DisableAdmissionPlugins) > 0 {
+		known := sets.New[string]()
+		if m := admissionPluginsRegexp.FindSubmatch(flagHelp(help, "enable-admission-plugins")); m != nil {
+			for _, name := range strings.Split(string(m[1]), ",") {
+				known.Insert(strings.TrimSpace(name))
+			}
+		}
+		for _, name := range append(slices.Clone(s.Options.EnableAdmissionPlugins), s.Options.DisableAdmissionPlugins...) {
+			if known.Len() > 0 && !known.Has(name) {
+				errList = append(errList, fmt.Errorf("admission plugin %q is not supported by %s", name, binary))
+			}
+		}
+		for _, name := range s.Options.EnableAdmissionPlugins {
+			if slices.Contains(s.Options.DisableAdmissionPlugins, name) {
+				errList = append(errList, fmt.Errorf("admission plugin %q can't be both enabled and disabled", name))
+			}
+		}
+	}
+
+	if len(s.Options.AuthorizationModes) > 0 {
+		known := sets.New[string]()
+		if m := authorizationModesRegexp.FindSubmatch(flagHelp(help, "authorization-mode")); m != nil {
+			known.Insert(strings.Split(string(m[1]), ",")...)
+		}
+		for _, mode := range s.Options.AuthorizationModes {
+			if known.Len() > 0 && !known.Has(mode) {
+				errList = append(errList, fmt.Errorf("authorization mode %q is not supported by %s", mode, binary))
+			}
+		}
+	}
+
+	return kerrors.NewAggregate(errList)
+}
+
+// optionArgs returns the flags for the options, which replace the defaults of
+// the same flags.
+func (s *APIServer) optionArgs() map[string][]string {
+	args := map[string][]string{}
+	if len(s.Options.FeatureGates) > 0 {
+		args["feature-gates"] = []string{joinBools(s.Options.FeatureGates)}
+	}
+	if len(s.Options.RuntimeConfig) > 0 {
+		args["runtime-config"] = []string{joinBools(s.Options.RuntimeConfig)}
+	}
+	if len(s.Options.EnableAdmissionPlugins) > 0 {
+		args["enable-admission-plugins"] = []string{strings.Join(s.Options.EnableAdmissionPlugins, ",")}
+	}
+	if len(s.Options.EnableAdmissionPlugins) > 0 || len(s.Options.DisableAdmissionPlugins) > 0 {
+		disabled := []string{}
+		for _, name := range append([]string{"ServiceAccount"}, s.Options.DisableAdmissionPlugins...) {
+			if !slices.Contains(s.Options.EnableAdmissionPlugins, name) && !slices.Contains(disabled, name) {
+				disabled = append(disabled, name)
+			}
+		}
+		if len(disabled) > 0 {
+			args["disable-admission-plugins"] = []string{strings.Join(disabled, ",")}
+		} else {
+			args["disable-admission-plugins"] = nil
+		}
+	}
+	if len(s.Options.AuthorizationModes) > 0 {
+		args["authorization-mode"] = []string{strings.Join(s.Options.AuthorizationModes, ",")}
+	}
+	return args
+}
+
+// flagHelp returns the line of the help output describing the given flag.
+func flagHelp(help []byte, flag string) []byte {
+	prefix := []byte("--" + flag + " ")
+	for _, line := range bytes.Split(help, []byte("\n")) {
+		if bytes.HasPrefix(bytes.TrimSpace(line), prefix) {
+			return line
+		}
+	}
+	return nil
+}
+
+// joinBools formats a map as comma separated key=value pairs, sorted by key.
+func joinBools(m map[string]bool) string {
+	pairs := make([]string, 0, len(m))
+	for _, key := range sortedKeys(m) {
+		pairs = append(pairs, key+"="+strconv.FormatBool(m[key]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -220,6 +220,83 @@ var _ = Describe("APIServer", func() {
 		})
 	})
 
+	Describe("setting typed options", func() {
+		BeforeEach(func() {
+			server.Path = "./testdata/fake-1.20-apiserver.sh"
+			server.Options = APIServerOptions{
+				FeatureGates:           map[string]bool{"AllAlpha": false, "APIServerIdentity": true},
+				RuntimeConfig:          map[string]bool{"api/alpha": true, "batch/v2alpha1": false},
+				EnableAdmissionPlugins: []string{"ServiceAccount", "AlwaysPullImages"},
+				AuthorizationModes:     []string{AuthorizationModeNode, AuthorizationModeRBAC},
+			}
+		})
+
+		It("should pass them as flags", func() {
+			Expect(APIServerArguments(server)).To(ContainElements(
+				"--feature-gates=APIServerIdentity=true,AllAlpha=false",
+				"--runtime-config=api/alpha=true,batch/v2alpha1=false",
+				"--enable-admission-plugins=ServiceAccount,AlwaysPullImages",
+				"--authorization-mode=Node,RBAC",
+			))
+		})
+
+		It("should not disable the ServiceAccount admission plugin when it is enabled", func() {
+			Expect(APIServerArguments(server)).NotTo(ContainElement(HavePrefix("--disable-admission-plugins")))
+		})
+
+		It("should keep the ServiceAccount admission plugin disabled when disabling others", func() {
+			server := &APIServer{
+				EtcdURL: &url.URL{},
+				Path:    "./testdata/fake-1.20-apiserver.sh",
+				Options: APIServerOptions{DisableAdmissionPlugins: []string{"DefaultStorageClass"}},
+			}
+			Expect(PrepareAPIServer(server)).To(Succeed())
+			Expect(APIServerArguments(server)).To(ContainElement("--disable-admission-plugins=ServiceAccount,DefaultStorageClass"))
+		})
+
+		It("should let flags set with Configure replace them", func() {
+			server := &APIServer{
+				EtcdURL: &url.URL{},
+				Path:    "./testdata/fake-1.20-apiserver.sh",
+				Options: APIServerOptions{AuthorizationModes: []string{AuthorizationModeRBAC}},
+			}
+			server.Configure().Set("authorization-mode", "AlwaysAllow")
+			Expect(PrepareAPIServer(server)).To(Succeed())
+			Expect(APIServerArguments(server)).To(ContainElement("--authorization-mode=AlwaysAllow"))
+			Expect(APIServerArguments(server)).NotTo(ContainElement("--authorization-mode=RBAC"))
+		})
+
+		It("should fail for options the binary doesn't support", func() {
+			server := &APIServer{
+				EtcdURL: &url.URL{},
+				Path:    "./testdata/fake-1.20-apiserver.sh",
+				Options: APIServerOptions{
+					FeatureGates:            map[string]bool{"DoesNotExist": true},
+					RuntimeConfig:           map[string]bool{"not a group version": true},
+					DisableAdmissionPlugins: []string{"NotAPlugin"},
+					AuthorizationModes:      []string{"Magic"},
+				},
+			}
+			err := PrepareAPIServer(server)
+			Expect(err).To(MatchError(ContainSubstring(`feature gate "DoesNotExist" is not supported by kube-apiserver`)))
+			Expect(err).To(MatchError(ContainSubstring(`runtime config "not a group version"`)))
+			Expect(err).To(MatchError(ContainSubstring(`admission plugin "NotAPlugin" is not supported`)))
+			Expect(err).To(MatchError(ContainSubstring(`authorization mode "Magic" is not supported`)))
+		})
+
+		It("should fail for admission plugins that are both enabled and disabled", func() {
+			server := &APIServer{
+				EtcdURL: &url.URL{},
+				Path:    "./testdata/fake-1.20-apiserver.sh",
+				Options: APIServerOptions{
+					EnableAdmissionPlugins:  []string{"AlwaysPullImages"},
+					DisableAdmissionPlugins: []string{"AlwaysPullImages"},
+				},
+			}
+			Expect(PrepareAPIServer(server)).To(MatchError(ContainSubstring(`admission plugin "AlwaysPullImages" can't be both enabled and disabled`)))
+		})
+	})
+
 	Describe("managing", func() {
 		// some of these tests are combined for speed reasons -- starting the apiserver
 		// takes a while, relatively speaking
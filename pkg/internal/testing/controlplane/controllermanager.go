@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/internal/testing/addr"
+	"sigs.k8s.io/controller-runtime/pkg/internal/testing/process"
+)
+
+// ControllerManager knows how to run a kube-controller-manager, to run some of
+// the controllers of a real cluster against the API server.
+type ControllerManager struct {
+	// Controllers are the names of the controllers to run, as accepted by the
+	// --controllers flag of kube-controller-manager (e.g. "namespace",
+	// "garbagecollector" or "serviceaccount-token").
+	//
+	// If this is not specified, the default controllers of kube-controller-manager
+	// are run, many of which won't do anything useful without nodes or a scheduler.
+	Controllers []string
+
+	// SecureServing indicates how kube-controller-manager will serve its health
+	// checks.
+	//
+	// If this is not specified, we default to a random free port on localhost.
+	SecureServing process.ListenAddr
+
+	// Path is the path to the kube-controller-manager binary.
+	//
+	// If this is left as the empty string, we will attempt to locate a binary,
+	// by checking for the TEST_ASSET_KUBE_CONTROLLER_MANAGER environment variable,
+	// and the default test assets directory. See the "Binaries" section above (in
+	// doc.go) for details.
+	Path string
+
+	// CertDir is a path to a directory containing the kubeconfig, the
+	// certificates, and the serving certificates kube-controller-manager
+	// generates for itself.
+	//
+	// If left unspecified, then the Start() method will create a fresh temporary
+	// directory, and the Stop() method will clean it up.
+	CertDir string
+
+	// KubeConfig is the kubeconfig kube-controller-manager uses to connect to
+	// the API server.
+	//
+	// If this is not specified, the Start() method will return an error.
+	// ControlPlane.Start populates it with a dedicated admin user.
+	KubeConfig []byte
+
+	// ServiceAccountKeyFile is the path to the private key used to sign service
+	// account tokens, and RootCA is the CA of the API server included in their
+	// secrets.  Both are needed by the serviceaccount-token controller.
+	//
+	// ControlPlane.Start populates them from its APIServer.
+	ServiceAccountKeyFile string
+	RootCA                []byte
+
+	// StartTimeout, StopTimeout specify the time kube-controller-manager is
+	// allowed to take when starting and stopping before an error is emitted.
+	//
+	// If not specified, these default to 20 seconds.
+	StartTimeout time.Duration
+	StopTimeout  time.Duration
+
+	// Out, Err specify where kube-controller-manager should write its StdOut,
+	// StdErr to.
+	//
+	// If not specified, the output will be discarded.
+	Out io.Writer
+	Err io.Writer
+
+	processState *process.State
+
+	// args contains the structured arguments to use for running
+	// kube-controller-manager.  Lazily initialized by .Configure(), Defaulted
+	// eventually with .defaultArgs()
+	args *process.Arguments
+}
+
+// Configure returns Arguments that may be used to customize the
+// flags used to launch kube-controller-manager.  A set of defaults will
+// be applied underneath.
+func (m *ControllerManager) Configure() *process.Arguments {
+	if m.args == nil {
+		m.args = process.EmptyArguments()
+	}
+	return m.args
+}
+
+// Start starts kube-controller-manager, waits for it to come up, and returns
+// an error, if occurred.
+func (m *ControllerManager) Start() error {
+	if err := m.setProcessState(); err != nil {
+		return err
+	}
+	return m.processState.Start(m.Out, m.Err)
+}
+
+func (m *ControllerManager) setProcessState() error {
+	if len(m.KubeConfig) == 0 {
+		return fmt.Errorf("expected KubeConfig to be configured")
+	}
+
+	m.processState = &process.State{
+		Dir:          m.CertDir,
+		Path:         m.Path,
+		StartTimeout: m.StartTimeout,
+		StopTimeout:  m.StopTimeout,
+	}
+	if err := m.processState.Init("kube-controller-manager"); err != nil {
+		return err
+	}
+
+	if m.SecureServing.Port == "" || m.SecureServing.Address == "" {
+		port, host, err := addr.Suggest("")
+		if err != nil {
+			return fmt.Errorf("unable to provision unused secure port: %w", err)
+		}
+		m.SecureServing.Port = strconv.Itoa(port)
+		m.SecureServing.Address = host
+	}
+	m.processState.HealthCheck.URL = *m.SecureServing.URL("https", "/healthz")
+
+	m.CertDir = m.processState.Dir
+	m.Path = m.processState.Path
+	m.StartTimeout = m.processState.StartTimeout
+	m.StopTimeout = m.processState.StopTimeout
+
+	if err := os.WriteFile(m.kubeConfigPath(), m.KubeConfig, 0600); err != nil {
+		return fmt.Errorf("unable to write kubeconfig: %w", err)
+	}
+	if len(m.RootCA) > 0 {
+		if err := os.WriteFile(m.rootCAPath(), m.RootCA, 0640); err != nil { //nolint:gosec
+			return fmt.Errorf("unable to write root CA: %w", err)
+		}
+	}
+
+	var err error
+	m.processState.Args, _, err = process.TemplateAndArguments(nil, m.Configure(), process.TemplateDefaults{
+		Data:     m,
+		Defaults: m.defaultArgs(),
+	})
+	return err
+}
+
+func (m *ControllerManager) kubeConfigPath() string {
+	return filepath.Join(m.CertDir, "kubeconfig")
+}
+
+func (m *ControllerManager) rootCAPath() string {
+	return filepath.Join(m.CertDir, "root-ca.crt")
+}
+
+func (m *ControllerManager) defaultArgs() map[string][]string {
+	args := map[string][]string{
+		"kubeconfig":                {m.kubeConfigPath()},
+		"authentication-kubeconfig": {m.kubeConfigPath()},
+		"authorization-kubeconfig":  {m.kubeConfigPath()},
+		"leader-elect":              {"false"},
+		"cert-dir":                  {m.CertDir},
+		"secure-port":               {m.SecureServing.Port},
+		"bind-address":              {m.SecureServing.Address},
+	}
+	if len(m.Controllers) > 0 {
+		args["controllers"] = []string{strings.Join(m.Controllers, ",")}
+	}
+	if m.ServiceAccountKeyFile != "" {
+		args["service-account-private-key-file"] = []string{m.ServiceAccountKeyFile}
+	}
+	if len(m.RootCA) > 0 {
+		args["root-ca-file"] = []string{m.rootCAPath()}
+	}
+	return args
+}
+
+// Stop stops this process gracefully, waits for its termination, and cleans up
+// the CertDir if necessary.
+func (m *ControllerManager) Stop() error {
+	if m.processState == nil {
+		return nil
+	}
+	if m.processState.DirNeedsCleaning {
+		m.CertDir = "" // reset the directory if it was randomly allocated, so that we can safely restart
+	}
+	return m.processState.Stop()
+}
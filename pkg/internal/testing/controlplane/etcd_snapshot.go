@@ -0,0 +1,211 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// etcdRegistryPrefix is the prefix the API server stores its objects under.
+	etcdRegistryPrefix = "/registry/"
+
+	// etcdPageSize is the number of keys read from etcd at once.
+	etcdPageSize = 500
+
+	// etcdMaxTxnOps is the default maximum number of operations etcd allows
+	// in a single transaction.
+	etcdMaxTxnOps = 128
+)
+
+// etcdUnrestoredPrefixes are the keys the API server keeps updating on its
+// own, which would conflict with those updates if restored.
+var etcdUnrestoredPrefixes = []string{
+	etcdRegistryPrefix + "masterleases/",
+	etcdRegistryPrefix + "leases/kube-system/apiserver-",
+}
+
+// EtcdSnapshot is a copy of the objects the API server stored in etcd at a
+// point in time.
+type EtcdSnapshot struct {
+	kvs map[string][]byte
+}
+
+// Len returns the number of objects in the snapshot.
+func (s *EtcdSnapshot) Len() int {
+	return len(s.kvs)
+}
+
+// Snapshot copies the objects the API server stored in this etcd.
+//
+// It uses the JSON gateway of etcd, so it doesn't need etcdctl, and only
+// works while etcd is running.
+func (e *Etcd) Snapshot() (*EtcdSnapshot, error) {
+	kvs, err := e.readRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("unable to snapshot etcd: %w", err)
+	}
+	return &EtcdSnapshot{kvs: kvs}, nil
+}
+
+// Restore reverts the objects the API server stored in this etcd to the
+// given snapshot, deleting the objects created since and putting back the
+// ones modified or deleted since.
+//
+// Unlike restoring an etcd snapshot or data directory, this doesn't move the
+// revision of etcd backwards: the changes are applied as new revisions, so
+// the API server and its watch caches see them like any other change, and
+// don't need to be restarted.  Restored objects get new resource versions.
+func (e *Etcd) Restore(s *EtcdSnapshot) error {
+	current, err := e.readRegistry()
+	if err != nil {
+		return fmt.Errorf("unable to restore etcd: %w", err)
+	}
+
+	var ops []etcdRequestOp
+	for key := range current {
+		if _, ok := s.kvs[key]; !ok {
+			ops = append(ops, etcdRequestOp{RequestDeleteRange: &etcdKeyValue{Key: []byte(key)}})
+		}
+	}
+	for key, value := range s.kvs {
+		if currentValue, ok := current[key]; !ok || !bytes.Equal(currentValue, value) {
+			ops = append(ops, etcdRequestOp{RequestPut: &etcdKeyValue{Key: []byte(key), Value: value}})
+		}
+	}
+
+	for len(ops) > 0 {
+		batch := ops[:min(len(ops), etcdMaxTxnOps)]
+		ops = ops[len(batch):]
+		if err := e.gatewayCall("/v3/kv/txn", etcdTxnRequest{Success: batch}, nil); err != nil {
+			return fmt.Errorf("unable to restore etcd: %w", err)
+		}
+	}
+	return nil
+}
+
+// readRegistry reads all restorable keys stored by the API server.
+func (e *Etcd) readRegistry() (map[string][]byte, error) {
+	kvs := map[string][]byte{}
+	req := etcdRangeRequest{
+		Key:      []byte(etcdRegistryPrefix),
+		RangeEnd: prefixRangeEnd(etcdRegistryPrefix),
+		Limit:    etcdPageSize,
+	}
+	for {
+		resp := etcdRangeResponse{}
+		if err := e.gatewayCall("/v3/kv/range", req, &resp); err != nil {
+			return nil, err
+		}
+		for _, kv := range resp.KVs {
+			if restorableKey(string(kv.Key)) {
+				kvs[string(kv.Key)] = kv.Value
+			}
+		}
+		if !resp.More || len(resp.KVs) == 0 {
+			return kvs, nil
+		}
+		// continue right after the last key we got.
+		req.Key = append(resp.KVs[len(resp.KVs)-1].Key, 0)
+	}
+}
+
+// gatewayCall posts req to the given path of the JSON gateway of etcd, and
+// decodes the response into resp, if non-nil.
+func (e *Etcd) gatewayCall(path string, req, resp interface{}) error {
+	if e.URL == nil {
+		return fmt.Errorf("etcd is not running")
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	httpResp, err := client.Post(e.URL.JoinPath(path).String(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("etcd returned %s for %s: %s", httpResp.Status, path, data)
+	}
+	if resp == nil {
+		return nil
+	}
+	return json.Unmarshal(data, resp)
+}
+
+func restorableKey(key string) bool {
+	for _, prefix := range etcdUnrestoredPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// prefixRangeEnd returns the end of the etcd key range containing all keys
+// with the given prefix.
+func prefixRangeEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// the prefix is all 0xff, so the range goes to the end of the keyspace.
+	return []byte{0}
+}
+
+// The following types are the JSON representations of the etcd API messages
+// we use.  Byte slices are base64 encoded, as expected by the gateway.
+
+type etcdKeyValue struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value,omitempty"`
+}
+
+type etcdRangeRequest struct {
+	Key      []byte `json:"key"`
+	RangeEnd []byte `json:"range_end,omitempty"`
+	Limit    int64  `json:"limit,omitempty"`
+}
+
+type etcdRangeResponse struct {
+	KVs  []etcdKeyValue `json:"kvs,omitempty"`
+	More bool           `json:"more,omitempty"`
+}
+
+type etcdRequestOp struct {
+	RequestPut         *etcdKeyValue `json:"request_put,omitempty"`
+	RequestDeleteRange *etcdKeyValue `json:"request_delete_range,omitempty"`
+}
+
+type etcdTxnRequest struct {
+	Success []etcdRequestOp `json:"success"`
+}
@@ -20,10 +20,12 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/internal/testing/certs"
+	"sigs.k8s.io/controller-runtime/pkg/internal/testing/process"
 )
 
 // NewTinyCA creates a new a tiny CA utility for provisioning serving certs and client certs FOR TESTING ONLY.
@@ -32,12 +34,16 @@ var NewTinyCA = certs.NewTinyCA
 
 // ControlPlane is a struct that knows how to start your test control plane.
 //
-// Right now, that means Etcd and your APIServer. This is likely to increase in
-// future.
+// Right now, that means Etcd and your APIServer, and optionally a
+// ControllerManager. This is likely to increase in future.
 type ControlPlane struct {
 	APIServer *APIServer
 	Etcd      *Etcd
 
+	// ControllerManager is started after the APIServer if set, to run some
+	// of the kube-controller-manager controllers.
+	ControllerManager *ControllerManager
+
 	// Kubectl will override the default asset search path for kubectl
 	KubectlPath string
 
@@ -86,6 +92,33 @@ func (f *ControlPlane) Start() (retErr error) {
 	}
 	f.defaultUserCfg = user.Config()
 	f.defaultUserKubectl = kubectl
+
+	if f.ControllerManager != nil {
+		if err := f.startControllerManager(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startControllerManager starts the ControllerManager against the APIServer,
+// as a dedicated admin user.
+func (f *ControlPlane) startControllerManager() error {
+	user, err := f.AddUser(User{Name: "kube-controller-manager", Groups: []string{"system:masters"}}, &rest.Config{})
+	if err != nil {
+		return fmt.Errorf("unable to provision the kube-controller-manager user: %w", err)
+	}
+	kubeConfig, err := user.KubeConfig()
+	if err != nil {
+		return fmt.Errorf("unable to provision the kube-controller-manager kubeconfig: %w", err)
+	}
+	f.ControllerManager.KubeConfig = kubeConfig
+	f.ControllerManager.ServiceAccountKeyFile = filepath.Join(f.APIServer.CertDir, saKeyFile)
+	f.ControllerManager.RootCA = f.APIServer.SecureServing.CA
+	if err := f.ControllerManager.Start(); err != nil {
+		_ = f.ControllerManager.Stop()
+		return err
+	}
 	return nil
 }
 
@@ -93,6 +126,12 @@ func (f *ControlPlane) Start() (retErr error) {
 func (f *ControlPlane) Stop() error {
 	var errList []error
 
+	if f.ControllerManager != nil {
+		if err := f.ControllerManager.Stop(); err != nil {
+			errList = append(errList, err)
+		}
+	}
+
 	if f.APIServer != nil {
 		if err := f.APIServer.Stop(); err != nil {
 			errList = append(errList, err)
@@ -257,3 +296,34 @@ func (f *ControlPlane) GetAPIServer() *APIServer {
 	}
 	return f.APIServer
 }
+
+// Processes is an internal-only (NEVER SHOULD BE EXPOSED) function that
+// returns the process IDs of the running control plane components, and the
+// temporary directories that need to be removed once they are stopped.  It
+// allows another process to stop a control plane that it didn't start.
+//
+// NB: do not expose this outside of internal.
+func Processes(f *ControlPlane) (pids []int, dirs []string) {
+	var states []*process.State
+	if f.Etcd != nil {
+		states = append(states, f.Etcd.processState)
+	}
+	if f.APIServer != nil {
+		states = append(states, f.APIServer.processState)
+	}
+	if f.ControllerManager != nil {
+		states = append(states, f.ControllerManager.processState)
+	}
+	for _, state := range states {
+		if state == nil {
+			continue
+		}
+		if state.Cmd != nil && state.Cmd.Process != nil {
+			pids = append(pids, state.Cmd.Process.Pid)
+		}
+		if state.DirNeedsCleaning {
+			dirs = append(dirs, state.Dir)
+		}
+	}
+	return pids, dirs
+}
@@ -18,13 +18,19 @@ package controlplane_test
 
 import (
 	"context"
+	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	kauthn "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	. "sigs.k8s.io/controller-runtime/pkg/internal/testing/controlplane"
+	"sigs.k8s.io/controller-runtime/pkg/internal/testing/process"
 )
 
 var _ = Describe("Control Plane", func() {
@@ -47,6 +53,35 @@ var _ = Describe("Control Plane", func() {
 		Expect(plane.Etcd).To(BeIdenticalTo(etcd))
 	})
 
+	It("should run the given kube-controller-manager controllers", func() {
+		if _, err := os.Stat(process.BinPathFinder("kube-controller-manager", "")); err != nil {
+			Skip("kube-controller-manager binary is not available")
+		}
+		ctx := context.Background()
+		plane := &ControlPlane{
+			ControllerManager: &ControllerManager{Controllers: []string{"namespace"}},
+		}
+		Expect(plane.Start()).To(Succeed())
+		defer func() { Expect(plane.Stop()).To(Succeed()) }()
+
+		cfg, err := plane.RESTClientConfig()
+		Expect(err).NotTo(HaveOccurred())
+		cl, err := client.New(cfg, client.Options{})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("deleting a namespace with contents")
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "to-delete"}}
+		Expect(cl.Create(ctx, ns)).To(Succeed())
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name, Name: "contents"}}
+		Expect(cl.Create(ctx, cm)).To(Succeed())
+		Expect(cl.Delete(ctx, ns)).To(Succeed())
+
+		By("waiting for the namespace controller to finalize it")
+		Eventually(func() bool {
+			return apierrors.IsNotFound(cl.Get(ctx, client.ObjectKeyFromObject(ns), ns))
+		}, 30*time.Second).Should(BeTrue())
+	})
+
 	It("should be able to restart", func() {
 		// NB(directxman12): currently restarting invalidates all current users
 		// when using CertAuthn.  We need to support restarting as per our previous
@@ -93,6 +128,38 @@ var _ = Describe("Control Plane", func() {
 			Expect(sar.Status.Allowed).To(BeTrue(), "admin user should be able to do everything")
 		})
 
+		It("should restore the objects of a snapshot", func() {
+			ctx := context.Background()
+			cfg, err := plane.RESTClientConfig()
+			Expect(err).NotTo(HaveOccurred())
+			cl, err := client.New(cfg, client.Options{})
+			Expect(err).NotTo(HaveOccurred())
+
+			kept := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kept"}, Data: map[string]string{"a": "b"}}
+			deleted := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "deleted"}}
+			Expect(cl.Create(ctx, kept)).To(Succeed())
+			Expect(cl.Create(ctx, deleted)).To(Succeed())
+
+			By("taking a snapshot")
+			snapshot, err := plane.Etcd.Snapshot()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot.Len()).To(BeNumerically(">", 2))
+
+			By("changing objects after the snapshot")
+			kept.Data["a"] = "c"
+			Expect(cl.Update(ctx, kept)).To(Succeed())
+			Expect(cl.Delete(ctx, deleted)).To(Succeed())
+			created := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "created"}}
+			Expect(cl.Create(ctx, created)).To(Succeed())
+
+			By("restoring the snapshot")
+			Expect(plane.Etcd.Restore(snapshot)).To(Succeed())
+			Expect(cl.Get(ctx, client.ObjectKeyFromObject(kept), kept)).To(Succeed())
+			Expect(kept.Data).To(Equal(map[string]string{"a": "b"}))
+			Expect(cl.Get(ctx, client.ObjectKeyFromObject(deleted), deleted)).To(Succeed())
+			Expect(apierrors.IsNotFound(cl.Get(ctx, client.ObjectKeyFromObject(created), created))).To(BeTrue())
+		})
+
 		// TODO(directxman12): more explicit tests for AddUser -- it's tested indirectly via the
 		// legacy user flow, but we should be explicit
 
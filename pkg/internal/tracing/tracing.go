@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"sigs.k8s.io/controller-runtime/pkg/tracing"
+)
+
+// ScopeName is the instrumentation scope of all spans created by controller-runtime.
+const ScopeName = "sigs.k8s.io/controller-runtime"
+
+// Attribute keys used on spans.
+const (
+	GroupKey     = attribute.Key("k8s.group")
+	VersionKey   = attribute.Key("k8s.version")
+	KindKey      = attribute.Key("k8s.kind")
+	NamespaceKey = attribute.Key("k8s.namespace")
+	NameKey      = attribute.Key("k8s.name")
+)
+
+// Tracer returns a tracer of the given provider, or a no-op tracer if the
+// provider is nil.
+func Tracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+	return tp.Tracer(ScopeName)
+}
+
+// TracerFromContext returns a tracer of the provider that created the span in
+// ctx. Child spans are thus only recorded within a span, e.g. a reconcile.
+func TracerFromContext(ctx context.Context) trace.Tracer {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(ScopeName)
+}
+
+// GVKAttributes returns the attributes describing the given GVK.
+func GVKAttributes(gvk schema.GroupVersionKind) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		GroupKey.String(gvk.Group),
+		VersionKey.String(gvk.Version),
+		KindKey.String(gvk.Kind),
+	}
+}
+
+// LinkObject links the given spans to the trace context stored in the
+// annotation of obj, if any.
+func LinkObject(obj metav1.Object, spans ...trace.Span) {
+	sc := tracing.SpanContextFromObject(obj)
+	if !sc.IsValid() {
+		return
+	}
+	for _, span := range spans {
+		span.AddLink(trace.Link{SpanContext: sc})
+	}
+}
+
+// End records err on span, if not nil, and ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// ExtractHTTP returns a context that carries the trace context of the
+// incoming request's headers.
+func ExtractHTTP(r *http.Request) context.Context {
+	return propagation.TraceContext{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+}
+
+// Handler wraps h so that every request creates a server span with the given
+// name. The span continues the trace context of the request's headers, if any.
+// If tp is nil, h is returned as is.
+func Handler(tp trace.TracerProvider, name string, h http.Handler) http.Handler {
+	if tp == nil {
+		return h
+	}
+	tracer := Tracer(tp)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(ExtractHTTP(r), name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("http.route", r.URL.Path)),
+		)
+		defer span.End()
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
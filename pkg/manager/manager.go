@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
 	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -95,6 +96,10 @@ type Manager interface {
 
 	// GetControllerOptions returns controller global configuration options.
 	GetControllerOptions() config.Controller
+
+	// GetTracerProvider returns the OpenTelemetry tracer provider of this
+	// manager. It is nil if tracing is disabled.
+	GetTracerProvider() trace.TracerProvider
 }
 
 // Options are the arguments for creating a new Manager.
@@ -246,6 +251,13 @@ type Options struct {
 	// If this is set, the Manager will use this server instead.
 	WebhookServer webhook.Server
 
+	// TracerProvider enables OpenTelemetry tracing if set. Controllers then
+	// create a span for every reconcile and the default webhook server
+	// creates a span for every request. Client calls made with the context
+	// of a traced reconcile or webhook request create child spans.
+	// Defaults to nil, which disables tracing.
+	TracerProvider trace.TracerProvider
+
 	// BaseContext is the function that provides Context values to Runnables
 	// managed by the Manager. If a BaseContext function isn't provided, Runnables
 	// will receive a new Background Context instead.
@@ -427,6 +439,7 @@ func New(config *rest.Config, options Options) (Manager, error) {
 		metricsServer:                 metricsServer,
 		controllerConfig:              options.Controller,
 		logger:                        options.Logger,
+		tracerProvider:                options.TracerProvider,
 		elected:                       make(chan struct{}),
 		webhookServer:                 options.WebhookServer,
 		leaderElectionID:              options.LeaderElectionID,
@@ -548,7 +561,9 @@ func setOptionsDefaults(options Options) Options {
 	}
 
 	if options.WebhookServer == nil {
-		options.WebhookServer = webhook.NewServer(webhook.Options{})
+		options.WebhookServer = webhook.NewServer(webhook.Options{
+			TracerProvider: options.TracerProvider,
+		})
 	}
 
 	return options
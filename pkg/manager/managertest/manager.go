@@ -0,0 +1,77 @@
+/*
+2024 Kubernetes Yazarları.
+
+Apache Lisansı, Sürüm 2.0 ("Lisans") uyarınca lisanslanmıştır;
+bu dosyayı ancak Lisans uyarınca kullanabilirsiniz.
+Lisansın bir kopyasını aşağıdaki adreste bulabilirsiniz:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Geçerli yasa tarafından gerekli kılınmadıkça veya yazılı olarak kabul edilmedikçe,
+Lisans kapsamında dağıtılan yazılım "OLDUĞU GİBİ" dağıtılır,
+HERHANGİ BİR GARANTİ VEYA KOŞUL OLMADAN, açık veya zımni.
+Lisans kapsamında izin verilen belirli dil kapsamındaki haklar ve
+sınırlamalar için Lisansa bakınız.
+*/
+
+// Paket managertest, bir API sunucusu olmadan çalışan yöneticiler oluşturarak
+// kontrolcülerin birim testlerde uçtan uca test edilmesini sağlar.
+package managertest
+
+import (
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// New, istemcisi, önbelleği ve RESTMapper'ı verilen istemciye dayanan bir
+// yönetici döndürür. Böylece Owns ve Watches kullanan kontrolcüler dahil
+// eksiksiz bir kontrolcü, envtest olmadan sıradan bir go test içinde
+// milisaniyeler içinde çalıştırılabilir:
+//
+//	c := fake.NewClientBuilder().WithWatchHistory(0).WithObjects(objs...).Build()
+//	mgr, err := managertest.New(c, manager.Options{})
+//
+// Önbelleğin bilgilendiricileri nesneleri verilen istemci üzerinden listeler ve
+// izler, bkz. cache.NewFromClient. İstemci genellikle sahte bir istemcidir ve
+// izlemelerin bilgilendiricilerin listelediği resourceVersion'dan başlayıp hiçbir
+// olayı kaçırmaması için fake.ClientBuilder.WithWatchHistory ile oluşturulmalıdır.
+//
+// RESTMapper, istemcinin RESTMapper'ında bulunmayan türleri istemcinin
+// şemasından eşler. Bu türlerin kapsamı yerleşik türler için bilinir, diğerleri
+// isim alanına bağlı kabul edilir. Küme kapsamlı özel kaynaklar için istemci
+// fake.ClientBuilder.WithCRDs veya WithRESTMapper ile oluşturulmalıdır.
+//
+// Yöneticinin istemcisi verilen istemcinin kendisidir, okumalar önbellek yerine
+// doğrudan istemciye gider. Scheme, MapperProvider, NewCache ve NewClient
+// seçenekleri her zaman geçersiz kılınır. Metrik sunucusu açıkça bir adres
+// belirtilmedikçe devre dışıdır ve aynı isimdeki kontrolcülerin birden fazla
+// testte kullanılabilmesi için kontrolcü isim doğrulaması varsayılan olarak atlanır.
+// Olay kaydedicilerinin yayınladığı olaylar bir API sunucusu olmadığından kaybolur.
+func New(c client.WithWatch, opts manager.Options) (manager.Manager, error) {
+	opts.Scheme = c.Scheme()
+	opts.MapperProvider = func(*rest.Config, *http.Client) (meta.RESTMapper, error) {
+		return meta.MultiRESTMapper{c.RESTMapper(), testrestmapper.TestOnlyStaticRESTMapper(c.Scheme())}, nil
+	}
+	opts.NewCache = func(_ *rest.Config, cacheOpts cache.Options) (cache.Cache, error) {
+		return cache.NewFromClient(c, cacheOpts)
+	}
+	opts.NewClient = func(*rest.Config, client.Options) (client.Client, error) {
+		return c, nil
+	}
+	if opts.Metrics.BindAddress == "" {
+		opts.Metrics.BindAddress = "0"
+	}
+	if opts.Controller.SkipNameValidation == nil {
+		opts.Controller.SkipNameValidation = ptr.To(true)
+	}
+
+	return manager.New(&rest.Config{Host: "http://managertest.invalid"}, opts)
+}
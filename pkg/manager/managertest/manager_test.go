@@ -0,0 +1,133 @@
+/*
+2024 Kubernetes Yazarları.
+
+Apache Lisansı, Sürüm 2.0 ("Lisans") uyarınca lisanslanmıştır;
+bu dosyayı ancak Lisans uyarınca kullanabilirsiniz.
+Lisansın bir kopyasını aşağıdaki adreste bulabilirsiniz:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Geçerli yasa tarafından gerekli kılınmadıkça veya yazılı olarak kabul edilmedikçe,
+Lisans kapsamında dağıtılan yazılım "OLDUĞU GİBİ" dağıtılır,
+HERHANGİ BİR GARANTİ VEYA KOŞUL OLMADAN, açık veya zımni.
+Lisans kapsamında izin verilen belirli dil kapsamındaki haklar ve
+sınırlamalar için Lisansa bakınız.
+*/
+
+package managertest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/manager/managertest"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// podCreator, her ReplicaSet için sahip olunan tek bir Pod oluşturur ve
+// ReplicaSet ile aynı isimli ConfigMap'in verisini Pod'un etiketlerine kopyalar.
+type podCreator struct {
+	client client.Client
+}
+
+func (r *podCreator) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	rs := &appsv1.ReplicaSet{}
+	if err := r.client.Get(ctx, req.NamespacedName, rs); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := r.client.Get(ctx, req.NamespacedName, cm); err != nil && !apierrors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: rs.Namespace, Name: rs.Name}}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.client, pod, func() error {
+		pod.Labels = cm.Data
+		pod.Spec.Containers = []corev1.Container{{Name: "app", Image: "app"}}
+		return controllerutil.SetControllerReference(rs, pod, r.client.Scheme())
+	})
+	return reconcile.Result{}, err
+}
+
+func TestNew(t *testing.T) {
+	g := NewWithT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	existing := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "existing"}}
+	c := fake.NewClientBuilder().WithWatchHistory(0).WithObjects(existing).Build()
+
+	mgr, err := managertest.New(c, manager.Options{})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	err = builder.ControllerManagedBy(mgr).
+		For(&appsv1.ReplicaSet{}).
+		Owns(&corev1.Pod{}).
+		Watches(&corev1.ConfigMap{}, &handler.EnqueueRequestForObject{}).
+		Complete(&podCreator{client: mgr.GetClient()})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(mgr.GetFieldIndexer().IndexField(ctx, &corev1.Pod{}, "owner", func(o client.Object) []string {
+		if ref := metav1.GetControllerOf(o); ref != nil {
+			return []string{ref.Name}
+		}
+		return nil
+	})).To(Succeed())
+
+	done := make(chan error)
+	go func() {
+		done <- mgr.Start(ctx)
+	}()
+	g.Expect(mgr.GetCache().WaitForCacheSync(ctx)).To(BeTrue())
+
+	pod := &corev1.Pod{}
+	g.Eventually(func() error {
+		return c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "existing"}, pod)
+	}).WithTimeout(5 * time.Second).Should(Succeed())
+
+	created := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "created"}}
+	g.Expect(c.Create(ctx, created)).To(Succeed())
+	g.Eventually(func() error {
+		return c.Get(ctx, client.ObjectKeyFromObject(created), pod)
+	}).WithTimeout(5 * time.Second).Should(Succeed())
+
+	// Owns: silinen Pod yeniden oluşturulmalı.
+	g.Expect(c.Delete(ctx, pod)).To(Succeed())
+	g.Eventually(func() error {
+		return c.Get(ctx, client.ObjectKeyFromObject(created), pod)
+	}).WithTimeout(5 * time.Second).Should(Succeed())
+
+	// Watches: ConfigMap değişiklikleri ReplicaSet'i uzlaştırmalı.
+	g.Expect(c.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "created"},
+		Data:       map[string]string{"color": "blue"},
+	})).To(Succeed())
+	g.Eventually(func() map[string]string {
+		g.Expect(c.Get(ctx, client.ObjectKeyFromObject(created), pod)).To(Succeed())
+		return pod.Labels
+	}).WithTimeout(5 * time.Second).Should(HaveKeyWithValue("color", "blue"))
+
+	// Önbellek, alan indeksleriyle birlikte sahte istemcideki nesneleri yansıtmalı.
+	pods := &corev1.PodList{}
+	g.Eventually(func() []corev1.Pod {
+		g.Expect(mgr.GetCache().List(ctx, pods, client.MatchingFields{"owner": "created"})).To(Succeed())
+		return pods.Items
+	}).WithTimeout(5 * time.Second).Should(HaveLen(1))
+
+	cancel()
+	g.Eventually(done).WithTimeout(5 * time.Second).Should(Receive(BeNil()))
+}
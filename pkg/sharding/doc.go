@@ -0,0 +1,52 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package sharding allows horizontally scaling controllers by splitting the
+objects of a kind into a fixed number of shards and distributing those shards
+across all manager replicas.
+
+Replicas discover each other through a ring of coordination.k8s.io Leases: each
+replica renews a membership Lease and computes the shards it should own using
+rendezvous hashing over all live members. Ownership of every shard is guarded by
+a dedicated shard Lease, which a replica only releases once it has finished all
+in-flight reconciles for that shard. A replica that joins or takes over a shard
+therefore never reconciles an object concurrently with the previous owner.
+
+An object is assigned to a shard either by hashing its namespace and name, or,
+if Options.Label is set, by reading the shard number from that label. In label
+mode ApplyToCache restricts the cache to labelled objects.
+
+A Ring is a Runnable and must be added to the manager. Replicas that use
+sharding must not use leader election for their controllers. The builder wires a
+Ring into a controller through WithSharding:
+
+	ring, err := sharding.New(mgr, sharding.Options{Name: "foo-controller", Namespace: "foo-system"})
+	if err != nil { ... }
+	if err := mgr.Add(ring); err != nil { ... }
+
+	err = builder.ControllerManagedBy(mgr).
+		For(&foov1.Foo{}).
+		WithSharding(ring).
+		Complete(r)
+*/
+package sharding
+
+import (
+	logf "sigs.k8s.io/controller-runtime/pkg/internal/log"
+)
+
+var log = logf.RuntimeLog.WithName("sharding")
@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/workqueue"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Predicate returns a predicate that only admits events for objects in
+// shards owned by this replica.
+func (r *Ring) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		shard, ok := r.ShardFor(obj)
+		return ok && r.Owns(shard)
+	})
+}
+
+// Reconciler wraps the given reconciler so that it only reconciles requests
+// for objects in shards owned by this replica, and so that a shard is only
+// handed off to another replica once all its in-flight reconciles are done.
+//
+// In label mode the shard of a request is determined by reading an object of
+// the same type as obj through the given reader, which should be the cache
+// the controller watches. Requests for objects that can't be found are always
+// reconciled, so that deletions are not missed. In hash mode reader and obj
+// are unused and may be nil.
+func (r *Ring) Reconciler(rec reconcile.Reconciler, reader client.Reader, obj client.Object) reconcile.Reconciler {
+	return &shardedReconciler{ring: r, reconciler: rec, reader: reader, obj: obj}
+}
+
+type shardedReconciler struct {
+	ring       *Ring
+	reconciler reconcile.Reconciler
+	reader     client.Reader
+	obj        client.Object
+}
+
+func (s *shardedReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	shard, found, err := s.shardForRequest(ctx, req)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !found {
+		return s.reconciler.Reconcile(ctx, req)
+	}
+
+	if !s.ring.enter(shard) {
+		logf.FromContext(ctx).V(1).Info("Skipping reconcile for object in shard owned by another replica", "shard", shard)
+		return reconcile.Result{}, nil
+	}
+	defer s.ring.exit(shard)
+	return s.reconciler.Reconcile(ctx, req)
+}
+
+func (s *shardedReconciler) shardForRequest(ctx context.Context, req reconcile.Request) (int, bool, error) {
+	if s.ring.label == "" {
+		return s.ring.shardForKey(req.NamespacedName), true, nil
+	}
+
+	obj, ok := s.obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return 0, false, fmt.Errorf("%T does not implement client.Object", s.obj)
+	}
+	if err := s.reader.Get(ctx, req.NamespacedName, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	shard, ok := s.ring.ShardFor(obj)
+	if !ok {
+		// The shard label was removed after the event was admitted, the object
+		// is no longer handled by any replica.
+		return -1, true, nil
+	}
+	return shard, true, nil
+}
+
+// resyncTarget is a queue of a controller that needs to be filled with the
+// objects of a shard once this replica acquires it.
+type resyncTarget struct {
+	reader client.Reader
+	list   client.ObjectList
+	queue  workqueue.TypedRateLimitingInterface[reconcile.Request]
+}
+
+// Source returns a source that enqueues all objects of a shard when this
+// replica acquires it. Events for these objects were filtered out by the
+// Predicate while the shard was owned by another replica, so without it
+// they would only be reconciled after the next resync.
+//
+// The objects are listed into a copy of list using the given reader, which
+// should be the cache the controller watches.
+func (r *Ring) Source(reader client.Reader, list client.ObjectList) source.Source {
+	return source.Func(func(ctx context.Context, queue workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.resyncs = append(r.resyncs, &resyncTarget{reader: reader, list: list, queue: queue})
+		return nil
+	})
+}
+
+// resync enqueues all objects of the given shard into the queues of the
+// controllers that use this ring.
+func (r *Ring) resync(ctx context.Context, shard int) {
+	r.mu.Lock()
+	targets := append([]*resyncTarget(nil), r.resyncs...)
+	r.mu.Unlock()
+
+	for _, target := range targets {
+		list, ok := target.list.DeepCopyObject().(client.ObjectList)
+		if !ok {
+			log.Error(fmt.Errorf("%T does not implement client.ObjectList", target.list), "Failed to resync shard", "ring", r.name, "shard", shard)
+			continue
+		}
+		if err := target.reader.List(ctx, list); err != nil {
+			log.Error(err, "Failed to resync shard", "ring", r.name, "shard", shard)
+			continue
+		}
+		if err := meta.EachListItem(list, func(o runtime.Object) error {
+			obj, ok := o.(client.Object)
+			if !ok {
+				return fmt.Errorf("%T does not implement client.Object", o)
+			}
+			if objShard, ok := r.ShardFor(obj); ok && objShard == shard {
+				target.queue.Add(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(obj)})
+			}
+			return nil
+		}); err != nil {
+			log.Error(err, "Failed to resync shard", "ring", r.name, "shard", shard)
+		}
+	}
+}
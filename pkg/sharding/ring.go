@@ -0,0 +1,628 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+)
+
+const (
+	// RingLabel is set on all Leases that belong to a ring. Its value is the name of the ring.
+	RingLabel = "sharding.controller-runtime.sigs.k8s.io/ring"
+
+	// ShardLabel is set on the Leases that guard the ownership of a shard. Its value is the
+	// number of the shard.
+	ShardLabel = "sharding.controller-runtime.sigs.k8s.io/shard"
+
+	defaultShards        = 16
+	defaultLeaseDuration = 15 * time.Second
+	defaultRenewPeriod   = 5 * time.Second
+	releaseTimeout       = 10 * time.Second
+)
+
+// Options are the arguments for creating a new Ring.
+type Options struct {
+	// Name is the name of the ring. All replicas that share the work for a
+	// controller must use the same name. It is used as prefix for the names
+	// of all Leases that belong to the ring.
+	Name string
+
+	// Namespace is the namespace the ring's Leases are created in.
+	Namespace string
+
+	// Identity uniquely identifies this replica within the ring.
+	// Defaults to the hostname, which is the pod name when running in-cluster.
+	Identity string
+
+	// Shards is the number of shards the objects are split into. It must be
+	// the same for all replicas and should be considerably larger than the
+	// number of replicas so that shards can be distributed evenly.
+	// Defaults to 16.
+	Shards int
+
+	// Label, if set, switches the ring to label mode: the shard of an object
+	// is read from the value of this label instead of being computed from a
+	// hash of its namespace and name. Objects without a valid value for the
+	// label are not handled by any replica.
+	Label string
+
+	// LeaseDuration is the duration after which a Lease that was not renewed
+	// is considered expired, and a replica that stopped renewing it is
+	// considered gone. Defaults to 15 seconds.
+	LeaseDuration time.Duration
+
+	// RenewPeriod is the interval at which the ring renews its Leases and
+	// rebalances shards. It must be smaller than LeaseDuration.
+	// Defaults to 5 seconds.
+	RenewPeriod time.Duration
+}
+
+// ApplyToCache restricts the cache to objects that carry the shard label of
+// a ring in label mode, for each of the given object types. It is a no-op in
+// hash mode, as all objects need to be cached to be able to take over any of
+// their shards.
+//
+// It is meant to be used when constructing the manager, before the Ring
+// itself can be created.
+func (o Options) ApplyToCache(opts *cache.Options, objs ...client.Object) error {
+	if o.Label == "" {
+		return nil
+	}
+	req, err := labels.NewRequirement(o.Label, selection.Exists, nil)
+	if err != nil {
+		return fmt.Errorf("invalid shard label %q: %w", o.Label, err)
+	}
+	if opts.ByObject == nil {
+		opts.ByObject = make(map[client.Object]cache.ByObject, len(objs))
+	}
+	for _, obj := range objs {
+		byObject := opts.ByObject[obj]
+		if byObject.Label == nil {
+			byObject.Label = labels.NewSelector()
+		}
+		byObject.Label = byObject.Label.Add(*req)
+		opts.ByObject[obj] = byObject
+	}
+	return nil
+}
+
+func (o Options) withDefaults() (Options, error) {
+	if o.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return o, fmt.Errorf("unable to default Identity: %w", err)
+		}
+		o.Identity = hostname
+	}
+	if o.Shards == 0 {
+		o.Shards = defaultShards
+	}
+	if o.LeaseDuration == 0 {
+		o.LeaseDuration = defaultLeaseDuration
+	}
+	if o.RenewPeriod == 0 {
+		o.RenewPeriod = defaultRenewPeriod
+	}
+	return o, nil
+}
+
+func (o Options) validate() error {
+	var errs []error
+	if o.Name == "" {
+		errs = append(errs, errors.New("Name must be set")) //nolint:stylecheck
+	}
+	if o.Namespace == "" {
+		errs = append(errs, errors.New("Namespace must be set")) //nolint:stylecheck
+	}
+	if o.Shards < 0 {
+		errs = append(errs, fmt.Errorf("Shards must not be negative, got %d", o.Shards)) //nolint:stylecheck
+	}
+	if o.RenewPeriod >= o.LeaseDuration {
+		errs = append(errs, fmt.Errorf("RenewPeriod %s must be smaller than LeaseDuration %s", o.RenewPeriod, o.LeaseDuration))
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(memberLeaseName(o.Name, o.Identity)) {
+		errs = append(errs, fmt.Errorf("Name and Identity must form a valid Lease name: %s", msg)) //nolint:stylecheck
+	}
+	if o.Label != "" {
+		for _, msg := range validation.IsQualifiedName(o.Label) {
+			errs = append(errs, fmt.Errorf("invalid Label %q: %s", o.Label, msg))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Ring distributes shards of objects across the replicas of a controller.
+// It implements manager.Runnable and must be added to the manager.
+type Ring struct {
+	name          string
+	namespace     string
+	identity      string
+	label         string
+	shards        int
+	leaseDuration time.Duration
+	renewPeriod   time.Duration
+
+	// reader is used to read Leases. It must not be backed by a cache, as
+	// ownership decisions must be based on the latest state of the Leases.
+	reader client.Reader
+	writer client.Writer
+	clock  clock.Clock
+
+	// mu guards members, held and resyncs.
+	mu      sync.Mutex
+	members []string
+	held    map[int]*shardState
+	resyncs []*resyncTarget
+
+	// exited is signaled when a reconcile of a draining shard exits, so that
+	// leave doesn't need to wait for the next renewal to release the shard.
+	exited chan struct{}
+}
+
+// shardState tracks a shard whose Lease is held by this replica.
+type shardState struct {
+	// draining is set once the shard was assigned to another replica. No new
+	// reconciles are started for a draining shard, and its Lease is released
+	// once inFlight drops to zero.
+	draining bool
+
+	// inFlight is the number of reconciles currently running for the shard.
+	inFlight int
+}
+
+// New returns a new Ring that uses the given cluster to read and write its Leases.
+// Leases are read through the cluster's APIReader and thus bypass the cache.
+func New(c cluster.Cluster, opts Options) (*Ring, error) {
+	return newRing(c.GetAPIReader(), c.GetClient(), clock.RealClock{}, opts)
+}
+
+func newRing(reader client.Reader, writer client.Writer, clk clock.Clock, opts Options) (*Ring, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	return &Ring{
+		name:          opts.Name,
+		namespace:     opts.Namespace,
+		identity:      opts.Identity,
+		label:         opts.Label,
+		shards:        opts.Shards,
+		leaseDuration: opts.LeaseDuration,
+		renewPeriod:   opts.RenewPeriod,
+		reader:        reader,
+		writer:        writer,
+		clock:         clk,
+		held:          make(map[int]*shardState),
+		exited:        make(chan struct{}, 1),
+	}, nil
+}
+
+// Start joins the ring and keeps rebalancing shards until the context is done.
+// Afterwards, the replica leaves the ring and releases all of its shards once
+// their reconciles are done, so that the remaining replicas can take over
+// without waiting for the Leases to expire.
+func (r *Ring) Start(ctx context.Context) error {
+	log.Info("Joining ring", "ring", r.name, "identity", r.identity, "shards", r.shards)
+	wait.JitterUntilWithContext(ctx, r.sync, r.renewPeriod, 0.1, true)
+
+	releaseCtx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	r.leave(releaseCtx)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. All replicas
+// need to participate in the ring.
+func (r *Ring) NeedLeaderElection() bool {
+	return false
+}
+
+// Members returns the identities of all replicas that were alive during the
+// last rebalancing, sorted alphabetically.
+func (r *Ring) Members() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.members...)
+}
+
+// OwnedShards returns the shards that are currently owned by this replica.
+func (r *Ring) OwnedShards() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	owned := make([]int, 0, len(r.held))
+	for shard, state := range r.held {
+		if !state.draining {
+			owned = append(owned, shard)
+		}
+	}
+	sort.Ints(owned)
+	return owned
+}
+
+// Owns returns true if this replica currently owns the given shard.
+func (r *Ring) Owns(shard int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.held[shard]
+	return ok && !state.draining
+}
+
+// ShardFor returns the shard of the given object. It returns false if the
+// ring is in label mode and the object doesn't carry a valid shard label.
+func (r *Ring) ShardFor(obj client.Object) (int, bool) {
+	if r.label == "" {
+		return r.shardForKey(client.ObjectKeyFromObject(obj)), true
+	}
+	value, ok := obj.GetLabels()[r.label]
+	if !ok {
+		return 0, false
+	}
+	shard, err := strconv.Atoi(value)
+	if err != nil || shard < 0 || shard >= r.shards {
+		return 0, false
+	}
+	return shard, true
+}
+
+func (r *Ring) shardForKey(key client.ObjectKey) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key.String()))
+	return int(h.Sum32() % uint32(r.shards)) //nolint:gosec // shards is a small positive number
+}
+
+// sync renews the membership of this replica and rebalances all shards.
+func (r *Ring) sync(ctx context.Context) {
+	if err := r.renewMembership(ctx); err != nil {
+		log.Error(err, "Failed to renew ring membership", "ring", r.name)
+		return
+	}
+	members, err := r.liveMembers(ctx)
+	if err != nil {
+		log.Error(err, "Failed to list ring members", "ring", r.name)
+		return
+	}
+
+	r.mu.Lock()
+	r.members = members
+	r.mu.Unlock()
+
+	for shard := 0; shard < r.shards; shard++ {
+		desired := assign(shard, members) == r.identity
+		if err := r.syncShard(ctx, shard, desired); err != nil {
+			log.Error(err, "Failed to sync shard", "ring", r.name, "shard", shard)
+		}
+	}
+}
+
+func (r *Ring) syncShard(ctx context.Context, shard int, desired bool) error {
+	r.mu.Lock()
+	state, held := r.held[shard]
+	if held {
+		// A shard might get assigned back to us while it's draining, for example when
+		// the member it was assigned to left again before it could take over.
+		state.draining = !desired
+	}
+	drained := held && state.draining && state.inFlight == 0
+	r.mu.Unlock()
+
+	switch {
+	case drained:
+		if err := r.releaseShard(ctx, shard); err != nil {
+			return err
+		}
+		r.mu.Lock()
+		delete(r.held, shard)
+		r.mu.Unlock()
+		log.V(1).Info("Released shard", "ring", r.name, "shard", shard)
+	case held:
+		// The Lease of a draining shard is renewed as well, so that it doesn't
+		// expire and get taken over while reconciles are still in flight.
+		acquired, err := r.acquireShard(ctx, shard)
+		if err != nil {
+			return err
+		}
+		if !acquired {
+			// Our Lease expired and another replica took over.
+			r.mu.Lock()
+			delete(r.held, shard)
+			r.mu.Unlock()
+			log.Info("Lost shard", "ring", r.name, "shard", shard)
+		}
+	case !held && desired:
+		acquired, err := r.acquireShard(ctx, shard)
+		if err != nil || !acquired {
+			return err
+		}
+		r.mu.Lock()
+		r.held[shard] = &shardState{}
+		r.mu.Unlock()
+		log.V(1).Info("Acquired shard", "ring", r.name, "shard", shard)
+		r.resync(ctx, shard)
+	}
+	return nil
+}
+
+// leave deletes the membership Lease of this replica and drains all of its
+// shards. As the manager stops the ring before the controllers, reconciles
+// might still be running, so the Leases are renewed and only released once
+// their shard has no reconciles in flight anymore, like in syncShard. The
+// Leases of shards that aren't drained before ctx is done are left to expire.
+func (r *Ring) leave(ctx context.Context) {
+	r.mu.Lock()
+	for _, state := range r.held {
+		state.draining = true
+	}
+	r.mu.Unlock()
+
+	lease := &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{
+		Namespace: r.namespace,
+		Name:      memberLeaseName(r.name, r.identity),
+	}}
+	if err := r.writer.Delete(ctx, lease); client.IgnoreNotFound(err) != nil {
+		log.Error(err, "Failed to leave ring", "ring", r.name)
+	}
+
+	for {
+		for _, shard := range r.heldShards() {
+			if err := r.syncShard(ctx, shard, false); err != nil {
+				log.Error(err, "Failed to release shard", "ring", r.name, "shard", shard)
+			}
+		}
+		shards := r.heldShards()
+		if len(shards) == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			log.Info("Timed out draining shards, leaving their Leases to expire", "ring", r.name, "shards", shards)
+			return
+		case <-r.exited:
+		case <-r.clock.After(r.renewPeriod):
+		}
+	}
+}
+
+// heldShards returns the shards whose Lease is held by this replica, including
+// draining ones.
+func (r *Ring) heldShards() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	shards := make([]int, 0, len(r.held))
+	for shard := range r.held {
+		shards = append(shards, shard)
+	}
+	sort.Ints(shards)
+	return shards
+}
+
+// enter registers a reconcile for the given shard. It returns false if the
+// shard is not owned by this replica, in which case the reconcile must be
+// skipped. Otherwise, exit must be called once the reconcile is done.
+func (r *Ring) enter(shard int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.held[shard]
+	if !ok || state.draining {
+		return false
+	}
+	state.inFlight++
+	return true
+}
+
+func (r *Ring) exit(shard int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if state, ok := r.held[shard]; ok {
+		state.inFlight--
+		if state.draining && state.inFlight == 0 {
+			select {
+			case r.exited <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func (r *Ring) renewMembership(ctx context.Context) error {
+	now := metav1.NewMicroTime(r.clock.Now())
+	lease := &coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: r.namespace, Name: memberLeaseName(r.name, r.identity)}
+	if err := r.reader.Get(ctx, key, lease); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		lease = r.newLease(key.Name, nil, now)
+		return r.writer.Create(ctx, lease)
+	}
+
+	lease.Spec.HolderIdentity = ptr.To(r.identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(r.leaseDuration.Seconds()))
+	lease.Spec.RenewTime = &now
+	return r.writer.Update(ctx, lease)
+}
+
+func (r *Ring) liveMembers(ctx context.Context) ([]string, error) {
+	leases := &coordinationv1.LeaseList{}
+	if err := r.reader.List(ctx, leases,
+		client.InNamespace(r.namespace),
+		client.MatchingLabels{RingLabel: r.name},
+	); err != nil {
+		return nil, err
+	}
+
+	members := []string{r.identity}
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		if _, isShard := lease.Labels[ShardLabel]; isShard {
+			continue
+		}
+		holder := ptr.Deref(lease.Spec.HolderIdentity, "")
+		if holder == "" || holder == r.identity || r.expired(lease) {
+			continue
+		}
+		members = append(members, holder)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// acquireShard acquires or renews the Lease of the given shard. It returns
+// false if the Lease is held by another replica.
+func (r *Ring) acquireShard(ctx context.Context, shard int) (bool, error) {
+	now := metav1.NewMicroTime(r.clock.Now())
+	lease := &coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: r.namespace, Name: shardLeaseName(r.name, shard)}
+	if err := r.reader.Get(ctx, key, lease); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		lease = r.newLease(key.Name, &shard, now)
+		if err := r.writer.Create(ctx, lease); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+
+	holder := ptr.Deref(lease.Spec.HolderIdentity, "")
+	if holder != "" && holder != r.identity && !r.expired(lease) {
+		return false, nil
+	}
+	if holder != r.identity {
+		lease.Spec.AcquireTime = &now
+		lease.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+	}
+	lease.Spec.HolderIdentity = ptr.To(r.identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(r.leaseDuration.Seconds()))
+	lease.Spec.RenewTime = &now
+	if err := r.writer.Update(ctx, lease); err != nil {
+		if apierrors.IsConflict(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// releaseShard clears the holder of the given shard's Lease so that the
+// replica it is assigned to can acquire it without waiting for it to expire.
+func (r *Ring) releaseShard(ctx context.Context, shard int) error {
+	lease := &coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: r.namespace, Name: shardLeaseName(r.name, shard)}
+	if err := r.reader.Get(ctx, key, lease); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != r.identity {
+		return nil
+	}
+	lease.Spec.HolderIdentity = nil
+	return r.writer.Update(ctx, lease)
+}
+
+func (r *Ring) newLease(name string, shard *int, now metav1.MicroTime) *coordinationv1.Lease {
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: r.namespace,
+			Name:      name,
+			Labels:    map[string]string{RingLabel: r.name},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To(r.identity),
+			LeaseDurationSeconds: ptr.To(int32(r.leaseDuration.Seconds())),
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		},
+	}
+	if shard != nil {
+		lease.Labels[ShardLabel] = strconv.Itoa(*shard)
+	}
+	return lease
+}
+
+func (r *Ring) expired(lease *coordinationv1.Lease) bool {
+	if lease.Spec.RenewTime == nil {
+		return true
+	}
+	duration := r.leaseDuration
+	if lease.Spec.LeaseDurationSeconds != nil {
+		duration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	}
+	return lease.Spec.RenewTime.Add(duration).Before(r.clock.Now())
+}
+
+// assign returns the member a shard is assigned to using rendezvous hashing,
+// which only moves the shards of a member that joins or leaves the ring.
+func assign(shard int, members []string) string {
+	var (
+		owner string
+		best  uint64
+	)
+	for _, member := range members {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(member + "/" + strconv.Itoa(shard)))
+		if score := mix(h.Sum64()); owner == "" || score > best {
+			owner, best = member, score
+		}
+	}
+	return owner
+}
+
+// mix is the finalizer of MurmurHash3. FNV alone doesn't spread inputs that
+// only differ in their first bytes well enough for rendezvous hashing.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+func memberLeaseName(ring, identity string) string {
+	return ring + "-member-" + identity
+}
+
+func shardLeaseName(ring string, shard int) string {
+	return ring + "-shard-" + strconv.Itoa(shard)
+}
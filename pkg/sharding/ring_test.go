@@ -0,0 +1,299 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Ring", func() {
+	var (
+		ctx context.Context
+		cl  client.Client
+		clk *clocktesting.FakeClock
+	)
+
+	newTestRing := func(identity string, mutate ...func(*Options)) *Ring {
+		opts := Options{Name: "test", Namespace: "default", Identity: identity, Shards: 8}
+		for _, m := range mutate {
+			m(&opts)
+		}
+		r, err := newRing(cl, cl, clk, opts)
+		Expect(err).NotTo(HaveOccurred())
+		return r
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cl = fake.NewClientBuilder().Build()
+		clk = clocktesting.NewFakeClock(time.Now())
+	})
+
+	It("should reject invalid options", func() {
+		_, err := newRing(cl, cl, clk, Options{Identity: "a"})
+		Expect(err).To(MatchError(ContainSubstring("Name must be set")))
+		Expect(err).To(MatchError(ContainSubstring("Namespace must be set")))
+
+		_, err = newRing(cl, cl, clk, Options{Name: "test", Namespace: "default", Identity: "Not_Valid"})
+		Expect(err).To(MatchError(ContainSubstring("valid Lease name")))
+
+		_, err = newRing(cl, cl, clk, Options{Name: "test", Namespace: "default", Identity: "a", RenewPeriod: time.Minute})
+		Expect(err).To(MatchError(ContainSubstring("must be smaller than LeaseDuration")))
+	})
+
+	It("should acquire all shards when it is the only member", func() {
+		a := newTestRing("a")
+		a.sync(ctx)
+
+		Expect(a.Members()).To(Equal([]string{"a"}))
+		Expect(a.OwnedShards()).To(Equal([]int{0, 1, 2, 3, 4, 5, 6, 7}))
+
+		lease := &coordinationv1.Lease{}
+		Expect(cl.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test-shard-3"}, lease)).To(Succeed())
+		Expect(lease.Spec.HolderIdentity).To(Equal(ptr.To("a")))
+		Expect(lease.Labels).To(HaveKeyWithValue(ShardLabel, "3"))
+	})
+
+	It("should hand off shards to a joining member without overlap", func() {
+		a := newTestRing("a")
+		b := newTestRing("b")
+
+		a.sync(ctx)
+		b.sync(ctx)
+		Expect(b.Members()).To(Equal([]string{"a", "b"}))
+		By("not acquiring shards that are still held by the other member")
+		Expect(b.OwnedShards()).To(BeEmpty())
+
+		a.sync(ctx)
+		b.sync(ctx)
+
+		Expect(a.OwnedShards()).NotTo(BeEmpty())
+		Expect(b.OwnedShards()).NotTo(BeEmpty())
+		for shard := 0; shard < 8; shard++ {
+			Expect(a.Owns(shard)).NotTo(Equal(b.Owns(shard)), "shard %d must be owned by exactly one member", shard)
+			Expect(a.Owns(shard)).To(Equal(assign(shard, []string{"a", "b"}) == "a"))
+		}
+	})
+
+	It("should not release a shard while a reconcile is in flight", func() {
+		a := newTestRing("a")
+		b := newTestRing("b")
+		a.sync(ctx)
+		b.sync(ctx)
+
+		var moved int
+		for shard := 0; shard < 8; shard++ {
+			if assign(shard, []string{"a", "b"}) == "b" {
+				moved = shard
+				break
+			}
+		}
+		Expect(a.enter(moved)).To(BeTrue())
+
+		a.sync(ctx)
+		By("draining the shard")
+		Expect(a.Owns(moved)).To(BeFalse())
+		Expect(a.enter(moved)).To(BeFalse())
+
+		b.sync(ctx)
+		Expect(b.Owns(moved)).To(BeFalse())
+
+		a.exit(moved)
+		a.sync(ctx)
+		b.sync(ctx)
+		Expect(b.Owns(moved)).To(BeTrue())
+	})
+
+	It("should keep renewing the lease of a draining shard while a reconcile outlives it", func() {
+		a := newTestRing("a")
+		b := newTestRing("b")
+		a.sync(ctx)
+		b.sync(ctx)
+
+		var moved int
+		for shard := 0; shard < 8; shard++ {
+			if assign(shard, []string{"a", "b"}) == "b" {
+				moved = shard
+				break
+			}
+		}
+		Expect(a.enter(moved)).To(BeTrue())
+		a.sync(ctx)
+		Expect(a.Owns(moved)).To(BeFalse())
+
+		By("letting the reconcile run for several lease durations")
+		for i := 0; i < 10; i++ {
+			clk.Step(5 * time.Second)
+			a.sync(ctx)
+			b.sync(ctx)
+			Expect(b.Owns(moved)).To(BeFalse(), "shard %d must not be taken over while it is reconciled", moved)
+		}
+
+		a.exit(moved)
+		a.sync(ctx)
+		b.sync(ctx)
+		Expect(b.Owns(moved)).To(BeTrue())
+	})
+
+	It("should take over the shards of a member whose lease expired", func() {
+		a := newTestRing("a")
+		b := newTestRing("b")
+		a.sync(ctx)
+		b.sync(ctx)
+		a.sync(ctx)
+		b.sync(ctx)
+
+		clk.Step(time.Minute)
+		b.sync(ctx)
+		Expect(b.Members()).To(Equal([]string{"b"}))
+		Expect(b.OwnedShards()).To(HaveLen(8))
+	})
+
+	It("should release all shards and leave the ring", func() {
+		a := newTestRing("a")
+		b := newTestRing("b")
+		a.sync(ctx)
+		b.sync(ctx)
+
+		a.leave(ctx)
+		Expect(a.OwnedShards()).To(BeEmpty())
+
+		b.sync(ctx)
+		Expect(b.Members()).To(Equal([]string{"b"}))
+		Expect(b.OwnedShards()).To(HaveLen(8))
+	})
+
+	It("should keep the lease of a shard with a reconcile in flight when stopped", func() {
+		a := newTestRing("a")
+		b := newTestRing("b")
+		a.sync(ctx)
+		Expect(a.OwnedShards()).To(HaveLen(8))
+		Expect(a.enter(3)).To(BeTrue())
+
+		ringCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- a.Start(ringCtx) }()
+		cancel()
+
+		holder := func(shard int) func() *string {
+			return func() *string {
+				lease := &coordinationv1.Lease{}
+				Expect(cl.Get(ctx, client.ObjectKey{Namespace: "default", Name: shardLeaseName("test", shard)}, lease)).To(Succeed())
+				return lease.Spec.HolderIdentity
+			}
+		}
+		Eventually(holder(0)).Should(BeNil())
+		Expect(holder(3)()).To(Equal(ptr.To("a")))
+		Consistently(done).ShouldNot(Receive())
+
+		b.sync(ctx)
+		Expect(b.Members()).To(Equal([]string{"b"}))
+		Expect(b.Owns(0)).To(BeTrue())
+		Expect(b.Owns(3)).To(BeFalse(), "shard 3 must not be taken over while it is reconciled")
+
+		a.exit(3)
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(holder(3)()).To(BeNil())
+		b.sync(ctx)
+		Expect(b.OwnedShards()).To(HaveLen(8))
+	})
+
+	Describe("ShardFor", func() {
+		It("should hash the namespace and name in hash mode", func() {
+			a := newTestRing("a")
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+			shard, ok := a.ShardFor(pod)
+			Expect(ok).To(BeTrue())
+			Expect(shard).To(Equal(a.shardForKey(types.NamespacedName{Namespace: "default", Name: "foo"})))
+		})
+
+		It("should read the shard label in label mode", func() {
+			a := newTestRing("a", func(o *Options) { o.Label = "example.com/shard" })
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"example.com/shard": "5"}}}
+			shard, ok := a.ShardFor(pod)
+			Expect(ok).To(BeTrue())
+			Expect(shard).To(Equal(5))
+
+			for _, value := range []string{"8", "-1", "foo"} {
+				pod.Labels["example.com/shard"] = value
+				_, ok := a.ShardFor(pod)
+				Expect(ok).To(BeFalse(), "value %q must not be valid", value)
+			}
+		})
+	})
+
+	It("should filter events and requests by shard ownership", func() {
+		a := newTestRing("a")
+		b := newTestRing("b")
+		a.sync(ctx)
+		b.sync(ctx)
+		a.sync(ctx)
+		b.sync(ctx)
+
+		var reconciled []string
+		rec := a.Reconciler(reconcile.Func(func(_ context.Context, req reconcile.Request) (reconcile.Result, error) {
+			reconciled = append(reconciled, req.Name)
+			return reconcile.Result{}, nil
+		}), nil, nil)
+		pred := a.Predicate()
+
+		for i := 0; i < 20; i++ {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("pod-%d", i)}}
+			shard, _ := a.ShardFor(pod)
+
+			Expect(pred.Create(event.CreateEvent{Object: pod})).To(Equal(a.Owns(shard)))
+
+			reconciled = nil
+			_, err := rec.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pod)})
+			Expect(err).NotTo(HaveOccurred())
+			if a.Owns(shard) {
+				Expect(reconciled).To(Equal([]string{pod.Name}))
+			} else {
+				Expect(reconciled).To(BeEmpty())
+			}
+		}
+	})
+
+	It("should restrict the cache to labelled objects in label mode", func() {
+		opts := &cache.Options{}
+		Expect(Options{}.ApplyToCache(opts, &corev1.Pod{})).To(Succeed())
+		Expect(opts.ByObject).To(BeEmpty())
+
+		pod := &corev1.Pod{}
+		Expect(Options{Label: "example.com/shard"}.ApplyToCache(opts, pod)).To(Succeed())
+		Expect(opts.ByObject[pod].Label.Matches(labels.Set{"example.com/shard": "1"})).To(BeTrue())
+		Expect(opts.ByObject[pod].Label.Matches(labels.Set{})).To(BeFalse())
+	})
+})
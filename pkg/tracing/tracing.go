@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing contains helpers to propagate OpenTelemetry trace context
+// through Kubernetes objects.
+//
+// Tracing is enabled by setting manager.Options.TracerProvider. The manager
+// then creates a span for every reconcile and every admission request, and
+// the client creates child spans for all calls made within them.
+//
+// As reconciles are triggered by changes to objects rather than by requests,
+// trace context can be handed to them through the Annotation on an object.
+// When a traced client reads an object carrying the annotation, the span of
+// the read and the span of the surrounding reconcile are linked to the trace
+// context stored in it.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Annotation is the annotation that stores trace context on objects, using
+// the W3C traceparent format.
+const Annotation = "tracing.controller-runtime.sigs.k8s.io/traceparent"
+
+var propagator = propagation.TraceContext{}
+
+// InjectIntoObject stores the trace context of the span in ctx in the
+// Annotation of obj. It returns false and leaves obj untouched if ctx
+// doesn't contain a valid span context.
+func InjectIntoObject(ctx context.Context, obj metav1.Object) bool {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	traceParent, ok := carrier["traceparent"]
+	if !ok {
+		return false
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[Annotation] = traceParent
+	obj.SetAnnotations(annotations)
+	return true
+}
+
+// SpanContextFromObject returns the span context stored in the Annotation of
+// obj. The returned span context is invalid if obj has no or a malformed
+// annotation.
+func SpanContextFromObject(obj metav1.Object) trace.SpanContext {
+	traceParent, ok := obj.GetAnnotations()[Annotation]
+	if !ok {
+		return trace.SpanContext{}
+	}
+	ctx := propagator.Extract(context.Background(), propagation.MapCarrier{"traceparent": traceParent})
+	return trace.SpanContextFromContext(ctx)
+}
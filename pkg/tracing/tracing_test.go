@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package tracing contains helpers to propagate OpenTelemetry trace context
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestObjectPropagation(t *testing.T) {
+	obj := &metav1.ObjectMeta{}
+	if InjectIntoObject(context.Background(), obj) {
+		t.Error("expected nothing to be injected without a span")
+	}
+	if SpanContextFromObject(obj).IsValid() {
+		t.Error("expected invalid span context without annotation")
+	}
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "test")
+	defer span.End()
+	if !InjectIntoObject(ctx, obj) {
+		t.Fatal("expected trace context to be injected")
+	}
+	if sc := SpanContextFromObject(obj); !sc.Equal(span.SpanContext().WithRemote(true)) {
+		t.Errorf("expected span context %v, got %v", span.SpanContext(), sc)
+	}
+
+	obj.Annotations[Annotation] = "invalid"
+	if SpanContextFromObject(obj).IsValid() {
+		t.Error("expected invalid span context for malformed annotation")
+	}
+}
@@ -24,14 +24,18 @@ import (
 	"sync"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gomodules.xyz/jsonpatch/v2"
 	admissionv1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/json"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/klog/v2"
 	admissionmetrics "sigs.k8s.io/controller-runtime/pkg/webhook/admission/metrics"
 
+	"sigs.k8s.io/controller-runtime/pkg/internal/tracing"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/internal/metrics"
 )
@@ -175,6 +179,15 @@ func (wh *Webhook) Handle(ctx context.Context, req Request) (response Response)
 		}
 	}()
 
+	ctx, span := startAdmissionSpan(ctx, req)
+	defer func() {
+		span.SetAttributes(
+			attribute.Bool("admission.allowed", response.Allowed),
+			attribute.Int("admission.patches", len(response.Patches)),
+		)
+		span.End()
+	}()
+
 	reqLog := wh.getLogger(&req)
 	ctx = logf.IntoContext(ctx, reqLog)
 
@@ -190,6 +203,37 @@ func (wh *Webhook) Handle(ctx context.Context, req Request) (response Response)
 	return resp
 }
 
+// startAdmissionSpan starts a span for the admission request, if ctx contains
+// a span, e.g. the one of a webhook server with a TracerProvider.
+func startAdmissionSpan(ctx context.Context, req Request) (context.Context, trace.Span) {
+	ctx, span := tracing.TracerFromContext(ctx).Start(ctx, fmt.Sprintf("admission %s %s", req.Operation, req.Kind.Kind))
+	if !span.IsRecording() {
+		return ctx, span
+	}
+	span.SetAttributes(tracing.GVKAttributes(schema.GroupVersionKind(req.Kind))...)
+	span.SetAttributes(
+		attribute.String("admission.uid", string(req.UID)),
+		attribute.String("admission.operation", string(req.Operation)),
+		attribute.Bool("admission.dry_run", req.DryRun != nil && *req.DryRun),
+		tracing.NamespaceKey.String(req.Namespace),
+		tracing.NameKey.String(req.Name),
+	)
+	if req.SubResource != "" {
+		span.SetAttributes(attribute.String("k8s.subresource", req.SubResource))
+	}
+
+	// Link the span to the trace context stored on the object, if any.
+	raw := req.Object.Raw
+	if len(raw) == 0 {
+		raw = req.OldObject.Raw
+	}
+	obj := &metav1.PartialObjectMetadata{}
+	if len(raw) > 0 && json.Unmarshal(raw, obj) == nil {
+		tracing.LinkObject(obj, span)
+	}
+	return ctx, span
+}
+
 // getLogger constructs a logger from the injected log and LogConstructor.
 func (wh *Webhook) getLogger(req *Request) logr.Logger {
 	wh.setupLogOnce.Do(func() {
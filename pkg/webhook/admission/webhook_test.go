@@ -25,15 +25,22 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 	"gomodules.xyz/jsonpatch/v2"
 	admissionv1 "k8s.io/api/admission/v1"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	machinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/utils/ptr"
 
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/tracing"
 )
 
 var _ = Describe("Admission Webhooks", func() {
@@ -295,3 +302,53 @@ var _ = Describe("admission.Request'i bağlama yazabilmeli/okuyabilmeli", func()
 	Expect(err).To(Not(HaveOccurred()))
 	Expect(gotRequest).To(Equal(testRequest))
 })
+
+var _ = Describe("Admission Webhook izleme", func() {
+	It("bağlamda bir span varsa admission isteği için alt span oluşturmalı", func() {
+		recorder := tracetest.NewSpanRecorder()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		ctx, parent := tp.Tracer("test").Start(context.Background(), "webhook")
+
+		By("izleme bağlamı içeren bir nesne hazırlamak")
+		origin, originSpan := tp.Tracer("test").Start(context.Background(), "origin")
+		obj := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+		Expect(tracing.InjectIntoObject(origin, obj)).To(BeTrue())
+		originSpan.End()
+		raw, err := json.Marshal(obj)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("webhook'u çağırmak")
+		webhook := &Webhook{Handler: HandlerFunc(func(ctx context.Context, req Request) Response {
+			return Allowed("")
+		})}
+		webhook.Handle(ctx, Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Namespace: "default",
+			Name:      "foo",
+			Object:    runtime.RawExtension{Raw: raw},
+		}})
+		parent.End()
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(3))
+		span := spans[1]
+		Expect(span.Name()).To(Equal("admission CREATE Pod"))
+		Expect(span.Parent().SpanID()).To(Equal(parent.SpanContext().SpanID()))
+		Expect(span.Attributes()).To(ContainElements(
+			attribute.String("k8s.kind", "Pod"),
+			attribute.String("k8s.namespace", "default"),
+			attribute.Bool("admission.allowed", true),
+		))
+		Expect(span.Links()).To(HaveLen(1))
+		Expect(span.Links()[0].SpanContext.SpanID()).To(Equal(originSpan.SpanContext().SpanID()))
+	})
+
+	It("bağlamda span yoksa span kaydetmemeli", func() {
+		webhook := &Webhook{Handler: HandlerFunc(func(ctx context.Context, req Request) Response {
+			Expect(trace.SpanFromContext(ctx).IsRecording()).To(BeFalse())
+			return Allowed("")
+		})}
+		Expect(webhook.Handle(context.Background(), Request{}).Allowed).To(BeTrue())
+	})
+})
@@ -29,9 +29,12 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/internal/httpserver"
+	"sigs.k8s.io/controller-runtime/pkg/internal/tracing"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/internal/metrics"
 )
 
@@ -101,6 +104,10 @@ type Options struct {
 
 	// WebhookMux is the multiplexer that handles different webhooks.
 	WebhookMux *http.ServeMux
+
+	// TracerProvider, if set, is used to create a span for every webhook
+	// request. The span continues the trace context sent by the API server.
+	TracerProvider trace.TracerProvider
 }
 
 // NewServer constructs a new webhook.Server from the provided options.
@@ -177,7 +184,7 @@ func (s *DefaultServer) Register(path string, hook http.Handler) {
 		panic(fmt.Errorf("can't register duplicate path: %v", path))
 	}
 	s.webhooks[path] = hook
-	s.webhookMux.Handle(path, metrics.InstrumentedHook(path, hook))
+	s.webhookMux.Handle(path, metrics.InstrumentedHook(path, tracing.Handler(s.Options.TracerProvider, "webhook "+path, hook)))
 
 	regLog := log.WithValues("path", path)
 	regLog.Info("Registering webhook")
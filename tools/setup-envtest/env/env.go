@@ -64,6 +64,10 @@ type Env struct {
 	// Out is the place to write output text to
 	Out io.Writer
 
+	// ExtraBinaries are binaries that aren't part of the envtest archives
+	// (e.g. kube-controller-manager), to download alongside them.
+	ExtraBinaries []string
+
 	// manualPath is the manually discovered path from PathMatches, if
 	// a non-store path was used.  It'll be printed by PrintInfo if present.
 	manualPath string
@@ -318,6 +322,57 @@ func (e *Env) Fetch(ctx context.Context) {
 	}
 }
 
+// EnsureExtraBinaries ensures that the ExtraBinaries are on disk next to
+// the binaries of our current version & platform, downloading the missing
+// ones.
+//
+// Must be called once our version & platform are on disk (i.e. after
+// ExistsAndValid returned true or after Fetch).
+func (e *Env) EnsureExtraBinaries(ctx context.Context) {
+	log := e.Log.WithName("fetch")
+
+	for _, name := range e.ExtraBinaries {
+		exists, err := e.Store.HasBinary(e.item(), name)
+		if err != nil {
+			ExitCause(2, err, "unable to check if %s exists", name)
+		}
+		if exists {
+			continue
+		}
+		if e.NoDownload {
+			Exit(2, "%s is missing for version %s (%s) on disk, and downloads are disabled", name, e.Version, e.Platform)
+		}
+		e.fetchBinary(ctx, log, name)
+	}
+}
+
+// fetchBinary downloads a single binary into the store.
+func (e *Env) fetchBinary(ctx context.Context, log logr.Logger, name string) {
+	binaryOut, err := e.FS.TempFile("", "*-"+name)
+	if err != nil {
+		ExitCause(2, err, "unable to open file to write downloaded %s to", name)
+	}
+	defer binaryOut.Close()
+	tmpPath := binaryOut.Name()
+	defer func() {
+		if err := e.FS.Remove(tmpPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			// don't bail, this isn't fatal
+			log.Error(err, "unable to remove downloaded binary", "path", tmpPath)
+		}
+	}()
+
+	log.V(1).Info("downloading binary", "name", name, "path", tmpPath)
+	if err := e.Client.GetBinary(ctx, *e.Version.AsConcrete(), e.Platform.Platform, name, e.VerifySum, binaryOut); err != nil {
+		ExitCause(2, err, "unable to download %s", name)
+	}
+	if _, err := binaryOut.Seek(0, 0); err != nil {
+		ExitCause(2, err, "unable to jump back to beginning of downloaded %s", name)
+	}
+	if err := e.Store.AddBinary(ctx, e.item(), name, binaryOut); err != nil {
+		ExitCause(2, err, "unable to store %s to disk", name)
+	}
+}
+
 // cleanup on error cleans up if we hit an exitCode error.
 //
 // Use it in a defer.
@@ -51,6 +51,11 @@ var (
 		"directory to store binary assets (default: $OS_SPECIFIC_DATA_DIR/envtest-binaries)")
 
 	index = flag.String("index", remote.DefaultIndexURL, "index to discover envtest binaries")
+
+	controllerManager = flag.Bool("controller-manager", false,
+		"also download kube-controller-manager, which isn't part of the envtest archives, "+
+			"to run kube-controller-manager controllers in envtest")
+	binaryURL = flag.String("binary-url", remote.DefaultBinaryURL, "URL template to download binaries that aren't part of the envtest archives from")
 )
 
 // TODO(directxman12): handle interrupts?
@@ -80,8 +85,9 @@ func setupEnv(globalLog logr.Logger, version string) *envp.Env {
 	log.V(1).Info("using binaries directory", "dir", *binDir)
 
 	client := &remote.HTTPClient{
-		Log:      globalLog.WithName("storage-client"),
-		IndexURL: *index,
+		Log:       globalLog.WithName("storage-client"),
+		IndexURL:  *index,
+		BinaryURL: *binaryURL,
 	}
 	log.V(1).Info("using HTTP client", "index", *index)
 
@@ -101,6 +107,9 @@ func setupEnv(globalLog logr.Logger, version string) *envp.Env {
 		Store: store.NewAt(*binDir),
 		Out:   os.Stdout,
 	}
+	if *controllerManager {
+		env.ExtraBinaries = append(env.ExtraBinaries, "kube-controller-manager")
+	}
 
 	switch version {
 	case "", "latest":
@@ -165,6 +174,9 @@ Examples:
 	# sideload a pre-downloaded tarball as Kubernetes 1.16.2 into our store
 	%[1]s sideload 1.16.2 < downloaded-envtest.tar.gz
 
+	# download the latest envtest along with kube-controller-manager
+	%[1]s use --controller-manager
+
 Commands:
 
 	use:
@@ -17,4 +17,6 @@ type Client interface {
 	GetVersion(ctx context.Context, version versions.Concrete, platform versions.PlatformItem, out io.Writer) error
 
 	FetchSum(ctx context.Context, ver versions.Concrete, pl *versions.PlatformItem) error
+
+	GetBinary(ctx context.Context, version versions.Concrete, platform versions.Platform, name string, verify bool, out io.Writer) error
 }
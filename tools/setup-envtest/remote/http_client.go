@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"net/url"
 	"sort"
+	"strings"
+	"text/template"
 
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/tools/setup-envtest/versions"
@@ -19,6 +21,12 @@ import (
 // DefaultIndexURL, HTTPClient içinde kullanılan varsayılan indekstir.
 var DefaultIndexURL = "https://raw.githubusercontent.com/kubernetes-sigs/controller-tools/HEAD/envtest-releases.yaml"
 
+// DefaultBinaryURL, envtest arşivlerinde bulunmayan tekil ikili dosyaların
+// (ör. kube-controller-manager) indirildiği varsayılan URL şablonudur. {{ .Version }},
+// {{ .OS }}, {{ .Arch }} ve {{ .Name }} ile doldurulur. Dosyanın sha512 hash değeri
+// aynı URL'nin ".sha512" uzantılı halinden okunur.
+var DefaultBinaryURL = "https://dl.k8s.io/release/v{{ .Version }}/bin/{{ .OS }}/{{ .Arch }}/{{ .Name }}"
+
 var _ Client = &HTTPClient{}
 
 // HTTPClient, envtest ikili arşivlerinin sürümlerini bir indeks üzerinden HTTP ile almak için kullanılan bir istemcidir.
@@ -28,6 +36,9 @@ type HTTPClient struct {
 
 	// IndexURL, indeksin URL'sidir, varsayılan olarak DefaultIndexURL kullanılır.
 	IndexURL string
+
+	// BinaryURL, tekil ikili dosyaların URL şablonudur, varsayılan olarak DefaultBinaryURL kullanılır.
+	BinaryURL string
 }
 
 // Index, envtest ikili arşivlerinin bir indeksini temsil eder. Örnek:
@@ -170,6 +181,78 @@ func (c *HTTPClient) FetchSum(ctx context.Context, version versions.Concrete, pl
 	return fmt.Errorf("arşiv bulunamadı %s (%s,%s)", version, platform.OS, platform.Arch)
 }
 
+// GetBinary, envtest arşivlerinde bulunmayan verilen ikili dosyayı belirtilen sürüm
+// ve platform için indirir ve çıktıya yazar. verify ayarlanmışsa dosya, yayınlanan
+// sha512 hash değeriyle doğrulanır.
+func (c *HTTPClient) GetBinary(ctx context.Context, version versions.Concrete, platform versions.Platform, name string, verify bool, out io.Writer) error {
+	binaryURL := c.BinaryURL
+	if binaryURL == "" {
+		binaryURL = DefaultBinaryURL
+	}
+	tmpl, err := template.New("binary-url").Parse(binaryURL)
+	if err != nil {
+		return fmt.Errorf("ikili dosya URL şablonu parse edilemedi: %w", err)
+	}
+	var loc strings.Builder
+	if err := tmpl.Execute(&loc, struct{ Version, OS, Arch, Name string }{
+		Version: version.String(), OS: platform.OS, Arch: platform.Arch, Name: name,
+	}); err != nil {
+		return fmt.Errorf("ikili dosya URL şablonu işlenemedi: %w", err)
+	}
+
+	item := versions.PlatformItem{Platform: platform}
+	if verify {
+		sum, err := c.get(ctx, loc.String()+".sha512", name+".sha512")
+		if err != nil {
+			return err
+		}
+		fields := strings.Fields(string(sum))
+		if len(fields) == 0 {
+			return fmt.Errorf("%s için hash değeri boş", name)
+		}
+		item.Hash = &versions.Hash{
+			Type:     versions.SHA512HashType,
+			Encoding: versions.HexHashEncoding,
+			Value:    fields[0],
+		}
+	}
+
+	c.Log.V(1).Info("ikili dosya indiriliyor", "name", name, "url", loc.String())
+	req, err := http.NewRequestWithContext(ctx, "GET", loc.String(), nil)
+	if err != nil {
+		return fmt.Errorf("istek oluşturulamadı %s: %w", name, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("alınamadı %s (%s): %w", name, req.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("alınamadı %s (%s) -- durum %q", name, req.URL, resp.Status)
+	}
+
+	return readBody(resp, out, name, item)
+}
+
+// get, verilen URL'nin içeriğini tamamen okur.
+func (c *HTTPClient) get(ctx context.Context, loc, name string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", loc, nil)
+	if err != nil {
+		return nil, fmt.Errorf("istek oluşturulamadı %s: %w", name, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("alınamadı %s (%s): %w", name, req.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("alınamadı %s (%s) -- durum %q", name, req.URL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
 func (c *HTTPClient) getIndex(ctx context.Context) (*Index, error) {
 	indexURL := c.IndexURL
 	if indexURL == "" {
@@ -195,6 +195,68 @@ func (s *Store) Add(ctx context.Context, item Item, contents io.Reader) (resErr
 	return nil
 }
 
+// HasBinary checks if the given binary is present in this item.
+func (s *Store) HasBinary(item Item, name string) (bool, error) {
+	_, err := s.unpackedPath(item.dirName()).Stat(name)
+	if errors.Is(err, afero.ErrFileNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// AddBinary adds a single binary to an item that's already in the store,
+// e.g. one that isn't part of the envtest archives.
+func (s *Store) AddBinary(ctx context.Context, item Item, name string, contents io.Reader) (resErr error) {
+	log, err := logr.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	itemName := item.dirName()
+	log = log.WithValues("version-platform", itemName, "binary", name)
+	itemPath := s.unpackedPath(itemName)
+
+	log.V(1).Info("switching version-platform directory to writable")
+	if err := itemPath.Chmod("", 0755); err != nil {
+		return fmt.Errorf("unable to make version-platform binaries dir %s writable: %w", itemName, err)
+	}
+	defer func() {
+		log.V(1).Info("switching version-platform directory to read-only")
+		if err := itemPath.Chmod("", 0555); err != nil {
+			// don't bail, this isn't fatal
+			log.Error(err, "unable to make version-platform directory read-only")
+		}
+	}()
+
+	// write to a temporary file first, so that we never leave a partial binary behind.
+	tmpPath := name + ".tmp"
+	binOut, err := itemPath.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0555)
+	if err != nil {
+		return fmt.Errorf("unable to create file %s for version-platform pair %s", name, itemName)
+	}
+	defer func() {
+		if resErr != nil {
+			if err := itemPath.Remove(tmpPath); err != nil && !errors.Is(err, afero.ErrFileNotFound) {
+				log.Error(err, "unable to clean up partially written binary")
+			}
+		}
+	}()
+	if err := func() error { // IIFE to close the file before renaming it
+		defer binOut.Close()
+		if _, err := io.Copy(binOut, contents); err != nil {
+			return fmt.Errorf("unable to write file %s to disk for version-platform pair %s", name, itemName)
+		}
+		return nil
+	}(); err != nil {
+		return err
+	}
+	if err := itemPath.Rename(tmpPath, name); err != nil {
+		return fmt.Errorf("unable to move file %s into place for version-platform pair %s: %w", name, itemName, err)
+	}
+	log.V(1).Info("added binary")
+	return nil
+}
+
 // Remove removes all items matching the given filter.
 //
 // It returns a list of the successfully removed items (even in the case
@@ -33,6 +33,7 @@ func (f Use) Do(env *envp.Env) {
 	}
 	env.EnsureVersionIsSet(ctx)
 	if env.ExistsAndValid() {
+		env.EnsureExtraBinaries(ctx)
 		env.PrintInfo(f.PrintFormat)
 		return
 	}
@@ -40,6 +41,7 @@ func (f Use) Do(env *envp.Env) {
 		envp.Exit(2, "Bu mimari (%s) için disk üzerinde böyle bir sürüm (%s) yok -- disk üzerinde ne olduğunu görmek için `list -i` komutunu çalıştırmayı deneyin", env.Version, env.Platform)
 	}
 	env.Fetch(ctx)
+	env.EnsureExtraBinaries(ctx)
 	env.PrintInfo(f.PrintFormat)
 }
 
@@ -5,8 +5,11 @@ package workflows_test
 
 import (
 	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
 	"fmt"
 	"io/fs"
+	"net/http"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -272,6 +275,49 @@ var _ = Describe("Workflows", func() {
 				flow.Do(env)
 			})
 		})
+
+		Describe("extra binaries", func() {
+			var binaryPath string
+			contents := []byte("kube-controller-manager contents")
+			BeforeEach(func() {
+				env.ExtraBinaries = []string{"kube-controller-manager"}
+				env.Client.(*remote.HTTPClient).BinaryURL = fmt.Sprintf("http://%s/v{{ .Version }}/{{ .OS }}/{{ .Arch }}/{{ .Name }}", server.Addr())
+				binaryPath = filepath.Join(testStorePath, "k8s", "1.16.0-linux-amd64", "kube-controller-manager")
+				server.RouteToHandler("GET", "/v1.16.0/linux/amd64/kube-controller-manager", ghttp.RespondWith(http.StatusOK, contents))
+			})
+
+			It("should download missing extra binaries next to the envtest binaries", func() {
+				sum := sha512.Sum512(contents)
+				server.RouteToHandler("GET", "/v1.16.0/linux/amd64/kube-controller-manager.sha512", ghttp.RespondWith(http.StatusOK, hex.EncodeToString(sum[:])))
+
+				flow.Do(env)
+				Expect(env.FS.ReadFile(binaryPath)).To(Equal(contents))
+			})
+
+			It("should not download extra binaries that are already present", func() {
+				server.Close() // confirm no network
+				Expect(env.FS.WriteFile(binaryPath, []byte("present"), 0555)).To(Succeed())
+
+				flow.Do(env)
+				Expect(env.FS.ReadFile(binaryPath)).To(Equal([]byte("present")))
+			})
+
+			It("should fail if the downloaded hash doesn't match", func() {
+				server.RouteToHandler("GET", "/v1.16.0/linux/amd64/kube-controller-manager.sha512", ghttp.RespondWith(http.StatusOK, "nottherightone!"))
+
+				defer func() {
+					Expect(env.FS.Exists(binaryPath)).To(BeFalse())
+				}()
+				defer shouldHaveError()
+				flow.Do(env)
+			})
+
+			It("should fail if extra binaries are missing and downloads are disabled", func() {
+				env.NoDownload = true
+				defer shouldHaveError()
+				flow.Do(env)
+			})
+		})
 	})
 
 	Describe("list", func() {